@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/loft-sh/devspace/cmd/flags"
 	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/services/terminal"
 	"github.com/loft-sh/devspace/pkg/util/factory"
+	"github.com/loft-sh/devspace/pkg/util/log"
 	"github.com/spf13/cobra"
 )
 
@@ -29,9 +33,38 @@ func NewDevCmd(f factory.Factory, globalFlags *flags.GlobalFlags, rawConfig *Raw
 Starts your project in development mode
 #######################################################`,
 		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			warnStaleTerminalSessions(f.GetLog())
 			return cmd.Run(cobraCmd, args, f, "devCommand")
 		},
 	}
 	cmd.AddPipelineFlags(f, devCmd, pipeline)
 	return devCmd
 }
+
+// warnStaleTerminalSessions warns about terminal sessions that were recorded by DevSpace processes
+// that are not running anymore, e.g. because they crashed, and removes them from the session store
+func warnStaleTerminalSessions(logger log.Logger) {
+	store, err := terminal.NewDefaultSessionStore()
+	if err != nil {
+		logger.Debugf("Error creating terminal session store: %v", err)
+		return
+	}
+
+	stale, err := store.Stale()
+	if err != nil {
+		logger.Debugf("Error reading terminal session store: %v", err)
+		return
+	}
+
+	ids := make([]string, 0, len(stale))
+	for _, record := range stale {
+		logger.Warnf("Terminal session to pod %s container %s in namespace %s was not closed cleanly, the DevSpace process (pid %d) that opened it at %s is not running anymore", record.Pod, record.Container, record.Namespace, record.PID, record.StartedAt.Local().Format(time.RFC3339))
+		ids = append(ids, record.ID)
+	}
+	if len(ids) > 0 {
+		err = store.Remove(ids...)
+		if err != nil {
+			logger.Debugf("Error removing stale terminal sessions from %s: %v", store.Path(), err)
+		}
+	}
+}
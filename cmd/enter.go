@@ -21,20 +21,39 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// timeoutExitCode is the exit code of enter if the terminal was closed due to a timeout
+const timeoutExitCode = 124
+
 // EnterCmd is a struct that defines a command call for "enter"
 type EnterCmd struct {
 	*flags.GlobalFlags
 
-	LabelSelector string
-	ImageSelector string
-	Container     string
-	Pod           string
-	Pick          bool
-	TTY           bool
-	Wait          bool
-	Reconnect     bool
-	Screen        bool
-	ScreenSession string
+	LabelSelector    string
+	ImageSelector    string
+	Container        string
+	Pod              string
+	StatefulSet      string
+	Ordinal          int
+	Pick             bool
+	TTY              bool
+	StdinFromTTY     bool
+	Wait             bool
+	Reconnect        bool
+	Resume           bool
+	Screen           bool
+	ScreenSession    string
+	FirstReady       []string
+	NativeKubectl    bool
+	AvoidScalingDown bool
+	Follow           bool
+	Namespaces       []string
+	AllNamespaces    bool
+	CheckRBAC        bool
+
+	EphemeralContainer bool
+	EphemeralImage     string
+	ImagePullPolicy    string
+	MountSharedVolume  bool
 
 	WorkingDirectory string
 
@@ -63,9 +82,13 @@ devspace enter --pick # Select pod to enter
 devspace enter bash
 devspace enter -c my-container
 devspace enter bash -n my-namespace
+devspace enter bash -l app=api --namespaces dev,staging
+devspace enter bash -l app=api --all-namespaces
 devspace enter bash -l release=test
+devspace enter --first-ready app=api --first-ready app=worker
 devspace enter bash --image-selector nginx:latest
 devspace enter bash --image-selector "${runtime.images.app.image}:${runtime.images.app.tag}"
+DEVSPACE_CONTAINER=my-pod/my-container devspace enter bash # Skip the container selection
 #######################################################`,
 		RunE: func(cobraCmd *cobra.Command, args []string) error {
 			plugin.SetPluginCommand(cobraCmd, args)
@@ -77,14 +100,29 @@ devspace enter bash --image-selector "${runtime.images.app.image}:${runtime.imag
 	enterCmd.Flags().StringVar(&cmd.Pod, "pod", "", "Pod to open a shell to")
 	enterCmd.Flags().StringVarP(&cmd.LabelSelector, "label-selector", "l", "", "Comma separated key=value selector list (e.g. release=test)")
 	enterCmd.Flags().StringVar(&cmd.ImageSelector, "image-selector", "", "The image to search a pod for (e.g. nginx, nginx:latest, ${runtime.images.app}, nginx:${runtime.images.app.tag})")
+	enterCmd.Flags().StringVar(&cmd.StatefulSet, "statefulset", "", "The statefulset to open the terminal to the replica with the given --ordinal of")
+	enterCmd.Flags().IntVar(&cmd.Ordinal, "ordinal", 0, "The ordinal of the statefulset replica, e.g. 2 for the pod <statefulset>-2")
 	enterCmd.Flags().StringVar(&cmd.WorkingDirectory, "workdir", "", "The working directory where to open the terminal or execute the command")
 
 	enterCmd.Flags().BoolVar(&cmd.TTY, "tty", true, "If to use a tty to start the command")
+	enterCmd.Flags().BoolVar(&cmd.StdinFromTTY, "stdin-from-tty", false, "Allocate a tty even if stdin is not a terminal, e.g. for expect scripts. The output might be garbled if the remote shell detects that its input isn't a terminal")
 	enterCmd.Flags().BoolVar(&cmd.Pick, "pick", true, "Select a pod / container if multiple are found")
 	enterCmd.Flags().BoolVar(&cmd.Wait, "wait", false, "Wait for the pod(s) to start if they are not running")
 	enterCmd.Flags().BoolVar(&cmd.Reconnect, "reconnect", false, "Will reconnect the terminal if an unexpected return code is encountered")
+	enterCmd.Flags().BoolVar(&cmd.Follow, "follow", false, "Reconnect the terminal to the pod that replaces the selected pod, e.g. after a rollout")
+	enterCmd.Flags().BoolVar(&cmd.Resume, "resume", false, "Skip the output that was already printed when reconnecting a command without tty. The command has to produce the same output again, e.g. cat of a log file")
+	enterCmd.Flags().BoolVar(&cmd.AvoidScalingDown, "avoid-scaling-down", false, "Skip pods that are about to be removed, because their replica set is scaled down")
+	enterCmd.Flags().BoolVar(&cmd.CheckRBAC, "check-rbac", false, "Check if you are allowed to exec into the pod before connecting to it")
+	enterCmd.Flags().BoolVar(&cmd.NativeKubectl, "native-kubectl", false, "Execute the command with the kubectl binary in PATH instead of the builtin client, e.g. if a proxy rejects SPDY connections")
 	enterCmd.Flags().BoolVar(&cmd.Screen, "screen", false, "Use a screen session to connect")
 	enterCmd.Flags().StringVar(&cmd.ScreenSession, "screen-session", "enter", "The screen session to create or connect to")
+	enterCmd.Flags().BoolVar(&cmd.EphemeralContainer, "ephemeral-container", false, "Launch an ephemeral container alongside the selected container and open the terminal to it")
+	enterCmd.Flags().StringVar(&cmd.EphemeralImage, "ephemeral-image", "", "The image of the ephemeral container, defaults to "+terminal.DefaultEphemeralImage)
+	enterCmd.Flags().StringVar(&cmd.ImagePullPolicy, "image-pull-policy", string(terminal.DefaultEphemeralContainerPullPolicy), "The image pull policy of the ephemeral container")
+	enterCmd.Flags().BoolVar(&cmd.MountSharedVolume, "mount-shared-volume", false, "Mount a shared emptyDir volume into the ephemeral and the selected container, this patches the workload of the pod after confirmation until the terminal ends")
+	enterCmd.Flags().StringSliceVar(&cmd.Namespaces, "namespaces", []string{}, "Comma separated namespaces to search the pod in, the pod can be picked if multiple are found")
+	enterCmd.Flags().BoolVarP(&cmd.AllNamespaces, "all-namespaces", "A", false, "Search the pod in all namespaces, the pod can be picked if multiple are found")
+	enterCmd.Flags().StringArrayVar(&cmd.FirstReady, "first-ready", []string{}, "Label selectors in order of priority, the terminal is opened to the first running container matched by one of them")
 
 	return enterCmd
 }
@@ -144,6 +182,18 @@ func (cmd *EnterCmd) Run(f factory.Factory, args []string) error {
 		WithPick(cmd.Pick).
 		WithWait(cmd.Wait).
 		WithQuestion("Which pod do you want to open the terminal for?")
+	if cmd.StatefulSet != "" {
+		if cmd.Pod != "" {
+			return fmt.Errorf("--pod and --statefulset cannot be used together")
+		}
+
+		selectorOptions = selectorOptions.WithStatefulSetOrdinal(cmd.StatefulSet, cmd.Ordinal)
+	}
+	if cmd.AllNamespaces {
+		selectorOptions = selectorOptions.WithAllNamespaces()
+	} else if len(cmd.Namespaces) > 0 {
+		selectorOptions = selectorOptions.WithNamespaces(cmd.Namespaces...)
+	}
 	if cmd.Wait {
 		selectorOptions = selectorOptions.WithContainerFilter(selector.FilterTerminatingContainers)
 		selectorOptions = selectorOptions.WithWaitingStrategy(targetselector.NewUntilNewestRunningWaitingStrategy(time.Second))
@@ -160,8 +210,65 @@ func (cmd *EnterCmd) Run(f factory.Factory, args []string) error {
 
 	// Start terminal
 	stdout, stderr, stdin := defaultStdStreams(cmd.Stdout, cmd.Stderr, cmd.Stdin)
-	exitCode, err := terminal.StartTerminalFromCMD(ctx, targetselector.NewTargetSelector(selectorOptions), command, cmd.Wait, cmd.Reconnect, cmd.TTY, cmd.Screen, cmd.ScreenSession, stdout, stderr, stdin)
+	restart := 0
+	if cmd.Reconnect {
+		restart = terminal.RestartUnlimited
+	}
+	terminalOptions := []terminal.OptionFunc{
+		terminal.WithCommand(command),
+		terminal.WithRestart(restart),
+		terminal.WithTTY(cmd.TTY),
+		terminal.WithForceTTY(cmd.StdinFromTTY),
+		terminal.WithScreen(cmd.Screen, cmd.ScreenSession),
+		terminal.WithStreams(stdout, stderr, stdin),
+		terminal.WithCheckRBAC(cmd.CheckRBAC),
+		terminal.WithQuiet(cmd.Silent),
+	}
+	sessionStore, err := terminal.NewDefaultSessionStore()
 	if err != nil {
+		logger.Debugf("Error creating terminal session store: %v", err)
+	} else {
+		terminalOptions = append(terminalOptions, terminal.WithSessionStore(sessionStore))
+	}
+	if cmd.Resume {
+		terminalOptions = append(terminalOptions, terminal.WithResume(nil))
+	}
+	if cmd.Follow {
+		terminalOptions = append(terminalOptions, terminal.WithFollowPodLifecycle(0))
+	}
+	if cmd.AvoidScalingDown {
+		terminalOptions = append(terminalOptions, terminal.WithAvoidScalingDownPods(true))
+	}
+	if cmd.NativeKubectl {
+		terminalOptions = append(terminalOptions, terminal.WithNativeKubectl(false))
+	}
+	if cmd.EphemeralContainer {
+		terminalOptions = append(terminalOptions, terminal.WithEphemeralContainer(terminal.EphemeralContainerOptions{
+			Image:             cmd.EphemeralImage,
+			ImagePullPolicy:   cmd.ImagePullPolicy,
+			MountSharedVolume: cmd.MountSharedVolume,
+		}))
+	}
+
+	var exitCode int
+	if len(cmd.FirstReady) > 0 {
+		prioritySelectors := []targetselector.Options{}
+		for _, labelSelector := range cmd.FirstReady {
+			prioritySelectors = append(prioritySelectors, selectorOptions.WithLabelSelector(labelSelector))
+		}
+
+		exitCode, err = terminal.StartTerminalToFirstReady(ctx, prioritySelectors, terminal.NewTerminalOptions(terminalOptions...))
+	} else {
+		exitCode, err = terminal.StartTerminalFromCMDWithOptions(ctx, targetselector.NewTargetSelector(selectorOptions), terminal.NewTerminalOptions(terminalOptions...))
+	}
+	if errors.Is(err, terminal.ErrClosedByUser) {
+		return nil
+	} else if errors.Is(err, terminal.ErrClosedByTimeout) {
+		// the reason was already logged by the terminal, so the command exits like timeout(1)
+		return &exit.ReturnCodeError{
+			ExitCode: timeoutExitCode,
+		}
+	} else if err != nil {
 		return err
 	} else if exitCode != 0 {
 		return &exit.ReturnCodeError{
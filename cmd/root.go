@@ -31,6 +31,7 @@ import (
 	"github.com/loft-sh/devspace/cmd/remove"
 	"github.com/loft-sh/devspace/cmd/reset"
 	"github.com/loft-sh/devspace/cmd/set"
+	"github.com/loft-sh/devspace/cmd/terminal"
 	"github.com/loft-sh/devspace/cmd/update"
 	"github.com/loft-sh/devspace/cmd/use"
 	"github.com/loft-sh/devspace/pkg/devspace/config/loader/variable"
@@ -270,6 +271,7 @@ Additional run commands:
 	rootCmd.AddCommand(remove.NewRemoveCmd(f, globalFlags, plugins))
 	rootCmd.AddCommand(reset.NewResetCmd(f, globalFlags, plugins))
 	rootCmd.AddCommand(set.NewSetCmd(f, globalFlags, plugins))
+	rootCmd.AddCommand(terminal.NewTerminalCmd(f, globalFlags, plugins))
 	rootCmd.AddCommand(use.NewUseCmd(f, globalFlags, plugins))
 	rootCmd.AddCommand(update.NewUpdateCmd(f, globalFlags, plugins))
 
@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"context"
+
+	"github.com/loft-sh/devspace/cmd/flags"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/loft-sh/devspace/pkg/devspace/services/terminal"
+	"github.com/loft-sh/devspace/pkg/util/factory"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type exportCmd struct {
+	*flags.GlobalFlags
+
+	LabelSelector string
+	Container     string
+	Pod           string
+	Pick          bool
+	Output        string
+}
+
+func newExportCmd(f factory.Factory, globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &exportCmd{GlobalFlags: globalFlags}
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Exports the files changed in a container to a tar archive",
+		Long: `
+#######################################################
+############### devspace terminal export ##############
+#######################################################
+Writes the files that were changed on the root
+filesystem of a container since it started to a tar
+archive, e.g. to keep the changes made while debugging.
+Volumes and deleted files are not part of the archive.
+
+devspace terminal export -l app=test
+devspace terminal export --pod my-pod -c my-container --output debug.tar
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.RunExport(f, cobraCmd, args)
+		}}
+
+	exportCmd.Flags().StringVarP(&cmd.LabelSelector, "label-selector", "l", "", "Comma separated key=value selector list (e.g. release=test)")
+	exportCmd.Flags().StringVarP(&cmd.Container, "container", "c", "", "Container name within pod to export the files of")
+	exportCmd.Flags().StringVar(&cmd.Pod, "pod", "", "Pod to export the files of")
+	exportCmd.Flags().BoolVar(&cmd.Pick, "pick", true, "Select a pod / container if multiple are found")
+	exportCmd.Flags().StringVarP(&cmd.Output, "output", "o", "devspace-export.tar", "The path of the tar archive")
+	return exportCmd
+}
+
+// RunExport runs the terminal export command logic
+func (cmd *exportCmd) RunExport(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	if cmd.Output == "" {
+		return errors.New("--output is required")
+	}
+
+	logger := f.GetLog()
+	configLoader, err := f.NewConfigLoader(cmd.ConfigPath)
+	if err != nil {
+		return err
+	}
+	configExists, err := configLoader.SetDevSpaceRoot(logger)
+	if err != nil {
+		return err
+	}
+
+	// Get kubectl client
+	client, err := f.NewKubeClientFromContext(cmd.KubeContext, cmd.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "new kube client")
+	}
+
+	// If the current kube context or namespace is different from old,
+	// show warnings and reset kube client if necessary
+	if configExists {
+		localCache, err := configLoader.LoadLocalCache()
+		if err != nil {
+			return err
+		}
+
+		client, err = kubectl.CheckKubeContext(client, localCache, cmd.NoWarn, cmd.SwitchContext, false, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx := devspacecontext.NewContext(context.Background(), nil, logger).WithKubeClient(client)
+	selectorOptions := targetselector.NewOptionsFromFlags(cmd.Container, cmd.LabelSelector, nil, client.Namespace(), cmd.Pod).
+		WithPick(cmd.Pick).
+		WithWait(false).
+		WithQuestion("Which container do you want to export the files of?")
+	return terminal.ExportFilesystem(ctx, nil, targetselector.NewTargetSelector(selectorOptions), ctx.ResolvePath(cmd.Output))
+}
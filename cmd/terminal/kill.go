@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"context"
+
+	"github.com/loft-sh/devspace/cmd/flags"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/devspace/services/terminal"
+	"github.com/loft-sh/devspace/pkg/util/factory"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type killCmd struct {
+	*flags.GlobalFlags
+
+	LabelSelector string
+	Container     string
+	Pod           string
+	Signal        string
+}
+
+func newKillCmd(f factory.Factory, globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &killCmd{GlobalFlags: globalFlags}
+
+	killCmd := &cobra.Command{
+		Use:   "kill",
+		Short: "Sends a signal to all processes in a container",
+		Long: `
+#######################################################
+############### devspace terminal kill ################
+#######################################################
+Sends a signal to all processes in the container except 
+for its main process, e.g. to stop a process that was 
+started in a terminal session. Windows containers have 
+no signals, so the processes are terminated instead.
+
+devspace terminal kill -l app=test
+devspace terminal kill --pod my-pod -c my-container --signal SIGKILL
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.RunKill(f, cobraCmd, args)
+		}}
+
+	killCmd.Flags().StringVarP(&cmd.LabelSelector, "label-selector", "l", "", "Comma separated key=value selector list (e.g. release=test)")
+	killCmd.Flags().StringVarP(&cmd.Container, "container", "c", "", "Container name within pod to send the signal to")
+	killCmd.Flags().StringVar(&cmd.Pod, "pod", "", "Pod to send the signal to")
+	killCmd.Flags().StringVar(&cmd.Signal, "signal", terminal.DefaultKillSignal, "The signal to send to the processes")
+	return killCmd
+}
+
+// RunKill runs the terminal kill command logic
+func (cmd *killCmd) RunKill(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	logger := f.GetLog()
+	configLoader, err := f.NewConfigLoader(cmd.ConfigPath)
+	if err != nil {
+		return err
+	}
+	configExists, err := configLoader.SetDevSpaceRoot(logger)
+	if err != nil {
+		return err
+	}
+
+	// Get kubectl client
+	client, err := f.NewKubeClientFromContext(cmd.KubeContext, cmd.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "new kube client")
+	}
+
+	// If the current kube context or namespace is different from old,
+	// show warnings and reset kube client if necessary
+	if configExists {
+		localCache, err := configLoader.LoadLocalCache()
+		if err != nil {
+			return err
+		}
+
+		client, err = kubectl.CheckKubeContext(client, localCache, cmd.NoWarn, cmd.SwitchContext, false, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx := devspacecontext.NewContext(context.Background(), nil, logger).WithKubeClient(client)
+	return terminal.KillTerminalSession(ctx, nil, selector.Selector{
+		LabelSelector: cmd.LabelSelector,
+		Pod:           cmd.Pod,
+		ContainerName: cmd.Container,
+		Namespace:     client.Namespace(),
+	}, cmd.Signal)
+}
@@ -0,0 +1,198 @@
+package terminal
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/loft-sh/devspace/cmd/flags"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/devspace/services/terminal"
+	"github.com/loft-sh/devspace/pkg/util/factory"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type listCmd struct {
+	*flags.GlobalFlags
+
+	LabelSelector string
+	Container     string
+	Pod           string
+	Local         bool
+}
+
+func newListCmd(f factory.Factory, globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &listCmd{GlobalFlags: globalFlags}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists all screen and tmux sessions in the namespace",
+		Long: `
+#######################################################
+############### devspace terminal list ################
+#######################################################
+Lists all active screen and tmux sessions of the 
+containers in the current namespace
+
+devspace terminal list
+devspace terminal list -l app=test
+devspace terminal list --pod my-pod -c my-container
+devspace terminal list --local
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.RunList(f, cobraCmd, args)
+		}}
+
+	listCmd.Flags().StringVarP(&cmd.LabelSelector, "label-selector", "l", "", "Comma separated key=value selector list (e.g. release=test)")
+	listCmd.Flags().StringVarP(&cmd.Container, "container", "c", "", "Container name within pod to list sessions for")
+	listCmd.Flags().StringVar(&cmd.Pod, "pod", "", "Pod to list sessions for")
+	listCmd.Flags().BoolVar(&cmd.Local, "local", false, "List the terminal sessions opened by DevSpace on this machine instead, including sessions of crashed DevSpace processes")
+	return listCmd
+}
+
+// RunList runs the terminal list command logic
+func (cmd *listCmd) RunList(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	logger := f.GetLog()
+	if cmd.Local {
+		return cmd.runListLocal(logger)
+	}
+
+	configLoader, err := f.NewConfigLoader(cmd.ConfigPath)
+	if err != nil {
+		return err
+	}
+	configExists, err := configLoader.SetDevSpaceRoot(logger)
+	if err != nil {
+		return err
+	}
+
+	// Get kubectl client
+	client, err := f.NewKubeClientFromContext(cmd.KubeContext, cmd.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "new kube client")
+	}
+
+	// If the current kube context or namespace is different from old,
+	// show warnings and reset kube client if necessary
+	if configExists {
+		localCache, err := configLoader.LoadLocalCache()
+		if err != nil {
+			return err
+		}
+
+		client, err = kubectl.CheckKubeContext(client, localCache, cmd.NoWarn, cmd.SwitchContext, false, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx := devspacecontext.NewContext(context.Background(), nil, logger).WithKubeClient(client)
+	sessions, err := terminal.ListTerminalSessions(ctx, client.Namespace(), selector.Selector{
+		LabelSelector: cmd.LabelSelector,
+		Pod:           cmd.Pod,
+		ContainerName: cmd.Container,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		logger.Info("No terminal sessions found")
+		return nil
+	}
+
+	headerColumnNames := []string{
+		"Pod",
+		"Container",
+		"Session",
+		"Type",
+		"Created",
+		"Attached",
+	}
+
+	sessionRows := make([][]string, 0, len(sessions))
+	for _, session := range sessions {
+		created := ""
+		if !session.Created.IsZero() {
+			created = session.Created.Format(time.RFC3339)
+		}
+
+		sessionRows = append(sessionRows, []string{
+			session.Pod,
+			session.Container,
+			session.Name,
+			session.Multiplexer,
+			created,
+			strconv.FormatBool(session.Attached),
+		})
+	}
+
+	log.PrintTable(logger, headerColumnNames, sessionRows)
+	return nil
+}
+
+// runListLocal lists the terminal sessions in the local session store
+func (cmd *listCmd) runListLocal(logger log.Logger) error {
+	store, err := terminal.NewDefaultSessionStore()
+	if err != nil {
+		return err
+	}
+
+	all, err := store.List()
+	if err != nil {
+		return err
+	}
+	stale, err := store.Stale()
+	if err != nil {
+		return err
+	}
+	staleIDs := map[string]bool{}
+	for _, record := range stale {
+		staleIDs[record.ID] = true
+	}
+
+	records := []terminal.SessionRecord{}
+	for _, record := range all {
+		if (cmd.Pod != "" && record.Pod != cmd.Pod) || (cmd.Container != "" && record.Container != cmd.Container) {
+			continue
+		}
+
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		logger.Info("No terminal sessions found")
+		return nil
+	}
+
+	headerColumnNames := []string{
+		"Pod",
+		"Container",
+		"Namespace",
+		"Context",
+		"Started",
+		"PID",
+		"Stale",
+	}
+
+	sessionRows := make([][]string, 0, len(records))
+	for _, record := range records {
+		sessionRows = append(sessionRows, []string{
+			record.Pod,
+			record.Container,
+			record.Namespace,
+			record.KubeContext,
+			record.StartedAt.Local().Format(time.RFC3339),
+			strconv.Itoa(record.PID),
+			strconv.FormatBool(staleIDs[record.ID]),
+		})
+	}
+
+	log.PrintTable(logger, headerColumnNames, sessionRows)
+	return nil
+}
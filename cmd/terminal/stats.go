@@ -0,0 +1,243 @@
+package terminal
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/loft-sh/devspace/cmd/flags"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/services/terminal"
+	"github.com/loft-sh/devspace/pkg/util/factory"
+	"github.com/loft-sh/devspace/pkg/util/interrupt"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+// clearScreen moves the cursor to the top left and clears the screen, so that the table is refreshed in place
+const clearScreen = "\033[H\033[2J"
+
+type statsCmd struct {
+	*flags.GlobalFlags
+
+	LabelSelector string
+	Container     string
+	Pod           string
+	Interval      time.Duration
+	Count         int
+}
+
+func newStatsCmd(f factory.Factory, globalFlags *flags.GlobalFlags) *cobra.Command {
+	cmd := &statsCmd{GlobalFlags: globalFlags}
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Shows the exec latency and bytes transferred of terminal sessions",
+		Long: `
+#######################################################
+############### devspace terminal stats ###############
+#######################################################
+Shows the terminal sessions opened by DevSpace on this
+machine with the bytes they transferred and the latency
+of the exec connection to their container in a table
+that is refreshed until interrupted. Helps to diagnose
+slow terminals.
+
+devspace terminal stats
+devspace terminal stats -l app=test --interval 5s
+devspace terminal stats --pod my-pod -c my-container --count 1
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+		RunE: func(cobraCmd *cobra.Command, args []string) error {
+			return cmd.RunStats(f, cobraCmd, args)
+		}}
+
+	statsCmd.Flags().StringVarP(&cmd.LabelSelector, "label-selector", "l", "", "Comma separated key=value selector list (e.g. release=test)")
+	statsCmd.Flags().StringVarP(&cmd.Container, "container", "c", "", "Container name within pod to show stats for")
+	statsCmd.Flags().StringVar(&cmd.Pod, "pod", "", "Pod to show stats for")
+	statsCmd.Flags().DurationVar(&cmd.Interval, "interval", time.Second*2, "The interval the sessions are pinged and the table is refreshed in")
+	statsCmd.Flags().IntVar(&cmd.Count, "count", 0, "The amount of refreshes after which the command exits, 0 refreshes until interrupted")
+	return statsCmd
+}
+
+// RunStats runs the terminal stats command logic
+func (cmd *statsCmd) RunStats(f factory.Factory, cobraCmd *cobra.Command, args []string) error {
+	if cmd.Interval <= 0 {
+		return errors.Errorf("interval must be positive")
+	}
+
+	logger := f.GetLog()
+	configLoader, err := f.NewConfigLoader(cmd.ConfigPath)
+	if err != nil {
+		return err
+	}
+	configExists, err := configLoader.SetDevSpaceRoot(logger)
+	if err != nil {
+		return err
+	}
+
+	// Get kubectl client
+	client, err := f.NewKubeClientFromContext(cmd.KubeContext, cmd.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "new kube client")
+	}
+
+	// If the current kube context or namespace is different from old,
+	// show warnings and reset kube client if necessary
+	if configExists {
+		localCache, err := configLoader.LoadLocalCache()
+		if err != nil {
+			return err
+		}
+
+		client, err = kubectl.CheckKubeContext(client, localCache, cmd.NoWarn, cmd.SwitchContext, false, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the label selector is resolved once to the matching pods of the current namespace
+	var pods map[string]bool
+	if cmd.LabelSelector != "" {
+		podList, err := client.KubeClient().CoreV1().Pods(client.Namespace()).List(context.Background(), metav1.ListOptions{LabelSelector: cmd.LabelSelector})
+		if err != nil {
+			return errors.Wrap(err, "list pods")
+		}
+
+		pods = map[string]bool{}
+		for _, pod := range podList.Items {
+			pods[pod.Name] = true
+		}
+	}
+
+	store, err := terminal.NewDefaultSessionStore()
+	if err != nil {
+		return err
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctx := devspacecontext.NewContext(cancelCtx, nil, logger).WithKubeClient(client)
+	return interrupt.Global.Run(func() error {
+		return cmd.printStats(ctx, store, pods)
+	}, cancel)
+}
+
+// printStats prints the live sessions of the session store until the count is reached or the context is done
+func (cmd *statsCmd) printStats(ctx devspacecontext.Context, store *terminal.SessionStore, pods map[string]bool) error {
+	pool := terminal.NewTerminalPool(ctx.KubeClient())
+	pool.DisableScreen = true
+	defer pool.Close()
+
+	// probe sessions are keyed by the id of the terminal session they measure the latency of
+	probes := map[string]*terminal.TerminalSession{}
+	refresh := term.IsTerminal(os.Stdout)
+	for i := 0; cmd.Count <= 0 || i < cmd.Count; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Context().Done():
+				return nil
+			case <-time.After(cmd.Interval):
+			}
+		}
+
+		records, err := cmd.liveSessions(store, ctx.KubeClient().Namespace(), pods)
+		if err != nil {
+			return err
+		}
+
+		rows := make([][]string, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, []string{
+				record.Pod,
+				record.Container,
+				record.Namespace,
+				strconv.Itoa(record.PID),
+				record.StartedAt.Local().Format(time.RFC3339),
+				cmd.latency(ctx, pool, probes, record),
+				strconv.FormatUint(record.BytesIn, 10),
+				strconv.FormatUint(record.BytesOut, 10),
+			})
+		}
+
+		if refresh {
+			ctx.Log().WriteString(logrus.InfoLevel, clearScreen)
+		}
+		if len(rows) == 0 {
+			ctx.Log().Info("No terminal sessions found")
+			continue
+		}
+		log.PrintTable(ctx.Log(), []string{"Pod", "Container", "Namespace", "PID", "Started", "Latency", "Bytes Sent", "Bytes Received"}, rows)
+	}
+
+	return nil
+}
+
+// liveSessions returns the sessions of running DevSpace processes that match the flags
+func (cmd *statsCmd) liveSessions(store *terminal.SessionStore, namespace string, pods map[string]bool) ([]terminal.SessionRecord, error) {
+	all, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	stale, err := store.Stale()
+	if err != nil {
+		return nil, err
+	}
+	staleIDs := map[string]bool{}
+	for _, record := range stale {
+		staleIDs[record.ID] = true
+	}
+
+	records := []terminal.SessionRecord{}
+	for _, record := range all {
+		if staleIDs[record.ID] || (cmd.Pod != "" && record.Pod != cmd.Pod) || (cmd.Container != "" && record.Container != cmd.Container) {
+			continue
+		} else if pods != nil && (record.Namespace != namespace || !pods[record.Pod]) {
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// latency pings the container of the session with a probe session of the pool, which is only possible
+// for sessions in the kube context of the command. Probes that failed are reopened on the next refresh.
+func (cmd *statsCmd) latency(ctx devspacecontext.Context, pool *terminal.TerminalPool, probes map[string]*terminal.TerminalSession, record terminal.SessionRecord) string {
+	if record.KubeContext != ctx.KubeClient().CurrentContext() {
+		return "-"
+	}
+
+	var err error
+	probe := probes[record.ID]
+	if probe == nil {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: record.Pod, Namespace: record.Namespace}}
+		probe, err = pool.Acquire(ctx.Context(), pod, record.Container)
+		if err != nil {
+			ctx.Log().Debugf("Error opening session to %s:%s: %v", record.Pod, record.Container, err)
+			return "-"
+		}
+
+		probes[record.ID] = probe
+	}
+
+	latency, err := probe.Ping(ctx.Context())
+	if err != nil {
+		ctx.Log().Debugf("Error pinging session to %s:%s: %v", record.Pod, record.Container, err)
+		probe.Close()
+		delete(probes, record.ID)
+		return "-"
+	}
+
+	return latency.Round(time.Millisecond).String()
+}
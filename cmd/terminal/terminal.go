@@ -0,0 +1,31 @@
+package terminal
+
+import (
+	"github.com/loft-sh/devspace/cmd/flags"
+	"github.com/loft-sh/devspace/pkg/devspace/plugin"
+	"github.com/loft-sh/devspace/pkg/util/factory"
+	"github.com/spf13/cobra"
+)
+
+// NewTerminalCmd creates a new cobra command
+func NewTerminalCmd(f factory.Factory, globalFlags *flags.GlobalFlags, plugins []plugin.Metadata) *cobra.Command {
+	terminalCmd := &cobra.Command{
+		Use:   "terminal",
+		Short: "Manages terminal sessions",
+		Long: `
+#######################################################
+################## devspace terminal ##################
+#######################################################
+	`,
+		Args: cobra.NoArgs,
+	}
+
+	terminalCmd.AddCommand(newListCmd(f, globalFlags))
+	terminalCmd.AddCommand(newKillCmd(f, globalFlags))
+	terminalCmd.AddCommand(newStatsCmd(f, globalFlags))
+	terminalCmd.AddCommand(newExportCmd(f, globalFlags))
+
+	// Add plugin commands
+	plugin.AddPluginCommands(terminalCmd, plugins, "terminal")
+	return terminalCmd
+}
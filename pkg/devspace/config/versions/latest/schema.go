@@ -1282,12 +1282,51 @@ type BandwidthLimits struct {
 // Terminal describes the terminal options
 type Terminal struct {
 	// Command is the command that should be executed on terminal start.
-	// This command is executed within a shell.
+	// This command is executed within a shell. The command can reference the selected
+	// container with go template actions, e.g. PS1='[{{.Pod}}] ', the available fields
+	// are .Pod, .Container, .Namespace and .Node.
 	Command string `yaml:"command,omitempty" json:"command,omitempty"`
 
+	// Shell is the shell the command is executed with, either sh or cmd and powershell for windows
+	// containers. If empty, DevSpace detects the operating system of the container from the pod
+	// or its node and falls back to probing the container for sh and cmd.
+	Shell string `yaml:"shell,omitempty" json:"shell,omitempty" jsonschema:"enum=sh,enum=cmd,enum=powershell"`
+
+	// WindowsShell is the shell used if a windows container was detected, either cmd or powershell.
+	// Screen is not available in windows containers. Defaults to cmd.
+	WindowsShell string `yaml:"windowsShell,omitempty" json:"windowsShell,omitempty" jsonschema:"enum=cmd,enum=powershell"`
+
 	// WorkDir is the working directory that is used to execute the command in.
 	WorkDir string `yaml:"workDir,omitempty" json:"workDir,omitempty"`
 
+	// Env are environment variables the command is executed with, without changing the spec of the
+	// container. They are set after switching to runAsUser and before the initScript is sourced.
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+
+	// WaitForRunning waits until a crash-looping container runs in between its restarts before opening
+	// the terminal. By default, the terminal fails with the last termination reason of the container.
+	WaitForRunning bool `yaml:"waitForRunning,omitempty" json:"waitForRunning,omitempty"`
+
+	// SubResource is the sub resource used to connect to the container, either exec or attach.
+	// Attach connects to the main process of the container, so command and screen are not used.
+	// Defaults to exec.
+	SubResource string `yaml:"subResource,omitempty" json:"subResource,omitempty" jsonschema:"enum=exec,enum=attach"`
+
+	// KubeContext is the kube context the terminal is opened in. If the active kube context
+	// differs, DevSpace switches to this context for the terminal and prints a warning.
+	KubeContext string `yaml:"kubeContext,omitempty" json:"kubeContext,omitempty"`
+
+	// StdinRateLimit limits the bytes per second that are sent from stdin to the container, which
+	// prevents a huge paste from freezing the terminal on slow links. Typing and pastes smaller than
+	// one second of the limit, or at least 4096 bytes, are not delayed. Defaults to unlimited.
+	StdinRateLimit int64 `yaml:"stdinRateLimit,omitempty" json:"stdinRateLimit,omitempty"`
+
+	// InitScript is a shell script that is sourced before the command is executed.
+	// This can be used to set up aliases, functions or environment variables in the terminal.
+	// If the command is bash or sh, the shell loads the script itself, so aliases and functions
+	// are kept in the interactive shell. For bash, it is loaded after ~/.bashrc.
+	InitScript string `yaml:"initScript,omitempty" json:"initScript,omitempty"`
+
 	// If enabled is true, DevSpace will use the terminal. Can be also
 	// used to disable the terminal if set to false. DevSpace makes sure
 	// that within a pipeline only one dev configuration can open a terminal
@@ -1302,8 +1341,122 @@ type Terminal struct {
 	// sessions if connections interrupt or the session is lost.
 	DisableScreen bool `yaml:"disableScreen,omitempty" json:"disableScreen,omitempty"`
 
+	// ScreenSessionName is the name of the screen session of the terminal, which may only contain
+	// alphanumeric characters and dashes. Terminals of different profiles or dev configurations to the
+	// same container need different names to not share their session. Defaults to dev.
+	ScreenSessionName string `yaml:"screenSessionName,omitempty" json:"screenSessionName,omitempty"`
+
+	// ScreenInstallTimeout is the amount of seconds to wait for screen to be installed in the
+	// container before falling back to a plain shell. Defaults to 60 seconds.
+	ScreenInstallTimeout int64 `yaml:"screenInstallTimeout,omitempty" json:"screenInstallTimeout,omitempty" jsonschema:"default=60"`
+
+	// ScreenInstallScript is a shell script that is used instead of the built-in script to
+	// install screen in the container. The script must exit with 0 if screen was installed
+	// successfully and with 1 if screen cannot be used.
+	ScreenInstallScript string `yaml:"screenInstallScript,omitempty" json:"screenInstallScript,omitempty"`
+
+	// NoPackageInstall tells DevSpace to never install packages in the container. Screen is only
+	// used if it is already present in the container, otherwise a plain shell is opened. This
+	// takes precedence over screenInstallScript.
+	NoPackageInstall bool `yaml:"noPackageInstall,omitempty" json:"noPackageInstall,omitempty"`
+
+	// DisableSessionFile tells DevSpace to not write the session metadata to /tmp/.devspace-session-
+	// followed by the session id in the container, which tells other tooling that it is running inside
+	// a DevSpace session. The path of the file is exported as DEVSPACE_SESSION_FILE.
+	DisableSessionFile bool `yaml:"disableSessionFile,omitempty" json:"disableSessionFile,omitempty"`
+
+	// ShowCommand logs the final command that is executed in the container, after it was wrapped
+	// in the screen session and env. Values of env whose names look like secrets are redacted.
+	ShowCommand bool `yaml:"showCommand,omitempty" json:"showCommand,omitempty"`
+
+	// IsolateHistory sets HISTFILE to a file that is unique per session, so that the histories of
+	// multiple terminals to the same container don't interleave. Only bash and zsh are supported and
+	// a HISTFILE set in their rc files takes precedence.
+	IsolateHistory bool `yaml:"isolateHistory,omitempty" json:"isolateHistory,omitempty"`
+
+	// NoHistory disables the history of bash and zsh for the session. It takes precedence over isolateHistory.
+	NoHistory bool `yaml:"noHistory,omitempty" json:"noHistory,omitempty"`
+
+	// RootFrom is the name of another container in the same pod whose root filesystem the command is
+	// executed in, e.g. to inspect the files of a container without a shell. The pod needs
+	// shareProcessNamespace enabled and this container needs nsenter. The command is resolved in the
+	// filesystem of the other container, so e.g. a statically linked shell has to exist there. Not
+	// supported together with initScript.
+	RootFrom string `yaml:"rootFrom,omitempty" json:"rootFrom,omitempty"`
+
+	// ForwardSSHAgent forwards the local ssh agent into the container while the terminal is connected and
+	// sets SSH_AUTH_SOCK, e.g. for git operations. This requires socat to be present in the container,
+	// otherwise a warning is printed and the terminal is opened without the ssh agent.
+	ForwardSSHAgent bool `yaml:"forwardSSHAgent,omitempty" json:"forwardSSHAgent,omitempty"`
+
+	// ShellPath is the absolute path of the shell binary in the container that runs the terminal command
+	// with -c, e.g. /bin/bash if sh is not available in the PATH of the container. Defaults to sh.
+	ShellPath string `yaml:"shellPath,omitempty" json:"shellPath,omitempty"`
+
+	// ManageScreenrc tells DevSpace to create a default ~/.screenrc in the container if none exists.
+	// Disable this if your dotfiles are mounted into the container and should not be clobbered.
+	ManageScreenrc *bool `yaml:"manageScreenrc,omitempty" json:"manageScreenrc,omitempty" jsonschema:"default=true"`
+
+	// ScreenLogExport is a local path the screen log of the session is copied to after
+	// the session has ended. Only used if screen is enabled.
+	ScreenLogExport string `yaml:"screenLogExport,omitempty" json:"screenLogExport,omitempty"`
+
+	// ScreenLogPath is the path of the screen log inside the container, which is also the file
+	// exported by screenLogExport. Defaults to a file per screen session under /tmp. Screen versions
+	// before 4.06 don't support a custom path and write screenlog.0 to the working directory instead.
+	ScreenLogPath string `yaml:"screenLogPath,omitempty" json:"screenLogPath,omitempty"`
+
+	// WatchdogInterval is the amount of seconds after which DevSpace periodically logs how long the
+	// terminal is connected to the .devspace/logs/terminal.log file. Disabled if zero.
+	WatchdogInterval int64 `yaml:"watchdogInterval,omitempty" json:"watchdogInterval,omitempty"`
+
+	// RunAsUser is the user the command is executed as. DevSpace uses runuser, setpriv or su,
+	// whichever is available in the container, and falls back to the default user otherwise.
+	RunAsUser string `yaml:"runAsUser,omitempty" json:"runAsUser,omitempty"`
+
+	// Cols is the fixed amount of columns of the terminal if stdin is not a terminal, e.g. in CI.
+	// If Cols and Rows are set, a tty with the given size is allocated regardless of stdin.
+	Cols uint16 `yaml:"cols,omitempty" json:"cols,omitempty"`
+
+	// Rows is the fixed amount of rows of the terminal if stdin is not a terminal, e.g. in CI.
+	// If Cols and Rows are set, a tty with the given size is allocated regardless of stdin.
+	Rows uint16 `yaml:"rows,omitempty" json:"rows,omitempty"`
+
+	// BannerColor is the ansi style of the pod and container name in the banner that is printed when
+	// the terminal is opened, e.g. blue+b for light terminal themes. Defaults to white+b.
+	BannerColor string `yaml:"bannerColor,omitempty" json:"bannerColor,omitempty" jsonschema:"default=white+b"`
+
+	// Banner is a message that is printed once the terminal is connected, e.g. a message of the day.
+	// It is only printed if the output is a terminal. \n is replaced with a new line and $${POD_NAME}
+	// and $${CONTAINER_NAME} with the name of the pod and container. The $$ prevents DevSpace from
+	// resolving them as variables when loading the config.
+	Banner string `yaml:"banner,omitempty" json:"banner,omitempty"`
+
 	// DisableTTY will disable a tty shell for terminal command execution
 	DisableTTY bool `yaml:"disableTTY,omitempty" json:"disableTTY,omitempty"`
+
+	// DisableTTYFallback tells DevSpace to not retry the terminal without a tty if the tty cannot
+	// be negotiated, e.g. because a proxy in front of the cluster breaks the connection upgrade
+	DisableTTYFallback bool `yaml:"disableTTYFallback,omitempty" json:"disableTTYFallback,omitempty"`
+
+	// ShowAllProcesses tells DevSpace to check if the pod shares its process namespace, so
+	// that processes of all containers are visible in the terminal. DevSpace will warn if
+	// this is not the case, as the pod needs shareProcessNamespace to be enabled.
+	ShowAllProcesses bool `yaml:"showAllProcesses,omitempty" json:"showAllProcesses,omitempty"`
+
+	// InjectMetadata tells DevSpace to set the environment variables DEVSPACE_POD, DEVSPACE_CONTAINER
+	// and DEVSPACE_NAMESPACE to the container the terminal is opened to. Enabled by default.
+	InjectMetadata *bool `yaml:"injectMetadata,omitempty" json:"injectMetadata,omitempty" jsonschema:"default=true"`
+
+	// UseEphemeralContainer tells DevSpace to launch an ephemeral container in the selected pod and
+	// open the terminal to it instead, e.g. for distroless containers without a shell. The ephemeral
+	// container is stopped after the session, but stays registered in the pod as Kubernetes does not
+	// allow to remove ephemeral containers.
+	UseEphemeralContainer bool `yaml:"useEphemeralContainer,omitempty" json:"useEphemeralContainer,omitempty"`
+
+	// EphemeralImage is the image of the ephemeral container, which needs to contain a shell.
+	// Defaults to busybox.
+	EphemeralImage string `yaml:"ephemeralImage,omitempty" json:"ephemeralImage,omitempty" jsonschema:"default=busybox"`
 }
 
 // DependencyConfig defines the devspace dependency
@@ -3,9 +3,11 @@ package versions
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 	"unicode"
 
+	"github.com/mgutz/ansi"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 	k8sv1 "k8s.io/api/core/v1"
@@ -36,6 +38,44 @@ func ValidContainerArch(arch latest.ContainerArchitecture) bool {
 		arch == latest.ContainerArchitectureArm64
 }
 
+var envVarNameRegEx = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ValidEnvVarName checks if the name is a valid shell variable name
+func ValidEnvVarName(name string) bool {
+	return envVarNameRegEx.MatchString(name)
+}
+
+var screenSessionNameRegEx = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+// ValidScreenSessionName checks if the screen session name only contains alphanumeric characters and dashes
+func ValidScreenSessionName(name string) bool {
+	return name == "" || screenSessionNameRegEx.MatchString(name)
+}
+
+// ValidAnsiStyle checks if the style is a valid ansi style in the format
+// foreground[+attributes][:background[+attributes]], e.g. white+b or blue:black
+func ValidAnsiStyle(style string) bool {
+	if style == "" {
+		return true
+	}
+
+	parts := strings.Split(style, ":")
+	if len(parts) > 2 {
+		return false
+	}
+	for _, part := range parts {
+		colorAttributes := strings.SplitN(part, "+", 2)
+		if _, ok := ansi.Colors[colorAttributes[0]]; !ok {
+			return false
+		}
+		if len(colorAttributes) == 2 && strings.Trim(colorAttributes[1], "bBuish") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
 func Validate(config *latest.Config) error {
 	if config.Name == "" {
 		return fmt.Errorf("you need to specify a name for your devspace.yaml")
@@ -411,6 +451,29 @@ func validateDevContainer(path string, devContainer *latest.DevContainer, devPod
 		return errors.Errorf("%s.arch is not valid '%s'", path, devContainer.Arch)
 	}
 
+	if devContainer.Terminal != nil && devContainer.Terminal.Shell != "" && devContainer.Terminal.Shell != "sh" && devContainer.Terminal.Shell != "cmd" && devContainer.Terminal.Shell != "powershell" {
+		return errors.Errorf("%s.terminal.shell is not valid '%s', expected sh, cmd or powershell", path, devContainer.Terminal.Shell)
+	}
+	if devContainer.Terminal != nil && devContainer.Terminal.WindowsShell != "" && devContainer.Terminal.WindowsShell != "cmd" && devContainer.Terminal.WindowsShell != "powershell" {
+		return errors.Errorf("%s.terminal.windowsShell is not valid '%s', expected cmd or powershell", path, devContainer.Terminal.WindowsShell)
+	}
+	if devContainer.Terminal != nil && !ValidAnsiStyle(devContainer.Terminal.BannerColor) {
+		return errors.Errorf("%s.terminal.bannerColor is not a valid ansi style '%s'", path, devContainer.Terminal.BannerColor)
+	}
+	if devContainer.Terminal != nil && !ValidScreenSessionName(devContainer.Terminal.ScreenSessionName) {
+		return errors.Errorf("%s.terminal.screenSessionName is not valid '%s', expected only alphanumeric characters and dashes", path, devContainer.Terminal.ScreenSessionName)
+	}
+	if devContainer.Terminal != nil && devContainer.Terminal.StdinRateLimit < 0 {
+		return errors.Errorf("%s.terminal.stdinRateLimit must not be negative", path)
+	}
+	if devContainer.Terminal != nil {
+		for name := range devContainer.Terminal.Env {
+			if !ValidEnvVarName(name) {
+				return errors.Errorf("%s.terminal.env has invalid variable name '%s', expected only alphanumeric characters and underscores", path, name)
+			}
+		}
+	}
+
 	// check if there are values from devContainers that are overwriting values from devPod
 	err := validatePodContainerDuplicates(path, devContainer, devPod)
 	if err != nil {
@@ -172,4 +172,90 @@ func TestValidateDev(t *testing.T) {
 
 	err = validateDev(config)
 	assert.Error(t, err, "dev.somename.reversePorts will be overwritten by dev.somename.containers[test], please specify dev.somename.containers[test].reversePorts instead")
+
+	// test terminal banner color
+	config = &latest.Config{
+		Dev: map[string]*latest.DevPod{
+			"test": {
+				ImageSelector: "selectMe",
+				DevContainer: latest.DevContainer{
+					Terminal: &latest.Terminal{
+						BannerColor: "white+x",
+					},
+				},
+			},
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.test.terminal.bannerColor is not a valid ansi style 'white+x'")
+
+	// test terminal screen session name
+	config = &latest.Config{
+		Dev: map[string]*latest.DevPod{
+			"test": {
+				ImageSelector: "selectMe",
+				DevContainer: latest.DevContainer{
+					Terminal: &latest.Terminal{
+						ScreenSessionName: "front end",
+					},
+				},
+			},
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.test.terminal.screenSessionName is not valid 'front end', expected only alphanumeric characters and dashes")
+
+	// test terminal stdin rate limit
+	config = &latest.Config{
+		Dev: map[string]*latest.DevPod{
+			"test": {
+				ImageSelector: "selectMe",
+				DevContainer: latest.DevContainer{
+					Terminal: &latest.Terminal{
+						StdinRateLimit: -1,
+					},
+				},
+			},
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.test.terminal.stdinRateLimit must not be negative")
+
+	// test terminal env
+	config = &latest.Config{
+		Dev: map[string]*latest.DevPod{
+			"test": {
+				ImageSelector: "selectMe",
+				DevContainer: latest.DevContainer{
+					Terminal: &latest.Terminal{
+						Env: map[string]string{"MY VAR": "value"},
+					},
+				},
+			},
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.test.terminal.env has invalid variable name 'MY VAR', expected only alphanumeric characters and underscores")
+}
+
+func TestValidScreenSessionName(t *testing.T) {
+	for _, name := range []string{"", "dev", "frontend-2", "Backend"} {
+		assert.Equal(t, ValidScreenSessionName(name), true, "Expected valid name "+name)
+	}
+	for _, name := range []string{"front end", "dev.1", "a/b", "x;rm"} {
+		assert.Equal(t, ValidScreenSessionName(name), false, "Expected invalid name "+name)
+	}
+}
+
+func TestValidAnsiStyle(t *testing.T) {
+	for _, style := range []string{"", "white+b", "blue", "red+bu:black", "202:white+h"} {
+		assert.Equal(t, ValidAnsiStyle(style), true, "Expected valid style "+style)
+	}
+	for _, style := range []string{"purple", "white+x", "white:black:red", "+b"} {
+		assert.Equal(t, ValidAnsiStyle(style), false, "Expected invalid style "+style)
+	}
 }
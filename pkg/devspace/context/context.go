@@ -9,6 +9,8 @@ import (
 	"strings"
 
 	"github.com/loft-sh/devspace/pkg/devspace/config"
+	"github.com/loft-sh/devspace/pkg/devspace/context/eventbus"
+	"github.com/loft-sh/devspace/pkg/devspace/context/values"
 	"github.com/loft-sh/devspace/pkg/devspace/dependency/types"
 	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
 	"github.com/loft-sh/devspace/pkg/devspace/pipeline/env"
@@ -35,9 +37,22 @@ func NewContext(ctx context2.Context, variables map[string]interface{}, log log.
 		runID:      strings.ToLower(randutil.GenerateRandomString(12)),
 		environ:    env.NewVariableEnvProvider(expand.ListEnviron(os.Environ()...), env.ConvertMap(variables)),
 		log:        log,
+		eventBus:   eventbus.New(),
 	}
 }
 
+// WithRequestID returns a copy of ctx that carries the given request id. The id is sent
+// as X-Request-ID header with the exec requests to the cluster for tracing.
+func WithRequestID(ctx Context, id string) Context {
+	return ctx.WithContext(values.WithRequestID(ctx.Context(), id))
+}
+
+// RequestIDFromContext returns the request id of ctx or an empty string if none is set
+func RequestIDFromContext(ctx Context) string {
+	id, _ := values.RequestIDFrom(ctx.Context())
+	return id
+}
+
 func RealWorkDir() (string, error) {
 	if runtime.GOOS == "darwin" {
 		if pwd, present := os.LookupEnv("PWD"); present {
@@ -78,6 +93,10 @@ type Context interface {
 	// KubeClient is the kubernetes client
 	KubeClient() kubectl.Client
 
+	// EventBus receives the events of the services of this run, e.g.
+	// terminal lifecycle changes. It is shared by all derived contexts.
+	EventBus() *eventbus.Bus
+
 	// IsDone checks if the context expired
 	IsDone() bool
 
@@ -124,6 +143,9 @@ type context struct {
 
 	// log is the currently used logger
 	log log.Logger
+
+	// eventBus receives the events of the services of this run
+	eventBus *eventbus.Bus
 }
 
 func (c *context) Environ() expand.Environ {
@@ -154,6 +176,10 @@ func (c *context) KubeClient() kubectl.Client {
 	return c.kubeClient
 }
 
+func (c *context) EventBus() *eventbus.Bus {
+	return c.eventBus
+}
+
 func (c *context) Log() log.Logger {
 	return c.log
 }
@@ -0,0 +1,60 @@
+package eventbus
+
+import "sync"
+
+// Bus delivers the events published by the services of a DevSpace run, e.g. terminal lifecycle
+// changes, to all subscribers, which can hook into them for automation. Publishing never blocks,
+// so that a slow subscriber can't stall the publisher, events for a full subscriber are dropped.
+type Bus struct {
+	m sync.Mutex
+
+	nextID      int
+	subscribers map[int]chan interface{}
+}
+
+// New creates a new event bus without subscribers
+func New() *Bus {
+	return &Bus{
+		subscribers: map[int]chan interface{}{},
+	}
+}
+
+// Subscribe returns a channel that receives all events published from now on, buffering up to size events,
+// and a function that unsubscribes and closes the channel again
+func (b *Bus) Subscribe(size int) (<-chan interface{}, func()) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	events := make(chan interface{}, size)
+	b.subscribers[id] = events
+
+	once := sync.Once{}
+	return events, func() {
+		once.Do(func() {
+			b.m.Lock()
+			defer b.m.Unlock()
+
+			delete(b.subscribers, id)
+			close(events)
+		})
+	}
+}
+
+// Publish sends the event to all subscribers that have room for it. Publishing to a nil bus does nothing.
+func (b *Bus) Publish(event interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	for _, events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package eventbus
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestBus(t *testing.T) {
+	bus := New()
+	first, unsubscribeFirst := bus.Subscribe(2)
+	second, unsubscribeSecond := bus.Subscribe(1)
+	defer unsubscribeSecond()
+
+	bus.Publish("a")
+	bus.Publish("b")
+	assert.Equal(t, <-first, "a")
+	assert.Equal(t, <-first, "b")
+
+	// the full subscriber misses events instead of blocking the publisher
+	assert.Equal(t, <-second, "a")
+	assert.Equal(t, len(second), 0)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	bus.Publish("c")
+	_, ok := <-first
+	assert.Equal(t, ok, false)
+	assert.Equal(t, <-second, "c")
+
+	var nilBus *Bus
+	nilBus.Publish("d")
+}
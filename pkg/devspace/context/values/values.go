@@ -18,6 +18,7 @@ const (
 	devContextKey
 	flagsKey
 	commandFlagsKey
+	requestIDKey
 )
 
 // WithFlagsMap creates a new context with the given flags
@@ -97,6 +98,17 @@ func TempFolderFrom(ctx context.Context) (string, bool) {
 	return user, ok
 }
 
+// WithRequestID returns a copy of parent in which the request id is set
+func WithRequestID(parent context.Context, id string) context.Context {
+	return WithValue(parent, requestIDKey, id)
+}
+
+// RequestIDFrom returns the request id used for tracing requests to the cluster
+func RequestIDFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok
+}
+
 func WithDependency(parent context.Context, dependency bool) context.Context {
 	return WithValue(parent, dependencyKey, dependency)
 }
@@ -468,14 +468,29 @@ func (d *devPod) startTerminal(ctx devspacecontext.Context, devContainer *latest
 
 		// make sure the global log is silent
 		ctx = ctx.WithLogger(ctx.Log().WithPrefixColor("term  ", "yellow+b"))
-		err = terminal.StartTerminal(
+		sessionStore, err := terminal.NewDefaultSessionStore()
+		if err != nil {
+			ctx.Log().Debugf("Error creating terminal session store: %v", err)
+		}
+		err = terminal.StartTerminalWithOptions(
 			ctx,
 			devContainer,
 			newTargetSelector(selectedPod.Pod.Name, selectedPod.Pod.Namespace, selectedPod.Container.Name, parent),
-			DefaultTerminalStdout,
-			DefaultTerminalStderr,
-			DefaultTerminalStdin,
 			parent,
+			terminal.NewTerminalOptions(
+				terminal.WithRestart(terminal.RestartUnlimited),
+				terminal.WithOnScreenFallback(func(reason error) {
+					// a read-only filesystem was already reported with a warning
+					fallbackErr := &terminal.ScreenFallbackError{}
+					if errors.As(reason, &fallbackErr) && fallbackErr.ReadOnlyFilesystem {
+						return
+					}
+
+					ctx.Log().Infof("Screen is not available in container %s, the terminal session won't survive reconnects: %v", selectedPod.Container.Name, reason)
+				}),
+				terminal.WithStreams(DefaultTerminalStdout, DefaultTerminalStderr, DefaultTerminalStdin),
+				terminal.WithSessionStore(sessionStore),
+			),
 		)
 		if err != nil {
 			return errors.Wrap(err, "error in terminal forwarding")
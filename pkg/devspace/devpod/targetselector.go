@@ -32,6 +32,8 @@ type targetSelector struct {
 	namespace        string
 	defaultContainer string
 	container        string
+	preferNewest     bool
+	cache            bool
 
 	// parent is killed if we cannot find the
 	// pod anymore we are assigned to
@@ -43,6 +45,12 @@ func (t *targetSelector) SelectSinglePod(ctx context.Context, client kubectl.Cli
 		WithPod(t.pod).
 		WithNamespace(t.namespace).
 		WithWaitingStrategy(newUntilNewestRunningWaitingStrategy(time.Millisecond*250, t.parent))
+	if t.preferNewest {
+		options = options.WithPreferNewest()
+	}
+	if t.cache {
+		options = options.WithCache(true)
+	}
 
 	return targetselector.NewTargetSelector(options).SelectSinglePod(ctx, client, log)
 }
@@ -58,6 +66,12 @@ func (t *targetSelector) SelectSingleContainer(ctx context.Context, client kubec
 		WithNamespace(t.namespace).
 		WithContainer(container).
 		WithWaitingStrategy(newUntilNewestRunningWaitingStrategy(time.Millisecond*250, t.parent))
+	if t.preferNewest {
+		options = options.WithPreferNewest()
+	}
+	if t.cache {
+		options = options.WithCache(true)
+	}
 
 	return targetselector.NewTargetSelector(options).SelectSingleContainer(ctx, client, log)
 }
@@ -68,6 +82,63 @@ func (t *targetSelector) WithContainer(container string) targetselector.TargetSe
 		namespace:        t.namespace,
 		container:        container,
 		defaultContainer: t.defaultContainer,
+		preferNewest:     t.preferNewest,
+		cache:            t.cache,
+		parent:           t.parent,
+	}
+}
+
+func (t *targetSelector) WithNamespace(namespace string) targetselector.TargetSelector {
+	return &targetSelector{
+		pod:              t.pod,
+		namespace:        namespace,
+		container:        t.container,
+		defaultContainer: t.defaultContainer,
+		preferNewest:     t.preferNewest,
+		cache:            t.cache,
+		parent:           t.parent,
+	}
+}
+
+func (t *targetSelector) WithPreferNewest() targetselector.TargetSelector {
+	return &targetSelector{
+		pod:              t.pod,
+		namespace:        t.namespace,
+		container:        t.container,
+		defaultContainer: t.defaultContainer,
+		preferNewest:     true,
+		cache:            t.cache,
+		parent:           t.parent,
+	}
+}
+
+// WithAvoidScalingDown returns the same selector, as the dev pod is selected by name
+func (t *targetSelector) WithAvoidScalingDown() targetselector.TargetSelector {
+	return t
+}
+
+// WithCache reuses the dev pod selected within targetselector.DefaultCacheTTL while it still runs
+func (t *targetSelector) WithCache() targetselector.TargetSelector {
+	return &targetSelector{
+		pod:              t.pod,
+		namespace:        t.namespace,
+		container:        t.container,
+		defaultContainer: t.defaultContainer,
+		preferNewest:     t.preferNewest,
+		cache:            true,
+		parent:           t.parent,
+	}
+}
+
+// WithBypassCache makes sure the dev pod is always listed again instead of reusing a cached selection
+func (t *targetSelector) WithBypassCache() targetselector.TargetSelector {
+	return &targetSelector{
+		pod:              t.pod,
+		namespace:        t.namespace,
+		container:        t.container,
+		defaultContainer: t.defaultContainer,
+		preferNewest:     t.preferNewest,
+		cache:            false,
 		parent:           t.parent,
 	}
 }
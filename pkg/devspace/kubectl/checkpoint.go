@@ -0,0 +1,71 @@
+package kubectl
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// minCheckpointKubeletVersion is the first kubelet version that serves the container checkpoint api
+var minCheckpointKubeletVersion = version.MustParseGeneric("1.25.0")
+
+// ErrCheckpointNotSupported is returned by CreateCheckpoint if the kubelet of the pod's node
+// is older than 1.25 and doesn't serve the container checkpoint api
+var ErrCheckpointNotSupported = errors.New("container checkpoints need kubelet 1.25 or newer")
+
+// checkpointResponse is the response of the kubelet checkpoint api
+type checkpointResponse struct {
+	Items []string `json:"items"`
+}
+
+// CreateCheckpoint creates a checkpoint of the container through the kubelet checkpoint api and
+// returns the path of the checkpoint archive on the node. The ContainerCheckpoint feature gate
+// has to be enabled on the kubelet.
+func (client *client) CreateCheckpoint(ctx context.Context, pod *corev1.Pod, container string) (string, error) {
+	if pod.Spec.NodeName == "" {
+		return "", errors.Errorf("pod %s is not scheduled to a node", pod.Name)
+	}
+
+	node, err := client.KubeClient().CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "get node %s", pod.Spec.NodeName)
+	}
+	if !checkpointSupported(node.Status.NodeInfo.KubeletVersion) {
+		return "", ErrCheckpointNotSupported
+	}
+
+	out, err := client.KubeClient().CoreV1().RESTClient().Post().
+		AbsPath("/api/v1/nodes", node.Name, "proxy", "checkpoint", pod.Namespace, pod.Name, container).
+		DoRaw(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "checkpoint container %s of pod %s", container, pod.Name)
+	}
+
+	return parseCheckpointResponse(out)
+}
+
+// checkpointSupported returns true if the kubelet version serves the checkpoint api
+func checkpointSupported(kubeletVersion string) bool {
+	parsed, err := version.ParseGeneric(kubeletVersion)
+	if err != nil {
+		return false
+	}
+
+	return parsed.AtLeast(minCheckpointKubeletVersion)
+}
+
+func parseCheckpointResponse(out []byte) (string, error) {
+	response := &checkpointResponse{}
+	err := json.Unmarshal(out, response)
+	if err != nil {
+		return "", errors.Wrap(err, "parse checkpoint response")
+	} else if len(response.Items) == 0 {
+		return "", errors.New("checkpoint response contains no checkpoint")
+	}
+
+	return response.Items[0], nil
+}
@@ -0,0 +1,60 @@
+package kubectl
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type checkpointSupportedTestCase struct {
+	name           string
+	kubeletVersion string
+	expected       bool
+}
+
+func TestCheckpointSupported(t *testing.T) {
+	testCases := []checkpointSupportedTestCase{
+		{name: "Older kubelet", kubeletVersion: "v1.24.9", expected: false},
+		{name: "First supported kubelet", kubeletVersion: "v1.25.0", expected: true},
+		{name: "Distribution suffix", kubeletVersion: "v1.27.3+k3s1", expected: true},
+		{name: "Invalid version", kubeletVersion: "unknown", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, checkpointSupported(testCase.kubeletVersion), testCase.expected, "Unexpected result in test case %s", testCase.name)
+	}
+}
+
+func TestParseCheckpointResponse(t *testing.T) {
+	path, err := parseCheckpointResponse([]byte(`{"items":["/var/lib/kubelet/checkpoints/checkpoint-pod_default-app.tar"]}`))
+	assert.NilError(t, err)
+	assert.Equal(t, path, "/var/lib/kubelet/checkpoints/checkpoint-pod_default-app.tar")
+
+	_, err = parseCheckpointResponse([]byte(`{"items":[]}`))
+	assert.ErrorContains(t, err, "contains no checkpoint")
+	_, err = parseCheckpointResponse([]byte(`checkpoint`))
+	assert.ErrorContains(t, err, "parse checkpoint response")
+}
+
+func TestCreateCheckpointOldKubelet(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KubeletVersion: "v1.24.0"}},
+	}
+	kubeClient := &client{Client: fake.NewSimpleClientset(node)}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "node"},
+	}
+
+	_, err := kubeClient.CreateCheckpoint(context.Background(), pod, "app")
+	assert.Equal(t, err, ErrCheckpointNotSupported)
+
+	pod.Spec.NodeName = ""
+	_, err = kubeClient.CreateCheckpoint(context.Background(), pod, "app")
+	assert.ErrorContains(t, err, "not scheduled")
+}
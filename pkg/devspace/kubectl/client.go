@@ -80,6 +80,9 @@ type Client interface {
 
 	// IsInCluster returns true if in cluster kubernetes configuration is detected
 	IsInCluster() bool
+
+	// CreateCheckpoint creates a checkpoint of the container and returns the path of the checkpoint on the node
+	CreateCheckpoint(ctx context.Context, pod *k8sv1.Pod, container string) (string, error)
 }
 
 type client struct {
@@ -0,0 +1,30 @@
+package kubectl
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// CountingReader counts the bytes read from the underlying reader
+type CountingReader struct {
+	Reader io.Reader
+	Count  *int64
+}
+
+func (c *CountingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	atomic.AddInt64(c.Count, int64(n))
+	return n, err
+}
+
+// CountingWriter counts the bytes written to the underlying writer
+type CountingWriter struct {
+	Writer io.Writer
+	Count  *int64
+}
+
+func (c *CountingWriter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	atomic.AddInt64(c.Count, int64(n))
+	return n, err
+}
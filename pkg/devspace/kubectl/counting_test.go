@@ -0,0 +1,37 @@
+package kubectl
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCountingReader(t *testing.T) {
+	count := int64(0)
+	reader := &CountingReader{Reader: strings.NewReader("hello world"), Count: &count}
+
+	buf := make([]byte, 5)
+	_, err := reader.Read(buf)
+	assert.NilError(t, err)
+	assert.Equal(t, count, int64(5))
+
+	_, err = io.ReadAll(reader)
+	assert.NilError(t, err)
+	assert.Equal(t, count, int64(11))
+}
+
+func TestCountingWriter(t *testing.T) {
+	count := int64(0)
+	out := &bytes.Buffer{}
+	writer := &CountingWriter{Writer: out, Count: &count}
+
+	_, err := writer.Write([]byte("hello"))
+	assert.NilError(t, err)
+	_, err = writer.Write([]byte(" world"))
+	assert.NilError(t, err)
+	assert.Equal(t, count, int64(11))
+	assert.Equal(t, out.String(), "hello world")
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"time"
 
+	"github.com/loft-sh/devspace/pkg/devspace/context/values"
 	"github.com/loft-sh/devspace/pkg/util/terminal"
+	"github.com/pkg/errors"
 	"k8s.io/kubectl/pkg/util/term"
 
 	corev1 "k8s.io/api/core/v1"
@@ -25,8 +28,38 @@ const (
 	SubResourceAttach SubResource = "attach"
 )
 
+// SubResources are the known sub resources to connect to a container
+var SubResources = []SubResource{SubResourceExec, SubResourceAttach}
+
+// ValidateSubResource returns an error if the sub resource is not one of SubResources.
+// An empty sub resource is valid and defaults to SubResourceExec.
+func ValidateSubResource(subResource SubResource) error {
+	if subResource == "" {
+		return nil
+	}
+	for _, known := range SubResources {
+		if subResource == known {
+			return nil
+		}
+	}
+
+	return errors.Errorf("unknown sub resource %q, please use %s or %s", subResource, SubResourceExec, SubResourceAttach)
+}
+
+// ErrExecTimeout is returned by ExecStream if the exec session exceeded ExecStreamOptions.Timeout
+var ErrExecTimeout = errors.New("exec session timed out")
+
 // execStreamWithTransport executes a kubectl exec with given transport round tripper and upgrader
 func (client *client) execStreamWithTransport(ctx context.Context, options *ExecStreamOptions) error {
+	if options.RequestID != "" {
+		ctx = values.WithRequestID(ctx, options.RequestID)
+	}
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, options.Timeout, ErrExecTimeout)
+		defer cancel()
+	}
+
 	var (
 		t             term.TTY
 		sizeQueue     remotecommand.TerminalSizeQueue
@@ -38,7 +71,7 @@ func (client *client) execStreamWithTransport(ctx context.Context, options *Exec
 		options.SubResource = SubResourceExec
 	}
 
-	wrapper, upgradeRoundTripper, err := GetUpgraderWrapper(client)
+	wrapper, upgradeRoundTripper, err := getExecUpgraderWrapper(client, options)
 	if err != nil {
 		return err
 	}
@@ -56,7 +89,9 @@ func (client *client) execStreamWithTransport(ctx context.Context, options *Exec
 			if t.Raw && options.TerminalSizeQueue == nil {
 				// this call spawns a goroutine to monitor/update the terminal size
 				sizeQueue = t.MonitorSize(t.GetSize())
-			} else if options.TerminalSizeQueue != nil {
+			} else {
+				// a forced tty is requested even if stdin isn't a terminal, the remote tty
+				// uses its default size if no size queue is given
 				sizeQueue = options.TerminalSizeQueue
 				t.Raw = true
 			}
@@ -80,6 +115,35 @@ func (client *client) execStreamWithTransport(ctx context.Context, options *Exec
 		}
 	}
 
+	// the stdin of the remote command is closed first on cancellation, so it can flush its output and exit
+	closeStdin := func() {}
+	if options.GracePeriod > 0 && streamOptions.Stdin != nil {
+		pump := options.StdinPump
+		if pump == nil {
+			pump = NewStdinPump(streamOptions.Stdin)
+		}
+		stdin := pump.Reader()
+		defer stdin.Close()
+		streamOptions.Stdin = stdin
+		closeStdin = stdin.Close
+	}
+
+	// the tty reads from the terminal and not from options.Stdin, so the limit is applied afterwards
+	if options.StdinRateLimit > 0 && streamOptions.Stdin != nil {
+		streamOptions.Stdin = newRateLimitedReader(ctx, streamOptions.Stdin, options.StdinRateLimit)
+	}
+	if options.BytesIn != nil && streamOptions.Stdin != nil {
+		streamOptions.Stdin = &CountingReader{Reader: streamOptions.Stdin, Count: options.BytesIn}
+	}
+	if options.BytesOut != nil {
+		if streamOptions.Stdout != nil {
+			streamOptions.Stdout = &CountingWriter{Writer: streamOptions.Stdout, Count: options.BytesOut}
+		}
+		if streamOptions.Stderr != nil {
+			streamOptions.Stderr = &CountingWriter{Writer: streamOptions.Stderr, Count: options.BytesOut}
+		}
+	}
+
 	if options.SubResource == SubResourceExec {
 		execRequest.VersionedParams(&corev1.PodExecOptions{
 			Container: options.Container,
@@ -104,21 +168,16 @@ func (client *client) execStreamWithTransport(ctx context.Context, options *Exec
 		return err
 	}
 
-	errChan := make(chan error)
-	go func() {
-		errChan <- t.Safe(func() error {
-			return exec.StreamWithContext(ctx, streamOptions)
+	err = runWithGracePeriod(ctx, options.GracePeriod, func(streamCtx context.Context) error {
+		return t.Safe(func() error {
+			return exec.StreamWithContext(streamCtx, streamOptions)
 		})
-	}()
-
-	select {
-	case <-ctx.Done():
-		upgradeRoundTripper.Close()
-		<-errChan
-		return nil
-	case err = <-errChan:
-		return err
+	}, closeStdin, func() { _ = upgradeRoundTripper.Close() })
+	if ctx.Err() != nil && errors.Is(context.Cause(ctx), ErrExecTimeout) {
+		return ErrExecTimeout
 	}
+
+	return err
 }
 
 // ExecStreamOptions are the options for ExecStream
@@ -137,6 +196,39 @@ type ExecStreamOptions struct {
 	Stderr io.Writer
 
 	SubResource SubResource
+
+	// Timeout bounds the duration of the exec session. If the timeout is exceeded
+	// the session is closed and ErrExecTimeout is returned. Zero means no timeout.
+	Timeout time.Duration
+
+	// HTTPProxy is the proxy the exec request is sent through instead of the proxy
+	// from the HTTPS_PROXY and HTTP_PROXY environment variables, if non-empty
+	HTTPProxy string
+
+	// NoProxy is a comma separated list of hosts that are not connected through the proxy
+	// instead of the hosts from the NO_PROXY environment variable, if non-empty
+	NoProxy string
+
+	// StdinRateLimit limits the bytes per second that are sent from stdin to the container,
+	// zero means unlimited
+	StdinRateLimit int64
+	// GracePeriod is the time the session gets to end on its own once the context is done. Stdin is
+	// closed first, so that the remote command can flush its output and exit. Zero closes the session
+	// immediately.
+	GracePeriod time.Duration
+
+	// BytesIn and BytesOut count the bytes sent to stdin and received from stdout and stderr
+	// of the command, if set. They are updated atomically while the session is running.
+	BytesIn  *int64
+	BytesOut *int64
+
+	// StdinPump reads Stdin if GracePeriod is set. Sessions that read the same stdin one after another,
+	// like the restarts of a terminal, should share a pump, so that input isn't lost between them.
+	StdinPump *StdinPump
+
+	// RequestID is sent as RequestIDHeader with the exec request instead of the request id of the
+	// context, if non-empty
+	RequestID string
 }
 
 // ExecStream executes a command and streams the output to the given streams
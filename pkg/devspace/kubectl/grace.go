@@ -0,0 +1,149 @@
+package kubectl
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// runWithGracePeriod runs stream until it returns. If ctx is done before, closeStdin is called and
+// stream gets the grace period to flush its output and return, e.g. because the remote command exited
+// on EOF. Afterwards abort is called, which must make stream return promptly. The context passed to
+// stream is only cancelled once the grace period is over. Returns nil if ctx was done.
+func runWithGracePeriod(ctx context.Context, gracePeriod time.Duration, stream func(ctx context.Context) error, closeStdin func(), abort func()) error {
+	streamCtx := ctx
+	if gracePeriod > 0 {
+		var cancel context.CancelFunc
+		streamCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+		defer cancel()
+
+		abortStream := abort
+		abort = func() {
+			abortStream()
+			cancel()
+		}
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- stream(streamCtx)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+
+	if gracePeriod > 0 {
+		closeStdin()
+
+		timer := time.NewTimer(gracePeriod)
+		defer timer.Stop()
+		select {
+		case <-errChan:
+			return nil
+		case <-timer.C:
+		}
+	}
+
+	abort()
+	<-errChan
+	return nil
+}
+
+// StdinPump reads stdin for the exec sessions that share it, e.g. the sessions of a restarting terminal.
+// Every session reads through its own reader, which returns io.EOF once it is closed, even if a read of
+// stdin is still blocked, which is the case until the user types. The blocked read is not abandoned, but
+// its data is handed to the reader of the next session, so that no input is lost between sessions.
+type StdinPump struct {
+	reader io.Reader
+
+	results  chan readResult
+	pending  bool
+	leftover []byte
+	err      error
+	m        sync.Mutex
+}
+
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// NewStdinPump creates a pump that reads from reader. Readers of the pump must not be read concurrently.
+func NewStdinPump(reader io.Reader) *StdinPump {
+	return &StdinPump{
+		reader:  reader,
+		results: make(chan readResult, 1),
+	}
+}
+
+// Reader returns a new reader of the pump for a session
+func (p *StdinPump) Reader() *StdinPumpReader {
+	return &StdinPumpReader{
+		pump:   p,
+		closed: make(chan struct{}),
+	}
+}
+
+// StdinPumpReader is the reader of a single session of a StdinPump
+type StdinPumpReader struct {
+	pump *StdinPump
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (r *StdinPumpReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.closed:
+		return 0, io.EOF
+	default:
+	}
+
+	pump := r.pump
+	pump.m.Lock()
+	defer pump.m.Unlock()
+
+	if len(pump.leftover) > 0 {
+		n := copy(p, pump.leftover)
+		pump.leftover = pump.leftover[n:]
+		return n, nil
+	} else if pump.err != nil {
+		return 0, pump.err
+	}
+
+	// the read happens in the background, so that the session can end while it is blocked
+	if !pump.pending {
+		pump.pending = true
+		buf := make([]byte, len(p))
+		go func() {
+			n, err := pump.reader.Read(buf)
+			pump.results <- readResult{data: buf[:n], err: err}
+		}()
+	}
+
+	select {
+	case result := <-pump.results:
+		pump.pending = false
+		pump.err = result.err
+		n := copy(p, result.data)
+		pump.leftover = result.data[n:]
+		if len(pump.leftover) > 0 {
+			return n, nil
+		}
+
+		return n, result.err
+	case <-r.closed:
+		return 0, io.EOF
+	}
+}
+
+// Close makes pending and future reads return io.EOF
+func (r *StdinPumpReader) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+	})
+}
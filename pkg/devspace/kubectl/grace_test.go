@@ -0,0 +1,110 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestRunWithGracePeriodStreamEnds(t *testing.T) {
+	err := runWithGracePeriod(context.Background(), time.Second, func(ctx context.Context) error {
+		return fmt.Errorf("exit 1")
+	}, func() {}, func() {})
+	assert.Error(t, err, "exit 1")
+}
+
+func TestRunWithGracePeriodFlush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stdinClosed := make(chan struct{})
+	aborted := false
+	started := make(chan struct{})
+
+	go func() {
+		<-started
+		cancel()
+	}()
+	err := runWithGracePeriod(ctx, time.Minute, func(streamCtx context.Context) error {
+		close(started)
+
+		// the remote command exits on EOF, while the stream is still open
+		<-stdinClosed
+		assert.NilError(t, streamCtx.Err())
+		return nil
+	}, func() { close(stdinClosed) }, func() { aborted = true })
+	assert.NilError(t, err)
+	assert.Assert(t, !aborted, "Expected the stream to end within the grace period")
+}
+
+func TestRunWithGracePeriodExceeded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	abort := make(chan struct{})
+	stdinClosed := false
+	started := time.Now()
+	err := runWithGracePeriod(ctx, time.Millisecond*50, func(streamCtx context.Context) error {
+		// the remote command ignores EOF
+		<-abort
+		<-streamCtx.Done()
+		return fmt.Errorf("connection closed")
+	}, func() { stdinClosed = true }, func() { close(abort) })
+	assert.NilError(t, err)
+	assert.Assert(t, stdinClosed, "Expected stdin to be closed")
+	assert.Assert(t, time.Since(started) < time.Second*5, "Expected a prompt return after the grace period")
+}
+
+func TestRunWithoutGracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stdinClosed := false
+	err := runWithGracePeriod(ctx, 0, func(streamCtx context.Context) error {
+		<-streamCtx.Done()
+		return nil
+	}, func() { stdinClosed = true }, func() {})
+	assert.NilError(t, err)
+	assert.Assert(t, !stdinClosed, "Expected the stream to be closed immediately")
+}
+
+func TestStdinPump(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+	pump := NewStdinPump(pipeReader)
+	reader := pump.Reader()
+
+	go func() {
+		_, _ = pipeWriter.Write([]byte("hello"))
+	}()
+	out := make([]byte, 3)
+	n, err := reader.Read(out)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out[:n]), "hel")
+	n, err = reader.Read(out)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out[:n]), "lo")
+
+	// the next read blocks on the pipe until the reader is closed
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		reader.Close()
+	}()
+	_, err = reader.Read(out)
+	assert.Equal(t, err, io.EOF)
+	_, err = reader.Read(out)
+	assert.Equal(t, err, io.EOF)
+
+	// the input of the read that was blocked when the session ended goes to the next session
+	go func() {
+		_, _ = pipeWriter.Write([]byte("x"))
+		_ = pipeWriter.Close()
+	}()
+	next := pump.Reader()
+	n, err = next.Read(out)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out[:n]), "x")
+	_, err = next.Read(out)
+	assert.Equal(t, err, io.EOF)
+}
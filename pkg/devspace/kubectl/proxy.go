@@ -0,0 +1,108 @@
+package kubectl
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ProxyEnv returns the proxy environment variables for subprocesses like kubectl, where
+// httpProxy and noProxy override the proxy environment variables of DevSpace if non-empty
+func ProxyEnv(httpProxy, noProxy string) []string {
+	env := []string{}
+	if httpProxy != "" {
+		env = append(env, "HTTPS_PROXY="+httpProxy, "https_proxy="+httpProxy, "HTTP_PROXY="+httpProxy, "http_proxy="+httpProxy)
+	}
+	if noProxy != "" {
+		env = append(env, "NO_PROXY="+noProxy, "no_proxy="+noProxy)
+	}
+
+	return env
+}
+
+// execProxy returns the proxy function used for exec requests. The proxy environment variables
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY are used unless they are overridden by httpProxy and noProxy.
+// Nil is returned if neither is overridden, which uses the default proxy of the rest config.
+func execProxy(httpProxy, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	if httpProxy == "" && noProxy == "" {
+		return nil, nil
+	}
+	if httpProxy == "" {
+		httpProxy = firstEnv("HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy")
+	}
+	if noProxy == "" {
+		noProxy = firstEnv("NO_PROXY", "no_proxy")
+	}
+	if httpProxy == "" {
+		return func(*http.Request) (*url.URL, error) { return nil, nil }, nil
+	}
+
+	// like curl, a proxy without scheme is an http proxy
+	if !strings.Contains(httpProxy, "://") {
+		httpProxy = "http://" + httpProxy
+	}
+	proxyURL, err := url.Parse(httpProxy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse proxy %s", httpProxy)
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+
+		return proxyURL, nil
+	}, nil
+}
+
+// bypassProxy checks if the host is localhost or matched by the comma separated no proxy list,
+// which may contain hosts, domains with an optional leading dot, ip addresses, cidrs or *
+func bypassProxy(host string, noProxy string) bool {
+	host = strings.ToLower(host)
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entryHost, _, err := net.SplitHostPort(entry); err == nil {
+			entry = entryHost
+		}
+
+		if entry == "" {
+			continue
+		} else if entry == "*" {
+			return true
+		} else if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+		} else if entryIP := net.ParseIP(entry); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+		} else if domain := strings.TrimPrefix(entry, "."); host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,86 @@
+package kubectl
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type execProxyTestCase struct {
+	name      string
+	env       map[string]string
+	httpProxy string
+	noProxy   string
+	url       string
+
+	expectedProxy string
+}
+
+func TestExecProxy(t *testing.T) {
+	testCases := []execProxyTestCase{
+		{
+			name:          "Explicit proxy",
+			httpProxy:     "proxy.corp:3128",
+			url:           "https://api.cluster:6443/api/v1/namespaces/default/pods/pod/exec",
+			expectedProxy: "http://proxy.corp:3128",
+		},
+		{
+			name:          "Explicit proxy overrides environment",
+			env:           map[string]string{"HTTPS_PROXY": "http://env.corp:8080"},
+			httpProxy:     "http://proxy.corp:3128",
+			url:           "https://api.cluster:6443",
+			expectedProxy: "http://proxy.corp:3128",
+		},
+		{
+			name:          "Explicit no proxy with proxy from environment",
+			env:           map[string]string{"HTTPS_PROXY": "http://env.corp:8080"},
+			noProxy:       "other.cluster",
+			url:           "https://api.cluster:6443",
+			expectedProxy: "http://env.corp:8080",
+		},
+		{
+			name:    "Explicit no proxy domain",
+			env:     map[string]string{"HTTPS_PROXY": "http://env.corp:8080"},
+			noProxy: ".cluster",
+			url:     "https://api.cluster:6443",
+		},
+		{
+			name:      "No proxy from environment",
+			env:       map[string]string{"NO_PROXY": "10.0.0.0/8"},
+			httpProxy: "http://proxy.corp:3128",
+			url:       "https://10.1.2.3:6443",
+		},
+		{
+			name:      "Localhost",
+			httpProxy: "http://proxy.corp:3128",
+			url:       "https://127.0.0.1:6443",
+		},
+	}
+
+	for _, testCase := range testCases {
+		for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"} {
+			t.Setenv(name, testCase.env[name])
+		}
+
+		proxy, err := execProxy(testCase.httpProxy, testCase.noProxy)
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+
+		req, err := http.NewRequest(http.MethodPost, testCase.url, nil)
+		assert.NilError(t, err)
+		proxyURL, err := proxy(req)
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		if testCase.expectedProxy == "" {
+			assert.Assert(t, proxyURL == nil, "Unexpected proxy in "+testCase.name)
+		} else {
+			assert.Assert(t, proxyURL != nil, "Expected proxy in "+testCase.name)
+			assert.Equal(t, proxyURL.String(), testCase.expectedProxy, "Unexpected proxy in "+testCase.name)
+		}
+	}
+}
+
+func TestExecProxyDefault(t *testing.T) {
+	proxy, err := execProxy("", "")
+	assert.NilError(t, err)
+	assert.Assert(t, proxy == nil, "Expected the default proxy of the rest config")
+}
@@ -0,0 +1,48 @@
+package kubectl
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// minStdinBurst is the minimum number of bytes that can be read from stdin without waiting,
+// so that typing and small pastes are never delayed even with a low rate limit
+const minStdinBurst = 4096
+
+// rateLimitedReader limits the bytes per second read from a reader with a token bucket.
+// The bucket starts full, so the limit only applies once a burst was used up.
+type rateLimitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+func newRateLimitedReader(ctx context.Context, reader io.Reader, bytesPerSecond int64) *rateLimitedReader {
+	burst := int(bytesPerSecond)
+	if burst < minStdinBurst {
+		burst = minStdinBurst
+	}
+
+	return &rateLimitedReader{
+		ctx:     ctx,
+		reader:  reader,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burst),
+	}
+}
+
+// Read waits until the tokens for the bytes read are available, reads are never larger than
+// the burst of the limiter. The wait is skipped if the context is done, which ends the session anyway.
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		_ = r.limiter.WaitN(r.ctx, n)
+	}
+
+	return n, err
+}
@@ -0,0 +1,41 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestRateLimitedReader(t *testing.T) {
+	// typing and small pastes are not delayed
+	input := bytes.Repeat([]byte("a"), minStdinBurst)
+	started := time.Now()
+	out, err := io.ReadAll(newRateLimitedReader(context.Background(), bytes.NewReader(input), 1))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, out, input)
+	assert.Assert(t, time.Since(started) < time.Second/2, "Expected no delay, took %s", time.Since(started))
+
+	// a paste larger than the burst is limited
+	input = bytes.Repeat([]byte("a"), minStdinBurst+minStdinBurst/4)
+	started = time.Now()
+	out, err = io.ReadAll(newRateLimitedReader(context.Background(), bytes.NewReader(input), minStdinBurst))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, out, input)
+	assert.Assert(t, time.Since(started) >= time.Second/5, "Expected a delay, took %s", time.Since(started))
+}
+
+func TestRateLimitedReaderContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	input := bytes.Repeat([]byte("a"), minStdinBurst*4)
+	started := time.Now()
+	out, err := io.ReadAll(newRateLimitedReader(ctx, bytes.NewReader(input), 1))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, out, input)
+	assert.Assert(t, time.Since(started) < time.Second/2, "Expected no delay, took %s", time.Since(started))
+}
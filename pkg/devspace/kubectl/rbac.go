@@ -0,0 +1,46 @@
+package kubectl
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CanI checks with a SelfSubjectAccessReview if the current user is allowed to perform the verb
+// on the resource. The resource may contain a subresource, e.g. pods/exec. If the user is not
+// allowed, the reason of the authorizer is returned, which might be empty.
+func CanI(ctx context.Context, client kubernetes.Interface, verb, resource, namespace, name string) (bool, string, error) {
+	resource, subresource, _ := strings.Cut(resource, "/")
+	review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Resource:    resource,
+				Subresource: subresource,
+				Name:        name,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", errors.Wrap(err, "create self subject access review")
+	}
+
+	return review.Status.Allowed, review.Status.Reason, nil
+}
+
+// CurrentUser returns the name of the user the client is authenticated as. It
+// requires the SelfSubjectReview api, which is available since kubernetes v1.28.
+func CurrentUser(ctx context.Context, client kubernetes.Interface) (string, error) {
+	review, err := client.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return "", errors.Wrap(err, "create self subject review")
+	}
+
+	return review.Status.UserInfo.Username, nil
+}
@@ -0,0 +1,25 @@
+package kubectl
+
+import (
+	"net/http"
+
+	"github.com/loft-sh/devspace/pkg/devspace/context/values"
+)
+
+// RequestIDHeader is the header the request id of the request context is sent with
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDRoundTripper adds the request id of the request context as header, so that
+// requests to the cluster can be traced back to the DevSpace command that issued them
+type requestIDRoundTripper struct {
+	roundTripper http.RoundTripper
+}
+
+func (r *requestIDRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id, ok := values.RequestIDFrom(req.Context()); ok && id != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	return r.roundTripper.RoundTrip(req)
+}
@@ -0,0 +1,31 @@
+package kubectl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/context/values"
+	"gotest.tools/assert"
+)
+
+func TestRequestIDRoundTripper(t *testing.T) {
+	received := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(RequestIDHeader)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &requestIDRoundTripper{roundTripper: http.DefaultTransport}}
+	for _, id := range []string{"test-request-id", ""} {
+		req, err := http.NewRequestWithContext(values.WithRequestID(context.Background(), id), http.MethodPost, server.URL, nil)
+		assert.NilError(t, err)
+
+		resp, err := client.Do(req)
+		assert.NilError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, <-received, id)
+	}
+}
@@ -19,6 +19,9 @@ const (
 	ImageSelectorAnnotation    = "devspace.sh/imageSelector"
 
 	ReplacedLabel = "devspace.sh/replaced"
+
+	// AllNamespaces can be used as namespace of a selector to select pods in all namespaces
+	AllNamespaces = "*"
 )
 
 var SortPodsByNewest = func(pods []*corev1.Pod, i, j int) bool {
@@ -26,7 +29,7 @@ var SortPodsByNewest = func(pods []*corev1.Pod, i, j int) bool {
 }
 
 var SortContainersByNewest = func(pods []*SelectedPodContainer, i, j int) bool {
-	if pods[i].Pod.Name == pods[j].Pod.Name {
+	if pods[i].Pod.Namespace == pods[j].Pod.Namespace && pods[i].Pod.Name == pods[j].Pod.Name {
 		// this is needed for containers with the same image where we want to say that normal containers take prio over init containers
 		return initContainerPos(pods[i].Container.Name, pods[i].Pod) < initContainerPos(pods[j].Container.Name, pods[j].Pod)
 	}
@@ -133,7 +136,9 @@ func (f *filter) SelectContainers(ctx context.Context, selectors ...Selector) ([
 	retList := []*SelectedPodContainer{}
 	for _, s := range selectors {
 		namespace := f.client.Namespace()
-		if s.Namespace != "" {
+		if s.Namespace == AllNamespaces {
+			namespace = metav1.NamespaceAll
+		} else if s.Namespace != "" {
 			namespace = s.Namespace
 		}
 
@@ -221,16 +226,38 @@ func byPodName(ctx context.Context, client kubectl.Client, namespace string, nam
 		return nil, nil
 	}
 
-	retPods := []*SelectedPodContainer{}
+	// a pod can only be retrieved by name within a namespace, so all pods are listed instead
+	if namespace == metav1.NamespaceAll {
+		podList, err := client.KubeClient().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "list pods")
+		}
+
+		retPods := []*SelectedPodContainer{}
+		for i := range podList.Items {
+			if podList.Items[i].Name == name {
+				retPods = append(retPods, podContainers(&podList.Items[i], containerName, skipContainer, skipInit)...)
+			}
+		}
+
+		return retPods, nil
+	}
+
 	pod, err := client.KubeClient().CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if kerrors.IsNotFound(err) {
-			return retPods, nil
+			return []*SelectedPodContainer{}, nil
 		}
 
 		return nil, errors.Wrap(err, "get pod")
 	}
 
+	return podContainers(pod, containerName, skipContainer, skipInit), nil
+}
+
+// podContainers returns the containers of the pod that match the container name and are not skipped
+func podContainers(pod *corev1.Pod, containerName string, skipContainer FilterContainer, skipInit bool) []*SelectedPodContainer {
+	retPods := []*SelectedPodContainer{}
 	if !skipInit {
 		for _, container := range pod.Spec.InitContainers {
 			if skipContainer != nil && skipContainer(pod, &container) {
@@ -262,7 +289,7 @@ func byPodName(ctx context.Context, client kubectl.Client, namespace string, nam
 		})
 	}
 
-	return retPods, nil
+	return retPods
 }
 
 func byLabelSelector(ctx context.Context, client kubectl.Client, namespace string, labelSelector string, containerName string, skipContainer FilterContainer, skipInit bool) ([]*SelectedPodContainer, error) {
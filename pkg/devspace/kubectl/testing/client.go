@@ -105,6 +105,11 @@ func (c *Client) ExecBufferedCombined(ctx context.Context, od *k8sv1.Pod, contai
 	return []byte{}, nil
 }
 
+// CreateCheckpoint is a fake implementation of function
+func (c *Client) CreateCheckpoint(ctx context.Context, pod *k8sv1.Pod, container string) (string, error) {
+	return "", nil
+}
+
 // GenericRequest is a fake implementation of function
 func (c *Client) GenericRequest(ctx context.Context, options *kubectl.GenericRequestOptions) (string, error) {
 	return "", nil
@@ -5,6 +5,7 @@ import (
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/rest"
 	clientspdy "k8s.io/client-go/transport/spdy"
 )
 
@@ -45,12 +46,30 @@ func (uw *upgraderWrapper) Close() error {
 
 // GetUpgraderWrapper returns an upgrade wrapper for the given config @Factory
 func GetUpgraderWrapper(client Client) (http.RoundTripper, UpgraderWrapper, error) {
-	wrapper, upgradeRoundTripper, err := clientspdy.RoundTripperFor(client.RestConfig())
+	return upgraderWrapperFor(client.RestConfig())
+}
+
+// getExecUpgraderWrapper returns an upgrade wrapper that connects through the proxy of the exec options
+func getExecUpgraderWrapper(client Client, options *ExecStreamOptions) (http.RoundTripper, UpgraderWrapper, error) {
+	proxy, err := execProxy(options.HTTPProxy, options.NoProxy)
+	if err != nil {
+		return nil, nil, err
+	} else if proxy == nil {
+		return GetUpgraderWrapper(client)
+	}
+
+	config := rest.CopyConfig(client.RestConfig())
+	config.Proxy = proxy
+	return upgraderWrapperFor(config)
+}
+
+func upgraderWrapperFor(config *rest.Config) (http.RoundTripper, UpgraderWrapper, error) {
+	wrapper, upgradeRoundTripper, err := clientspdy.RoundTripperFor(config)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return wrapper, &upgraderWrapper{
+	return &requestIDRoundTripper{roundTripper: wrapper}, &upgraderWrapper{
 		Upgrader:    upgradeRoundTripper,
 		Connections: make([]httpstream.Connection, 0, 1),
 	}, nil
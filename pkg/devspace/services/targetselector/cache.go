@@ -0,0 +1,158 @@
+package targetselector
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"strings"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultCacheTTL is the time a selected container is reused for identical selections
+	DefaultCacheTTL = time.Second * 10
+
+	// DefaultCacheSize is the maximum number of selections that are cached
+	DefaultCacheSize = 64
+)
+
+// containers caches the containers of selections with Options.WithCache
+var containers = newContainerCache(DefaultCacheSize, DefaultCacheTTL)
+
+type containerCacheKey struct {
+	client      kubectl.Client
+	fingerprint string
+}
+
+type containerCacheEntry struct {
+	key       containerCacheKey
+	container *selector.SelectedPodContainer
+	expires   time.Time
+}
+
+// containerCache is a least recently used cache of selected containers with a time to live
+type containerCache struct {
+	m       sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[containerCacheKey]*list.Element
+	order   *list.List
+
+	now func() time.Time
+}
+
+func newContainerCache(size int, ttl time.Duration) *containerCache {
+	return &containerCache{
+		size:    size,
+		ttl:     ttl,
+		entries: map[containerCacheKey]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *containerCache) get(key containerCacheKey) *selector.SelectedPodContainer {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*containerCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil
+	}
+
+	c.order.MoveToFront(element)
+	return entry.container
+}
+
+func (c *containerCache) remove(key containerCacheKey) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+func (c *containerCache) set(key containerCacheKey, container *selector.SelectedPodContainer) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+	}
+	c.entries[key] = c.order.PushFront(&containerCacheEntry{
+		key:       key,
+		container: container,
+		expires:   c.now().Add(c.ttl),
+	})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*containerCacheEntry).key)
+	}
+}
+
+// cacheable returns true if the options can be identified by their fingerprint. Custom filter or sort
+// functions carry state that cannot be compared, so these selections are never cached. Waiting strategies
+// don't change which container is selected and a reused container is only returned while it runs.
+func (o Options) cacheable() bool {
+	return (o.selector.FilterContainer == nil || sameFunc(o.selector.FilterContainer, selector.FilterTerminatingContainers)) &&
+		(o.sortContainers == nil || sameFunc(o.sortContainers, selector.SortContainersByNewest))
+}
+
+// fingerprint identifies the options that select the same container
+func (o Options) fingerprint(client kubectl.Client) string {
+	return strings.Join([]string{
+		client.CurrentContext(),
+		client.Namespace(),
+		strings.Join(o.selector.ImageSelector, ","),
+		o.selector.LabelSelector,
+		o.selector.Pod,
+		o.selector.ContainerName,
+		o.selector.Namespace,
+		strings.Join(o.namespaces, ","),
+		o.question,
+		fmt.Sprintf("%t/%t/%t/%t/%t", o.selector.SkipInitContainers, o.allowPick, o.failIfMultiple, o.preferNewest, o.avoidScalingDown),
+		fmt.Sprintf("%t/%t", o.selector.FilterContainer == nil, o.sortContainers == nil),
+	}, "|")
+}
+
+// stillRunning fetches the pod of the cached container and returns the container with the current pod
+// if it is still the same pod, which isn't terminating, and the container is running. Otherwise nil is returned.
+func stillRunning(ctx context.Context, client kubectl.Client, container *selector.SelectedPodContainer) *selector.SelectedPodContainer {
+	pod, err := client.KubeClient().CoreV1().Pods(container.Pod.Namespace).Get(ctx, container.Pod.Name, metav1.GetOptions{})
+	if err != nil || pod.UID != container.Pod.UID || pod.DeletionTimestamp != nil {
+		return nil
+	}
+
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+		for _, status := range statuses {
+			if status.Name == container.Container.Name && status.State.Running != nil {
+				return &selector.SelectedPodContainer{Pod: pod, Container: container.Container}
+			}
+		}
+	}
+
+	return nil
+}
+
+// sameFunc returns true if both values are the same top level function
+func sameFunc(fn interface{}, other interface{}) bool {
+	return reflect.ValueOf(fn).Pointer() == reflect.ValueOf(other).Pointer()
+}
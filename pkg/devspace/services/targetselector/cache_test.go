@@ -0,0 +1,114 @@
+package targetselector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	logtesting "github.com/loft-sh/devspace/pkg/util/log/testing"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type containerCacheTestCase struct {
+	name            string
+	cache           bool
+	waitingStrategy WaitingStrategy
+	elapsed         time.Duration
+	replaced        bool
+
+	expectedLists int
+	expectedPods  []string
+}
+
+func TestSelectSingleContainerCache(t *testing.T) {
+	testCases := []containerCacheTestCase{
+		{
+			name:          "Within ttl",
+			cache:         true,
+			elapsed:       time.Second * 5,
+			expectedLists: 1,
+		},
+		{
+			name:          "Pod replaced within ttl",
+			cache:         true,
+			replaced:      true,
+			expectedLists: 2,
+			expectedPods:  []string{"api", "api-2"},
+		},
+		{
+			name:          "Expired",
+			cache:         true,
+			elapsed:       DefaultCacheTTL,
+			expectedLists: 2,
+		},
+		{
+			name:          "Not enabled",
+			expectedLists: 2,
+		},
+		{
+			name:            "Waiting strategy",
+			cache:           true,
+			waitingStrategy: NewUntilNotWaitingStrategy(0),
+			expectedLists:   1,
+		},
+	}
+
+	defer func(original *containerCache) { containers = original }(containers)
+	for _, testCase := range testCases {
+		now := time.Now()
+		containers = newContainerCache(DefaultCacheSize, DefaultCacheTTL)
+		containers.now = func() time.Time { return now }
+
+		kubeClient := fake.NewSimpleClientset()
+		pod := newRunningPod("api", map[string]string{"app": "api"}, true)
+		_, err := kubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+		kubeClient.ClearActions()
+
+		client := &kubectltesting.Client{Client: kubeClient}
+		options := NewEmptyOptions().WithLabelSelector("app=api").WithNamespace(pod.Namespace).WithWait(false).WithCache(testCase.cache)
+		if testCase.waitingStrategy != nil {
+			options = options.WithWaitingStrategy(testCase.waitingStrategy)
+		}
+		expectedPods := testCase.expectedPods
+		if expectedPods == nil {
+			expectedPods = []string{"api", "api"}
+		}
+		for i := 0; i < 2; i++ {
+			container, err := NewTargetSelector(options).SelectSingleContainer(context.Background(), client, logtesting.NewFakeLogger())
+			assert.NilError(t, err, "Unexpected error in "+testCase.name)
+			assert.Equal(t, container.Pod.Name, expectedPods[i], "Unexpected pod in "+testCase.name)
+			now = now.Add(testCase.elapsed)
+
+			// the cached container is not reused once its pod is gone
+			if testCase.replaced && i == 0 {
+				assert.NilError(t, kubeClient.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{}))
+				_, err = kubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), newRunningPod("api-2", map[string]string{"app": "api"}, true), metav1.CreateOptions{})
+				assert.NilError(t, err)
+			}
+		}
+
+		lists := 0
+		for _, action := range kubeClient.Actions() {
+			if action.Matches("list", "pods") {
+				lists++
+			}
+		}
+		assert.Equal(t, lists, testCase.expectedLists, "Unexpected pod lists in "+testCase.name)
+	}
+}
+
+func TestContainerCacheEviction(t *testing.T) {
+	cache := newContainerCache(2, DefaultCacheTTL)
+	for _, name := range []string{"a", "b", "c"} {
+		cache.set(containerCacheKey{fingerprint: name}, &selector.SelectedPodContainer{})
+	}
+
+	assert.Assert(t, cache.get(containerCacheKey{fingerprint: "a"}) == nil, "Expected the least recently used container to be evicted")
+	assert.Assert(t, cache.get(containerCacheKey{fingerprint: "b"}) != nil)
+	assert.Assert(t, cache.get(containerCacheKey{fingerprint: "c"}) != nil)
+}
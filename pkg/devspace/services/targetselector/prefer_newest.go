@@ -0,0 +1,52 @@
+package targetselector
+
+import (
+	"sort"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	v1 "k8s.io/api/core/v1"
+)
+
+// preferNewestContainers removes the containers of pods that are being deleted and sorts the
+// remaining ones so that the containers of the newest pod come first. Containers of the same
+// pod keep their original order.
+func preferNewestContainers(containers []*selector.SelectedPodContainer) []*selector.SelectedPodContainer {
+	retContainers := []*selector.SelectedPodContainer{}
+	for _, container := range containers {
+		if container.Pod.DeletionTimestamp == nil {
+			retContainers = append(retContainers, container)
+		}
+	}
+
+	sort.SliceStable(retContainers, func(i, j int) bool {
+		return isNewerPod(retContainers[i].Pod, retContainers[j].Pod)
+	})
+	return retContainers
+}
+
+// preferNewestPods removes the pods that are being deleted and sorts the remaining ones by newest first
+func preferNewestPods(pods []*v1.Pod) []*v1.Pod {
+	retPods := []*v1.Pod{}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp == nil {
+			retPods = append(retPods, pod)
+		}
+	}
+
+	sort.SliceStable(retPods, func(i, j int) bool {
+		return isNewerPod(retPods[i], retPods[j])
+	})
+	return retPods
+}
+
+// isNewerPod returns true if a was created after b. Pods created within the same second
+// are ordered by namespace and name to make the selection deterministic.
+func isNewerPod(a *v1.Pod, b *v1.Pod) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return b.CreationTimestamp.Before(&a.CreationTimestamp)
+	} else if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+
+	return a.Name < b.Name
+}
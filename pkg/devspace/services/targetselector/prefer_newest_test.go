@@ -0,0 +1,99 @@
+package targetselector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type preferNewestTestCase struct {
+	name       string
+	containers []*selector.SelectedPodContainer
+
+	expected []string
+}
+
+func TestPreferNewestContainers(t *testing.T) {
+	now := time.Now()
+	testCases := []preferNewestTestCase{
+		{
+			name: "Newest first",
+			containers: []*selector.SelectedPodContainer{
+				newSelectedPodContainer("old", "app", now.Add(-time.Minute), false),
+				newSelectedPodContainer("new", "app", now, false),
+			},
+			expected: []string{"new:app", "old:app"},
+		},
+		{
+			name: "Filter deleted pods",
+			containers: []*selector.SelectedPodContainer{
+				newSelectedPodContainer("terminating", "app", now, true),
+				newSelectedPodContainer("old", "app", now.Add(-time.Minute), false),
+			},
+			expected: []string{"old:app"},
+		},
+		{
+			name: "Tie-break by pod name",
+			containers: []*selector.SelectedPodContainer{
+				newSelectedPodContainer("pod-b", "app", now, false),
+				newSelectedPodContainer("pod-a", "app", now, false),
+			},
+			expected: []string{"pod-a:app", "pod-b:app"},
+		},
+		{
+			name: "Keep container order of the same pod",
+			containers: []*selector.SelectedPodContainer{
+				newSelectedPodContainer("pod", "main", now, false),
+				newSelectedPodContainer("pod", "init", now, false),
+			},
+			expected: []string{"pod:main", "pod:init"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		names := []string{}
+		for _, container := range preferNewestContainers(testCase.containers) {
+			names = append(names, container.Pod.Name+":"+container.Container.Name)
+		}
+		assert.DeepEqual(t, names, testCase.expected)
+	}
+}
+
+func TestPreferNewestPods(t *testing.T) {
+	now := time.Now()
+	pods := preferNewestPods([]*v1.Pod{
+		newSelectedPodContainer("pod-b", "app", now, false).Pod,
+		newSelectedPodContainer("terminating", "app", now.Add(time.Minute), true).Pod,
+		newSelectedPodContainer("pod-a", "app", now, false).Pod,
+		newSelectedPodContainer("old", "app", now.Add(-time.Minute), false).Pod,
+	})
+
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	assert.DeepEqual(t, names, []string{"pod-a", "pod-b", "old"})
+}
+
+func newSelectedPodContainer(pod string, container string, created time.Time, deleted bool) *selector.SelectedPodContainer {
+	selected := &selector.SelectedPodContainer{
+		Pod: &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              pod,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		},
+		Container: &v1.Container{Name: container},
+	}
+	if deleted {
+		deletionTimestamp := metav1.NewTime(created)
+		selected.Pod.DeletionTimestamp = &deletionTimestamp
+	}
+
+	return selected
+}
@@ -0,0 +1,151 @@
+package targetselector
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// prioritySelector selects the first running container of multiple selectors. If containers
+// of multiple selectors are running, the selector given first wins.
+type prioritySelector struct {
+	options []Options
+
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// NewPriorityTargetSelector creates a target selector that waits until one of the given selectors
+// matches a running container and selects it. The order of the options is the order of priority.
+func NewPriorityTargetSelector(options ...Options) TargetSelector {
+	return &prioritySelector{
+		options:  options,
+		interval: time.Millisecond * 500,
+		timeout:  time.Minute * 10,
+	}
+}
+
+func (p *prioritySelector) with(fn func(options Options) Options) TargetSelector {
+	newOptions := []Options{}
+	for _, options := range p.options {
+		newOptions = append(newOptions, fn(options))
+	}
+
+	return &prioritySelector{
+		options:  newOptions,
+		interval: p.interval,
+		timeout:  p.timeout,
+	}
+}
+
+func (p *prioritySelector) WithContainer(container string) TargetSelector {
+	return p.with(func(options Options) Options {
+		return options.WithContainer(container)
+	})
+}
+
+func (p *prioritySelector) WithNamespace(namespace string) TargetSelector {
+	return p.with(func(options Options) Options {
+		return options.WithNamespace(namespace)
+	})
+}
+
+func (p *prioritySelector) WithPreferNewest() TargetSelector {
+	return p.with(func(options Options) Options {
+		return options.WithPreferNewest()
+	})
+}
+
+func (p *prioritySelector) WithAvoidScalingDown() TargetSelector {
+	return p.with(func(options Options) Options {
+		return options.WithAvoidScalingDown()
+	})
+}
+
+func (p *prioritySelector) WithCache() TargetSelector {
+	return p.with(func(options Options) Options {
+		return options.WithCache(true)
+	})
+}
+
+func (p *prioritySelector) WithBypassCache() TargetSelector {
+	return p.with(func(options Options) Options {
+		return options.WithCache(false)
+	})
+}
+
+func (p *prioritySelector) SelectSinglePod(ctx context.Context, client kubectl.Client, log log.Logger) (*v1.Pod, error) {
+	container, err := p.SelectSingleContainer(ctx, client, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return container.Pod, nil
+}
+
+func (p *prioritySelector) SelectSingleContainer(ctx context.Context, client kubectl.Client, log log.Logger) (*selector.SelectedPodContainer, error) {
+	var selected *selector.SelectedPodContainer
+	err := wait.PollUntilContextTimeout(ctx, p.interval, p.timeout, true, func(ctx context.Context) (bool, error) {
+		container, err := p.selectFirstRunning(ctx, client, log)
+		if err != nil {
+			return false, err
+		} else if container == nil {
+			log.Debugf("Waiting for a running container with %s", p.String())
+			return false, nil
+		}
+
+		selected = container
+		return true, nil
+	})
+	if err != nil {
+		if wait.Interrupted(err) {
+			return nil, &NotFoundErr{
+				Timeout:  true,
+				Selector: p.String(),
+			}
+		}
+
+		return nil, err
+	}
+
+	return selected, nil
+}
+
+func (p *prioritySelector) selectFirstRunning(ctx context.Context, client kubectl.Client, log log.Logger) (*selector.SelectedPodContainer, error) {
+	for _, options := range p.options {
+		podSelectors := options.selectors()
+		for i := range podSelectors {
+			podSelectors[i].FilterContainer = selector.FilterNonRunningContainers
+		}
+		containers, err := selector.NewFilterWithSort(client, options.sortContainers).SelectContainers(ctx, podSelectors...)
+		if err != nil {
+			return nil, err
+		}
+		if options.preferNewest {
+			containers = preferNewestContainers(containers)
+		}
+		if options.avoidScalingDown {
+			containers = avoidScalingDownContainers(ctx, client, containers, log)
+		}
+		if len(containers) > 0 {
+			return containers[0], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *prioritySelector) String() string {
+	selectors := []string{}
+	for _, options := range p.options {
+		selectors = append(selectors, options.selector.String())
+	}
+
+	return strings.Join(selectors, " or ")
+}
@@ -0,0 +1,98 @@
+package targetselector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type prioritySelectorTestCase struct {
+	name string
+	pods []*v1.Pod
+
+	expectedPod string
+}
+
+func TestPrioritySelector(t *testing.T) {
+	testCases := []prioritySelectorTestCase{
+		{
+			name: "First selector wins",
+			pods: []*v1.Pod{
+				newRunningPod("worker", map[string]string{"app": "worker"}, true),
+				newRunningPod("api", map[string]string{"app": "api"}, true),
+			},
+			expectedPod: "api",
+		},
+		{
+			name: "Skip selector without running container",
+			pods: []*v1.Pod{
+				newRunningPod("worker", map[string]string{"app": "worker"}, true),
+				newRunningPod("api", map[string]string{"app": "api"}, false),
+			},
+			expectedPod: "worker",
+		},
+	}
+
+	for _, testCase := range testCases {
+		kubeClient := fake.NewSimpleClientset()
+		for _, pod := range testCase.pods {
+			_, err := kubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+			assert.NilError(t, err)
+		}
+
+		container, err := newTestPrioritySelector().SelectSingleContainer(context.Background(), &kubectltesting.Client{Client: kubeClient}, log.Discard)
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, container.Pod.Name, testCase.expectedPod, "Unexpected pod in "+testCase.name)
+	}
+}
+
+func TestPrioritySelectorWait(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(newRunningPod("api", map[string]string{"app": "api"}, false))
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		_, _ = kubeClient.CoreV1().Pods("testNamespace").Create(context.Background(), newRunningPod("worker", map[string]string{"app": "worker"}, true), metav1.CreateOptions{})
+	}()
+
+	container, err := newTestPrioritySelector().SelectSingleContainer(context.Background(), &kubectltesting.Client{Client: kubeClient}, log.Discard)
+	assert.NilError(t, err)
+	assert.Equal(t, container.Pod.Name, "worker")
+}
+
+func newTestPrioritySelector() TargetSelector {
+	selector := NewPriorityTargetSelector(
+		NewEmptyOptions().WithLabelSelector("app=api"),
+		NewEmptyOptions().WithLabelSelector("app=worker"),
+	).(*prioritySelector)
+	selector.interval = time.Millisecond * 10
+	selector.timeout = time.Second * 5
+	return selector
+}
+
+func newRunningPod(name string, labels map[string]string, ready bool) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "testNamespace",
+			Labels:    labels,
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "app"}},
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:  "app",
+					Ready: ready,
+					State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+				},
+			},
+		},
+	}
+}
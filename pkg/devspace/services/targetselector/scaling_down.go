@@ -0,0 +1,106 @@
+package targetselector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ScalingDownErr is returned if pods match the selector, but all of them are about to be
+// removed, because the replica sets owning them are scaled down
+type ScalingDownErr struct {
+	Selector string
+}
+
+func (s *ScalingDownErr) Error() string {
+	return fmt.Sprintf("all pods matching %s are being scaled down", s.Selector)
+}
+
+// avoidScalingDownContainers removes the containers of pods that are about to be removed because
+// their replica set is scaled down. If the pods of a replica set cannot be determined, they are kept.
+func avoidScalingDownContainers(ctx context.Context, client kubectl.Client, containers []*selector.SelectedPodContainer, log log.Logger) []*selector.SelectedPodContainer {
+	excessPods := map[types.UID]bool{}
+	checkedReplicaSets := map[types.UID]bool{}
+	retContainers := []*selector.SelectedPodContainer{}
+	for _, container := range containers {
+		owner := metav1.GetControllerOf(container.Pod)
+		if container.Pod.DeletionTimestamp == nil && owner != nil && owner.Kind == "ReplicaSet" && !checkedReplicaSets[owner.UID] {
+			checkedReplicaSets[owner.UID] = true
+			pods, err := excessReplicaSetPods(ctx, client, container.Pod.Namespace, owner.Name)
+			if err != nil {
+				log.Debugf("Error checking if replica set %s is scaled down: %v", owner.Name, err)
+			}
+			for _, pod := range pods {
+				excessPods[pod.UID] = true
+			}
+		}
+
+		if container.Pod.DeletionTimestamp != nil || excessPods[container.Pod.UID] {
+			log.Debugf("Skipping pod %s, because it is being scaled down", container.Pod.Name)
+			continue
+		}
+
+		retContainers = append(retContainers, container)
+	}
+
+	return retContainers
+}
+
+// excessReplicaSetPods returns the pods of the replica set that exceed its desired replicas. The pods
+// are ranked similar to the replica set controller, which removes not ready and newer pods first.
+func excessReplicaSetPods(ctx context.Context, client kubectl.Client, namespace, name string) ([]v1.Pod, error) {
+	replicaSet, err := client.KubeClient().AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	desired := 1
+	if replicaSet.Spec.Replicas != nil {
+		desired = int(*replicaSet.Spec.Replicas)
+	}
+	labelSelector, err := metav1.LabelSelectorAsSelector(replicaSet.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	podList, err := client.KubeClient().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	pods := []v1.Pod{}
+	for _, pod := range podList.Items {
+		owner := metav1.GetControllerOf(&pod)
+		if pod.DeletionTimestamp == nil && owner != nil && owner.UID == replicaSet.UID {
+			pods = append(pods, pod)
+		}
+	}
+	if len(pods) <= desired {
+		return nil, nil
+	}
+
+	sort.SliceStable(pods, func(i, j int) bool {
+		if isPodReady(&pods[i]) != isPodReady(&pods[j]) {
+			return isPodReady(&pods[i])
+		}
+
+		return isNewerPod(&pods[j], &pods[i])
+	})
+	return pods[desired:], nil
+}
+
+func isPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package targetselector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type avoidScalingDownTestCase struct {
+	name     string
+	replicas int32
+	pods     []*v1.Pod
+
+	expectedPod string
+	expectedErr string
+}
+
+func TestAvoidScalingDown(t *testing.T) {
+	now := time.Now()
+	testCases := []avoidScalingDownTestCase{
+		{
+			name:     "Skip newer excess pod",
+			replicas: 1,
+			pods: []*v1.Pod{
+				newReplicaSetPod("new", now, true),
+				newReplicaSetPod("old", now.Add(-time.Minute), true),
+			},
+			expectedPod: "old",
+		},
+		{
+			name:     "Skip not ready excess pod",
+			replicas: 1,
+			pods: []*v1.Pod{
+				newReplicaSetPod("old", now.Add(-time.Minute), false),
+				newReplicaSetPod("new", now, true),
+			},
+			expectedPod: "new",
+		},
+		{
+			name:     "Keep pods of a stable replica set",
+			replicas: 2,
+			pods: []*v1.Pod{
+				newReplicaSetPod("new", now, true),
+				newReplicaSetPod("old", now.Add(-time.Minute), true),
+			},
+			expectedPod: "new",
+		},
+		{
+			name:     "All pods scaled down",
+			replicas: 0,
+			pods: []*v1.Pod{
+				newReplicaSetPod("old", now.Add(-time.Minute), true),
+			},
+			expectedErr: "all pods matching label selector: app=api are being scaled down",
+		},
+	}
+
+	for _, testCase := range testCases {
+		replicas := testCase.replicas
+		objects := []runtime.Object{&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "testNamespace", UID: "api"},
+			Spec: appsv1.ReplicaSetSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			},
+		}}
+		for _, pod := range testCase.pods {
+			objects = append(objects, pod)
+		}
+		client := &kubectltesting.Client{Client: fake.NewSimpleClientset(objects...)}
+		options := NewOptionsFromFlags("", "app=api", nil, "testNamespace", "").
+			WithWait(false).
+			WithAvoidScalingDown()
+
+		container, err := NewTargetSelector(options).SelectSingleContainer(context.Background(), client, log.Discard)
+		if testCase.expectedErr != "" {
+			assert.Error(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+			continue
+		}
+
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, container.Pod.Name, testCase.expectedPod, "Unexpected pod in "+testCase.name)
+	}
+}
+
+func newReplicaSetPod(name string, created time.Time, ready bool) *v1.Pod {
+	pod := newRunningPod(name, map[string]string{"app": "api"}, ready)
+	pod.UID = types.UID(name)
+	pod.CreationTimestamp = metav1.NewTime(created)
+	pod.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "api", UID: "api"}}, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))}
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: status}}
+	return pod
+}
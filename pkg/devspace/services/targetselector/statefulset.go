@@ -0,0 +1,72 @@
+package targetselector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// statefulSetOrdinal is a replica of a stateful set that is selected by its ordinal
+type statefulSetOrdinal struct {
+	name    string
+	ordinal int
+}
+
+// podName returns the name of the pod of the replica, which is <name>-<ordinal>
+func (s *statefulSetOrdinal) podName() string {
+	return fmt.Sprintf("%s-%d", s.name, s.ordinal)
+}
+
+// validate checks that the ordinal is in range of the replicas of the stateful set and that the
+// pod of the replica exists and belongs to the stateful set. If requireReady is true, the pod also
+// has to be ready.
+func (s *statefulSetOrdinal) validate(ctx context.Context, client kubectl.Client, namespace string, requireReady bool) error {
+	if namespace == "" {
+		namespace = client.Namespace()
+	}
+
+	statefulSet, err := client.KubeClient().AppsV1().StatefulSets(namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return errors.Errorf("statefulset %s not found in namespace %s", s.name, namespace)
+		}
+
+		return errors.Wrapf(err, "get statefulset %s", s.name)
+	}
+
+	start := 0
+	if statefulSet.Spec.Ordinals != nil {
+		start = int(statefulSet.Spec.Ordinals.Start)
+	}
+	replicas := 1
+	if statefulSet.Spec.Replicas != nil {
+		replicas = int(*statefulSet.Spec.Replicas)
+	}
+	if replicas == 0 {
+		return errors.Errorf("ordinal %d is out of range, statefulset %s is scaled down to 0 replicas", s.ordinal, s.name)
+	} else if s.ordinal < start || s.ordinal >= start+replicas {
+		return errors.Errorf("ordinal %d is out of range, statefulset %s has the ordinals %d to %d", s.ordinal, s.name, start, start+replicas-1)
+	}
+
+	pod, err := client.KubeClient().CoreV1().Pods(namespace).Get(ctx, s.podName(), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return errors.Errorf("pod %s of statefulset %s doesn't exist yet", s.podName(), s.name)
+		}
+
+		return errors.Wrapf(err, "get pod %s", s.podName())
+	}
+
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "StatefulSet" || owner.UID != statefulSet.UID {
+		return errors.Errorf("pod %s doesn't belong to statefulset %s", pod.Name, s.name)
+	} else if requireReady && !isPodReady(pod) {
+		return errors.Errorf("pod %s of statefulset %s is not ready, use --wait to wait until it is", pod.Name, s.name)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package targetselector
+
+import (
+	"context"
+	"testing"
+
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	logtesting "github.com/loft-sh/devspace/pkg/util/log/testing"
+	"github.com/loft-sh/devspace/pkg/util/ptr"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newStatefulSetPod(statefulSet *appsv1.StatefulSet, ordinal string, ready bool) *v1.Pod {
+	pod := newRunningPod(statefulSet.Name+"-"+ordinal, map[string]string{"app": statefulSet.Name}, ready)
+	pod.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "apps/v1",
+		Kind:       "StatefulSet",
+		Name:       statefulSet.Name,
+		UID:        statefulSet.UID,
+		Controller: ptr.Bool(true),
+	}}
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: status}}
+	return pod
+}
+
+type statefulSetOrdinalTestCase struct {
+	name    string
+	ordinal int
+	wait    bool
+
+	expectedPod string
+	expectedErr string
+}
+
+func TestSelectStatefulSetOrdinal(t *testing.T) {
+	testCases := []statefulSetOrdinalTestCase{
+		{
+			name:        "Select replica",
+			ordinal:     2,
+			expectedPod: "db-2",
+		},
+		{
+			name:        "Ordinal out of range",
+			ordinal:     3,
+			expectedErr: "ordinal 3 is out of range, statefulset db has the ordinals 0 to 2",
+		},
+		{
+			name:        "Pod not ready",
+			ordinal:     1,
+			expectedErr: "pod db-1 of statefulset db is not ready",
+		},
+		{
+			name:        "Wait for pod that is not ready",
+			ordinal:     1,
+			wait:        true,
+			expectedPod: "db-1",
+		},
+		{
+			name:        "Pod of another owner",
+			ordinal:     0,
+			expectedErr: "pod db-0 doesn't belong to statefulset db",
+		},
+	}
+
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "testNamespace", UID: types.UID("db")},
+		Spec:       appsv1.StatefulSetSpec{Replicas: ptr.Int32(3)},
+	}
+	otherPod := newRunningPod("db-0", map[string]string{"app": "db"}, true)
+	client := &kubectltesting.Client{Client: fake.NewSimpleClientset(
+		statefulSet,
+		otherPod,
+		newStatefulSetPod(statefulSet, "1", false),
+		newStatefulSetPod(statefulSet, "2", true),
+	)}
+
+	for _, testCase := range testCases {
+		options := NewEmptyOptions().WithStatefulSetOrdinal("db", testCase.ordinal).WithWait(testCase.wait)
+		container, err := NewTargetSelector(options).SelectSingleContainer(context.Background(), client, logtesting.NewFakeLogger())
+		if testCase.expectedErr != "" {
+			assert.ErrorContains(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+			continue
+		}
+
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, container.Pod.Name, testCase.expectedPod, "Unexpected pod in "+testCase.name)
+	}
+
+	_, err := NewTargetSelector(NewEmptyOptions().WithStatefulSetOrdinal("cache", 0).WithWait(false)).SelectSinglePod(context.Background(), client, logtesting.NewFakeLogger())
+	assert.ErrorContains(t, err, "statefulset cache not found in namespace testNamespace")
+}
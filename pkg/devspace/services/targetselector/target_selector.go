@@ -3,6 +3,7 @@ package targetselector
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/loft-sh/devspace/pkg/devspace/imageselector"
@@ -26,7 +27,8 @@ const DefaultContainerQuestion = "Select a container"
 
 // Options holds the options for a target selector
 type Options struct {
-	selector selector.Selector
+	selector   selector.Selector
+	namespaces []string
 
 	allowPick bool
 	question  string
@@ -34,10 +36,16 @@ type Options struct {
 	wait    *bool
 	timeout int64
 
-	failIfMultiple bool
-	sortContainers selector.SortContainers
+	failIfMultiple   bool
+	sortContainers   selector.SortContainers
+	preferNewest     bool
+	avoidScalingDown bool
 
 	waitingStrategy WaitingStrategy
+
+	cache bool
+
+	statefulSet *statefulSetOrdinal
 }
 
 func NewEmptyOptions() Options {
@@ -69,6 +77,16 @@ func (o Options) WithPod(pod string) Options {
 	return newOptions
 }
 
+// WithStatefulSetOrdinal selects the replica of the stateful set with the given ordinal, which is
+// the pod <name>-<ordinal>. The selection fails if the ordinal is out of range, the pod doesn't
+// belong to the stateful set or isn't ready without waiting.
+func (o Options) WithStatefulSetOrdinal(name string, ordinal int) Options {
+	newOptions := o
+	newOptions.statefulSet = &statefulSetOrdinal{name: name, ordinal: ordinal}
+	newOptions.selector.Pod = newOptions.statefulSet.podName()
+	return newOptions
+}
+
 func (o Options) WithLabelSelector(labelSelector string) Options {
 	newOptions := o
 	newOptions.selector.LabelSelector = labelSelector
@@ -108,9 +126,29 @@ func (o Options) WithTimeout(timeout int64) Options {
 func (o Options) WithNamespace(namespace string) Options {
 	newOptions := o
 	newOptions.selector.Namespace = namespace
+	newOptions.namespaces = nil
+	return newOptions
+}
+
+// WithNamespaces selects containers in all of the given namespaces. The
+// namespace is shown when picking one of multiple matching containers.
+func (o Options) WithNamespaces(namespaces ...string) Options {
+	if len(namespaces) == 1 {
+		return o.WithNamespace(namespaces[0])
+	}
+
+	newOptions := o
+	newOptions.selector.Namespace = ""
+	newOptions.namespaces = namespaces
 	return newOptions
 }
 
+// WithAllNamespaces selects containers in all namespaces, which is usually
+// combined with a label or image selector
+func (o Options) WithAllNamespaces() Options {
+	return o.WithNamespace(selector.AllNamespaces)
+}
+
 func (o Options) WithSkipInitContainers(skip bool) Options {
 	newOptions := o
 	newOptions.selector.SkipInitContainers = skip
@@ -129,6 +167,31 @@ func (o Options) WithWaitingStrategy(waitingStrategy WaitingStrategy) Options {
 	return newOptions
 }
 
+// WithPreferNewest ignores pods that are being deleted and prefers the newest
+// pod, e.g. to select the surviving pod during a rolling update
+func (o Options) WithPreferNewest() Options {
+	newOptions := o
+	newOptions.preferNewest = true
+	return newOptions
+}
+
+// WithAvoidScalingDown ignores pods that are about to be removed, because the
+// replica set owning them has more pods than desired
+func (o Options) WithAvoidScalingDown() Options {
+	newOptions := o
+	newOptions.avoidScalingDown = true
+	return newOptions
+}
+
+// WithCache reuses a container that was selected with the same options within DefaultCacheTTL
+// instead of listing the pods again. The pod of a reused container is fetched again and the
+// container is only reused if it still runs in the same pod, otherwise the pods are listed.
+func (o Options) WithCache(cache bool) Options {
+	newOptions := o
+	newOptions.cache = cache
+	return newOptions
+}
+
 func (o Options) WithPick(allowPick bool) Options {
 	newOptions := o
 	newOptions.allowPick = allowPick
@@ -143,7 +206,7 @@ func (o Options) ApplyConfigParameter(containerName string, labelSelector map[st
 	if labelSelector != nil && o.selector.LabelSelector == "" {
 		newOptions.selector.LabelSelector = labels.Set(labelSelector).String()
 	}
-	if namespace != "" && o.selector.Namespace == "" {
+	if namespace != "" && o.selector.Namespace == "" && len(o.namespaces) == 0 {
 		newOptions.selector.Namespace = namespace
 	}
 	if pod != "" && o.selector.Pod == "" {
@@ -155,6 +218,37 @@ func (o Options) ApplyConfigParameter(containerName string, labelSelector map[st
 	return newOptions
 }
 
+// selectors returns a selector for each namespace containers are selected in
+func (o Options) selectors() []selector.Selector {
+	if len(o.namespaces) == 0 {
+		return []selector.Selector{o.selector}
+	}
+
+	selectors := []selector.Selector{}
+	for _, namespace := range o.namespaces {
+		namespaceSelector := o.selector
+		namespaceSelector.Namespace = namespace
+		selectors = append(selectors, namespaceSelector)
+	}
+
+	return selectors
+}
+
+// multipleNamespaces checks if containers are selected in more than one namespace
+func (o Options) multipleNamespaces() bool {
+	return len(o.namespaces) > 0 || o.selector.Namespace == selector.AllNamespaces
+}
+
+// namespace returns the namespace containers are selected in or an empty
+// string, which means the namespace of the client, if there are multiple
+func (o Options) namespace() string {
+	if o.multipleNamespaces() {
+		return ""
+	}
+
+	return o.selector.Namespace
+}
+
 func ToStringImageSelector(imageSelector []imageselector.ImageSelector) []string {
 	imageSelectors := []string{}
 	for _, i := range imageSelector {
@@ -173,6 +267,11 @@ type TargetSelector interface {
 	SelectSingleContainer(ctx context.Context, client kubectl.Client, log log.Logger) (*selector.SelectedPodContainer, error)
 
 	WithContainer(container string) TargetSelector
+	WithNamespace(namespace string) TargetSelector
+	WithPreferNewest() TargetSelector
+	WithAvoidScalingDown() TargetSelector
+	WithCache() TargetSelector
+	WithBypassCache() TargetSelector
 }
 
 // targetSelector is the struct that will select a target
@@ -193,9 +292,56 @@ func (t *targetSelector) WithContainer(container string) TargetSelector {
 	}
 }
 
+func (t *targetSelector) WithNamespace(namespace string) TargetSelector {
+	return &targetSelector{
+		options: t.options.WithNamespace(namespace),
+	}
+}
+
+func (t *targetSelector) WithPreferNewest() TargetSelector {
+	return &targetSelector{
+		options: t.options.WithPreferNewest(),
+	}
+}
+
+func (t *targetSelector) WithAvoidScalingDown() TargetSelector {
+	return &targetSelector{
+		options: t.options.WithAvoidScalingDown(),
+	}
+}
+
+func (t *targetSelector) WithCache() TargetSelector {
+	return &targetSelector{
+		options: t.options.WithCache(true),
+	}
+}
+
+func (t *targetSelector) WithBypassCache() TargetSelector {
+	return &targetSelector{
+		options: t.options.WithCache(false),
+	}
+}
+
 func (t *targetSelector) SelectSingleContainer(ctx context.Context, client kubectl.Client, log log.Logger) (*selector.SelectedPodContainer, error) {
 	log.Debugf("Start selecting a single container with selector %v", t.options.selector.String())
 
+	cacheKey, cached := t.cacheKey(client)
+	if cached {
+		if container := containers.get(cacheKey); container != nil {
+			if running := stillRunning(ctx, client, container); running != nil {
+				log.Debugf("Reuse selected container %s:%s", container.Pod.Name, container.Container.Name)
+				return running, nil
+			}
+
+			log.Debugf("Selected container %s:%s is not running anymore, selecting again", container.Pod.Name, container.Container.Name)
+			containers.remove(cacheKey)
+		}
+	}
+
+	err := t.validateStatefulSet(ctx, client)
+	if err != nil {
+		return nil, err
+	}
 	if t.options.waitingStrategy != nil {
 		t.options.waitingStrategy = t.options.waitingStrategy.Reset()
 	}
@@ -207,12 +353,28 @@ func (t *targetSelector) SelectSingleContainer(ctx context.Context, client kubec
 		return nil, nil
 	}
 
+	if cached {
+		containers.set(cacheKey, container.(*selector.SelectedPodContainer))
+	}
 	return container.(*selector.SelectedPodContainer), nil
 }
 
+// cacheKey returns the key of the selected container in the cache and false if the selection should not be cached
+func (t *targetSelector) cacheKey(client kubectl.Client) (containerCacheKey, bool) {
+	if !t.options.cache || !t.options.cacheable() || client == nil || !reflect.TypeOf(client).Comparable() {
+		return containerCacheKey{}, false
+	}
+
+	return containerCacheKey{client: client, fingerprint: t.options.fingerprint(client)}, true
+}
+
 func (t *targetSelector) SelectSinglePod(ctx context.Context, client kubectl.Client, log log.Logger) (*v1.Pod, error) {
 	log.Debugf("Start selecting a single pod with selector %v", t.options.selector.String())
 
+	err := t.validateStatefulSet(ctx, client)
+	if err != nil {
+		return nil, err
+	}
 	if t.options.waitingStrategy != nil {
 		t.options.waitingStrategy = t.options.waitingStrategy.Reset()
 	}
@@ -227,6 +389,16 @@ func (t *targetSelector) SelectSinglePod(ctx context.Context, client kubectl.Cli
 	return pod.(*v1.Pod), nil
 }
 
+// validateStatefulSet validates the stateful set replica if one is selected. The pod only
+// has to be ready if we don't wait for it.
+func (t *targetSelector) validateStatefulSet(ctx context.Context, client kubectl.Client) error {
+	if t.options.statefulSet == nil {
+		return nil
+	}
+
+	return t.options.statefulSet.validate(ctx, client, t.options.namespace(), t.options.wait != nil && !*t.options.wait)
+}
+
 func (t *targetSelector) selectSingle(ctx context.Context, client kubectl.Client, options Options, log log.Logger, selectFn func(ctx context.Context, client kubectl.Client, options Options, log log.Logger) (bool, interface{}, error)) (interface{}, error) {
 	if options.wait == nil || *options.wait {
 		timeout := time.Minute * 10
@@ -275,11 +447,21 @@ func (t *targetSelector) selectSingle(ctx context.Context, client kubectl.Client
 }
 
 func (t *targetSelector) selectSingleContainer(ctx context.Context, client kubectl.Client, options Options, log log.Logger) (bool, interface{}, error) {
-	containers, err := selector.NewFilterWithSort(client, options.sortContainers).SelectContainers(ctx, options.selector)
+	containers, err := selector.NewFilterWithSort(client, options.sortContainers).SelectContainers(ctx, options.selectors()...)
 	if err != nil {
 		return false, nil, err
-	} else if options.waitingStrategy != nil {
-		return options.waitingStrategy.SelectContainer(ctx, client, options.selector.Namespace, containers, log)
+	}
+	if options.preferNewest {
+		containers = preferNewestContainers(containers)
+	}
+	if options.avoidScalingDown && len(containers) > 0 {
+		containers = avoidScalingDownContainers(ctx, client, containers, log)
+		if len(containers) == 0 {
+			return false, nil, &ScalingDownErr{Selector: options.selector.String()}
+		}
+	}
+	if options.waitingStrategy != nil {
+		return options.waitingStrategy.SelectContainer(ctx, client, options.namespace(), containers, log)
 	}
 
 	if len(containers) == 0 {
@@ -291,7 +473,7 @@ func (t *targetSelector) selectSingleContainer(ctx context.Context, client kubec
 	if options.allowPick {
 		names := []string{}
 		for _, container := range containers {
-			names = append(names, container.Pod.Name+":"+container.Container.Name)
+			names = append(names, containerName(container, options.multipleNamespaces()))
 		}
 
 		question := DefaultContainerQuestion
@@ -299,7 +481,7 @@ func (t *targetSelector) selectSingleContainer(ctx context.Context, client kubec
 			question = options.question
 		}
 
-		containerName, err := log.Question(&survey.QuestionOptions{
+		answer, err := log.Question(&survey.QuestionOptions{
 			Question: question,
 			Options:  names,
 		})
@@ -308,7 +490,7 @@ func (t *targetSelector) selectSingleContainer(ctx context.Context, client kubec
 		}
 
 		for _, container := range containers {
-			if container.Pod.Name+":"+container.Container.Name == containerName {
+			if containerName(container, options.multipleNamespaces()) == answer {
 				return true, container, nil
 			}
 		}
@@ -325,15 +507,25 @@ func (t *targetSelector) selectSingleContainer(ctx context.Context, client kubec
 }
 
 func (t *targetSelector) selectSinglePod(ctx context.Context, client kubectl.Client, options Options, log log.Logger) (bool, interface{}, error) {
-	stack, err := selector.NewFilterWithSort(client, options.sortContainers).SelectContainers(ctx, options.selector)
+	stack, err := selector.NewFilterWithSort(client, options.sortContainers).SelectContainers(ctx, options.selectors()...)
 	if err != nil {
 		return false, nil, err
 	}
 
+	if options.avoidScalingDown && len(stack) > 0 {
+		stack = avoidScalingDownContainers(ctx, client, stack, log)
+		if len(stack) == 0 {
+			return false, nil, &ScalingDownErr{Selector: options.selector.String()}
+		}
+	}
+
 	// transform stack
 	pods := selector.PodsFromPodContainer(stack)
+	if options.preferNewest {
+		pods = preferNewestPods(pods)
+	}
 	if options.waitingStrategy != nil {
-		namespace := options.selector.Namespace
+		namespace := options.namespace()
 		if namespace == "" {
 			namespace = client.Namespace()
 		}
@@ -350,7 +542,7 @@ func (t *targetSelector) selectSinglePod(ctx context.Context, client kubectl.Cli
 	if options.allowPick {
 		podNames := []string{}
 		for _, pod := range pods {
-			podNames = append(podNames, pod.Name)
+			podNames = append(podNames, podName(pod, options.multipleNamespaces()))
 		}
 
 		question := DefaultPodQuestion
@@ -358,7 +550,7 @@ func (t *targetSelector) selectSinglePod(ctx context.Context, client kubectl.Cli
 			question = options.question
 		}
 
-		podName, err := log.Question(&survey.QuestionOptions{
+		answer, err := log.Question(&survey.QuestionOptions{
 			Question: question,
 			Options:  podNames,
 		})
@@ -367,7 +559,7 @@ func (t *targetSelector) selectSinglePod(ctx context.Context, client kubectl.Cli
 		}
 
 		for _, pod := range pods {
-			if pod.Name == podName {
+			if podName(pod, options.multipleNamespaces()) == answer {
 				return true, pod, nil
 			}
 		}
@@ -383,6 +575,20 @@ func (t *targetSelector) selectSinglePod(ctx context.Context, client kubectl.Cli
 	return true, pods[0], nil
 }
 
+// podName returns the name of the pod that is shown when picking a pod
+func podName(pod *v1.Pod, withNamespace bool) string {
+	if withNamespace {
+		return pod.Namespace + "/" + pod.Name
+	}
+
+	return pod.Name
+}
+
+// containerName returns the name of the container that is shown when picking a container
+func containerName(container *selector.SelectedPodContainer, withNamespace bool) string {
+	return podName(container.Pod, withNamespace) + ":" + container.Container.Name
+}
+
 type NotFoundErr struct {
 	Timeout  bool
 	Selector string
@@ -0,0 +1,94 @@
+package targetselector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	logtesting "github.com/loft-sh/devspace/pkg/util/log/testing"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type multipleNamespacesTestCase struct {
+	name    string
+	options Options
+	answer  string
+
+	expectedNamespace string
+}
+
+func TestSelectSingleContainerMultipleNamespaces(t *testing.T) {
+	testCases := []multipleNamespacesTestCase{
+		{
+			name:              "Pick among namespaces",
+			options:           NewEmptyOptions().WithLabelSelector("app=api").WithNamespaces("dev", "staging", "prod").WithPick(true),
+			answer:            "staging/api:app",
+			expectedNamespace: "staging",
+		},
+		{
+			name:              "Pick in all namespaces",
+			options:           NewEmptyOptions().WithLabelSelector("app=api").WithAllNamespaces().WithPick(true),
+			answer:            "prod/api:app",
+			expectedNamespace: "prod",
+		},
+		{
+			name:              "Newest without pick",
+			options:           NewEmptyOptions().WithLabelSelector("app=api").WithNamespaces("dev", "staging"),
+			expectedNamespace: "staging",
+		},
+		{
+			name:              "Pod name in all namespaces",
+			options:           NewEmptyOptions().WithPod("api").WithAllNamespaces().WithPick(true),
+			answer:            "dev/api:app",
+			expectedNamespace: "dev",
+		},
+		{
+			name:              "Single namespace",
+			options:           NewEmptyOptions().WithLabelSelector("app=api").WithNamespaces("prod").WithPick(true),
+			expectedNamespace: "prod",
+		},
+	}
+
+	now := time.Now()
+	kubeClient := fake.NewSimpleClientset()
+	for i, namespace := range []string{"dev", "staging", "prod"} {
+		pod := newRunningPod("api", map[string]string{"app": "api"}, true)
+		pod.Namespace = namespace
+		pod.CreationTimestamp = metav1.NewTime(now.Add(time.Duration(i) * time.Minute))
+		_, err := kubeClient.CoreV1().Pods(namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+	}
+	client := &kubectltesting.Client{Client: kubeClient}
+
+	for _, testCase := range testCases {
+		logger := logtesting.NewFakeLogger()
+		if testCase.answer != "" {
+			logger.SetAnswer(testCase.answer)
+		}
+
+		container, err := NewTargetSelector(testCase.options.WithWait(false)).SelectSingleContainer(context.Background(), client, logger)
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, container.Pod.Namespace, testCase.expectedNamespace, "Unexpected namespace in "+testCase.name)
+	}
+}
+
+func TestSelectSinglePodMultipleNamespaces(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	for _, namespace := range []string{"dev", "staging", "prod"} {
+		pod := newRunningPod("api", map[string]string{"app": "api"}, true)
+		pod.Namespace = namespace
+		_, err := kubeClient.CoreV1().Pods(namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		assert.NilError(t, err)
+	}
+
+	logger := logtesting.NewFakeLogger()
+	logger.SetAnswer("prod/api")
+	options := NewEmptyOptions().WithLabelSelector("app=api").WithAllNamespaces().WithPick(true).WithWait(false)
+
+	pod, err := NewTargetSelector(options).SelectSinglePod(context.Background(), &kubectltesting.Client{Client: kubeClient}, logger)
+	assert.NilError(t, err)
+	assert.Equal(t, pod.Namespace, "prod")
+}
@@ -0,0 +1,112 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/pkg/errors"
+)
+
+// BackgroundTerminal is a terminal running in its own goroutine, which can be closed
+// without cancelling the context of the caller
+type BackgroundTerminal struct {
+	cancel    context.CancelFunc
+	stdin     *io.PipeWriter
+	done      chan struct{}
+	closeOnce sync.Once
+
+	exitCode int
+	err      error
+}
+
+// StartTerminalInBackground runs StartTerminalFromCMDWithOptions in a new goroutine and
+// returns a handle to close the terminal
+func StartTerminalInBackground(
+	ctx devspacecontext.Context,
+	selector targetselector.TargetSelector,
+	options *TerminalOptions,
+) *BackgroundTerminal {
+	return startInBackground(ctx, options, func(ctx devspacecontext.Context, options *TerminalOptions) (int, error) {
+		return StartTerminalFromCMDWithOptions(ctx, selector, options)
+	})
+}
+
+// StartDevTerminalInBackground runs StartTerminalWithOptions in a new goroutine and
+// returns a handle to close the terminal
+func StartDevTerminalInBackground(
+	ctx devspacecontext.Context,
+	devContainer *latest.DevContainer,
+	selector targetselector.TargetSelector,
+	parent *tomb.Tomb,
+	options *TerminalOptions,
+) *BackgroundTerminal {
+	return startInBackground(ctx, options, func(ctx devspacecontext.Context, options *TerminalOptions) (int, error) {
+		return 0, StartTerminalWithOptions(ctx, devContainer, selector, parent, options)
+	})
+}
+
+func startInBackground(ctx devspacecontext.Context, options *TerminalOptions, run func(ctx devspacecontext.Context, options *TerminalOptions) (int, error)) *BackgroundTerminal {
+	cancelCtx, cancel := context.WithCancel(ctx.Context())
+	terminal := &BackgroundTerminal{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	// stdin is passed through a pipe, so that Close can send EOF to the container
+	// even if the original reader never ends, e.g. os.Stdin
+	sessionOptions := *options
+	var stdinReader *io.PipeReader
+	if options.Stdin != nil {
+		stdinReader, terminal.stdin = io.Pipe()
+		sessionOptions.Stdin = stdinReader
+		go func() {
+			_, err := io.Copy(terminal.stdin, options.Stdin)
+			_ = terminal.stdin.CloseWithError(err)
+		}()
+	}
+
+	go func() {
+		defer close(terminal.done)
+		defer cancel()
+
+		terminal.exitCode, terminal.err = run(ctx.WithContext(cancelCtx), &sessionOptions)
+		// closing the terminal is no error, unless the context of the caller was cancelled
+		if errors.Is(terminal.err, ErrClosedByUser) && ctx.Context().Err() == nil {
+			terminal.err = nil
+		}
+		if stdinReader != nil {
+			_ = stdinReader.Close()
+		}
+	}()
+
+	return terminal
+}
+
+// Close cancels the terminal, sends EOF to its stdin and waits until the terminal
+// has stopped. Close is idempotent and safe to call from multiple goroutines.
+func (t *BackgroundTerminal) Close() {
+	t.closeOnce.Do(func() {
+		t.cancel()
+		if t.stdin != nil {
+			_ = t.stdin.Close()
+		}
+	})
+
+	<-t.done
+}
+
+// Done returns a channel that is closed when the terminal has stopped
+func (t *BackgroundTerminal) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait waits until the terminal has stopped and returns its exit code and error
+func (t *BackgroundTerminal) Wait() (int, error) {
+	<-t.done
+	return t.exitCode, t.err
+}
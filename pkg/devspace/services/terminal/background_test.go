@@ -0,0 +1,68 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// stdinExecClient reads stdin until EOF and records if EOF was received
+type stdinExecClient struct {
+	kubectltesting.Client
+
+	started chan struct{}
+	eof     bool
+}
+
+func (c *stdinExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	close(c.started)
+	_, err := io.Copy(io.Discard, options.Stdin)
+	c.eof = err == nil
+	return nil
+}
+
+func TestBackgroundTerminalClose(t *testing.T) {
+	client := &stdinExecClient{started: make(chan struct{})}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "container"},
+	}}
+
+	// the stdin of the caller never ends, like os.Stdin
+	stdin, stdinWriter := io.Pipe()
+	defer stdinWriter.Close()
+
+	terminal := StartTerminalInBackground(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithStreams(io.Discard, io.Discard, stdin),
+	))
+	<-client.started
+
+	waitGroup := sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			terminal.Close()
+		}()
+	}
+	waitGroup.Wait()
+	terminal.Close()
+
+	exitCode, err := terminal.Wait()
+	assert.NilError(t, err)
+	assert.Equal(t, exitCode, 0)
+	assert.Assert(t, client.eof, "Expected EOF on stdin")
+	assert.NilError(t, ctx.Context().Err(), "Expected the context of the caller not to be cancelled")
+}
@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"os"
+	"strconv"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+)
+
+// CheckpointFeatureFlag is the environment variable of the devspace_experimental_checkpoint feature flag,
+// which enables TerminalOptions.SnapshotOnExit
+const CheckpointFeatureFlag = "DEVSPACE_EXPERIMENTAL_CHECKPOINT"
+
+// checkpointEnabled returns true if the checkpoint feature flag is set to a true value
+func checkpointEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(CheckpointFeatureFlag))
+	return enabled
+}
+
+// snapshotContainer creates a checkpoint of the container after its session exited with a non-zero exit code
+// and prints the path of the checkpoint on the node. Errors are logged, as the session already ended.
+func snapshotContainer(ctx devspacecontext.Context, container *selector.SelectedPodContainer, exitCode int) {
+	if exitCode == 0 {
+		return
+	} else if !checkpointEnabled() {
+		ctx.Log().Debugf("Skip checkpoint of container %s, because %s is not set", container.Container.Name, CheckpointFeatureFlag)
+		return
+	}
+
+	path, err := ctx.KubeClient().CreateCheckpoint(ctx.Context(), container.Pod, container.Container.Name)
+	if err != nil {
+		if errors.Is(err, kubectl.ErrCheckpointNotSupported) {
+			ctx.Log().Infof("Skip checkpoint of container %s: %v", container.Container.Name, err)
+		} else {
+			ctx.Log().Warnf("Error creating checkpoint of container %s: %v", container.Container.Name, err)
+		}
+		return
+	}
+
+	ctx.Log().Infof("Created checkpoint of container %s on node %s: %s", container.Container.Name, container.Pod.Spec.NodeName, path)
+}
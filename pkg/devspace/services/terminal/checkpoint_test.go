@@ -0,0 +1,111 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// checkpointExecClient exits every exec stream with the given exit code and records checkpoints
+type checkpointExecClient struct {
+	exitCodeExecClient
+
+	checkpointErr error
+	checkpoints   []string
+}
+
+func (c *checkpointExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	if c.exitCode == 0 {
+		return nil
+	}
+
+	return kubectlExec.CodeExitError{Err: fmt.Errorf("command terminated with exit code %d", c.exitCode), Code: c.exitCode}
+}
+
+func (c *checkpointExecClient) CreateCheckpoint(ctx context.Context, pod *corev1.Pod, container string) (string, error) {
+	if c.checkpointErr != nil {
+		return "", c.checkpointErr
+	}
+
+	c.checkpoints = append(c.checkpoints, pod.Name+"/"+container)
+	return "/var/lib/kubelet/checkpoints/checkpoint-" + pod.Name + "_" + container + ".tar", nil
+}
+
+type snapshotOnExitTestCase struct {
+	name string
+
+	featureFlag   string
+	exitCode      int
+	checkpointErr error
+
+	expectedCheckpoints []string
+	expectedLog         string
+}
+
+func TestSnapshotOnExit(t *testing.T) {
+	testCases := []snapshotOnExitTestCase{
+		{
+			name:                "Non-zero exit code",
+			featureFlag:         "true",
+			exitCode:            1,
+			expectedCheckpoints: []string{"test-pod/test-container"},
+			expectedLog:         "Created checkpoint of container test-container on node node: /var/lib/kubelet/checkpoints/checkpoint-test-pod_test-container.tar",
+		},
+		{
+			name:        "Zero exit code",
+			featureFlag: "true",
+		},
+		{
+			name:     "Feature flag not set",
+			exitCode: 1,
+		},
+		{
+			name:          "Old kubelet",
+			featureFlag:   "true",
+			exitCode:      1,
+			checkpointErr: kubectl.ErrCheckpointNotSupported,
+			expectedLog:   "Skip checkpoint of container test-container",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Setenv(CheckpointFeatureFlag, testCase.featureFlag)
+		client := &checkpointExecClient{exitCodeExecClient: exitCodeExecClient{exitCode: testCase.exitCode}, checkpointErr: testCase.checkpointErr}
+		out := &strings.Builder{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"},
+				Spec:       corev1.PodSpec{NodeName: "node"},
+			},
+			Container: &corev1.Container{Name: "test-container"},
+		}}
+
+		exitCode, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithSnapshotOnExit(true),
+			WithDisableSessionFile(true),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err, "Unexpected error in test case %s", testCase.name)
+		assert.Equal(t, exitCode, testCase.exitCode, "Unexpected exit code in test case %s", testCase.name)
+		assert.DeepEqual(t, client.checkpoints, testCase.expectedCheckpoints)
+		if testCase.expectedLog != "" {
+			assert.Assert(t, strings.Contains(out.String(), testCase.expectedLog), "Unexpected log in test case %s: %s", testCase.name, out.String())
+		} else {
+			assert.Assert(t, !strings.Contains(out.String(), "checkpoint"), "Unexpected log in test case %s: %s", testCase.name, out.String())
+		}
+	}
+}
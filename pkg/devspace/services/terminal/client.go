@@ -0,0 +1,78 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Client opens and manages terminal sessions to containers. It is meant to be
+// used by third-party tooling that doesn't run within a DevSpace pipeline.
+type Client interface {
+	// OpenSession opens a terminal to the container selected by targetSelector and
+	// returns the exit code of the command once the session has ended
+	OpenSession(ctx context.Context, targetSelector targetselector.TargetSelector, options *TerminalOptions) (int, error)
+
+	// ListSessions returns the screen and tmux sessions of all containers in the namespace matched by podSelector
+	ListSessions(ctx context.Context, namespace string, podSelector selector.Selector) ([]SessionInfo, error)
+
+	// CloseSession terminates the given screen or tmux session in its container
+	CloseSession(ctx context.Context, session SessionInfo) error
+}
+
+// NewClient creates a new terminal client
+func NewClient(kubeClient kubectl.Client, log log.Logger) Client {
+	return &client{
+		kubeClient: kubeClient,
+		log:        log,
+	}
+}
+
+type client struct {
+	kubeClient kubectl.Client
+	log        log.Logger
+}
+
+func (c *client) context(ctx context.Context) devspacecontext.Context {
+	return devspacecontext.NewContext(ctx, nil, c.log).WithKubeClient(c.kubeClient)
+}
+
+func (c *client) OpenSession(ctx context.Context, targetSelector targetselector.TargetSelector, options *TerminalOptions) (int, error) {
+	return StartTerminalFromCMDWithOptions(c.context(ctx), targetSelector, options)
+}
+
+func (c *client) ListSessions(ctx context.Context, namespace string, podSelector selector.Selector) ([]SessionInfo, error) {
+	return ListTerminalSessions(c.context(ctx), namespace, podSelector)
+}
+
+func (c *client) CloseSession(ctx context.Context, session SessionInfo) error {
+	var command []string
+	switch session.Multiplexer {
+	case MultiplexerScreen:
+		command = []string{"screen", "-S", session.Name, "-X", "quit"}
+	case MultiplexerTmux:
+		command = []string{"tmux", "kill-session", "-t", session.Name}
+	default:
+		return fmt.Errorf("unsupported multiplexer %s of session %s", session.Multiplexer, session.Name)
+	}
+
+	namespace := session.Namespace
+	if namespace == "" {
+		namespace = c.kubeClient.Namespace()
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: session.Pod, Namespace: namespace}}
+	_, stderr, err := c.kubeClient.ExecBuffered(ctx, pod, session.Container, command, nil)
+	if err != nil {
+		return errors.Wrapf(err, "close %s session %s: %s", session.Multiplexer, session.Name, string(stderr))
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// sessionExecClient lists a single screen session and records all commands
+type sessionExecClient struct {
+	kubectltesting.Client
+
+	m        sync.Mutex
+	streams  [][]string
+	commands [][]string
+}
+
+func (c *sessionExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.streams = append(c.streams, options.Command)
+	return nil
+}
+
+func (c *sessionExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.commands = append(c.commands, command)
+	if len(command) == 3 && command[2] == listSessionsScript {
+		return []byte("# screen\nThere is a screen on:\n\t1234.dev\t(Detached)\n1 Socket in /run/screen/S-root.\n"), nil, nil
+	}
+
+	return nil, nil, nil
+}
+
+func TestClient(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "testNamespace", Labels: map[string]string{"app": "test"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  "app",
+					Ready: true,
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				},
+			},
+		},
+	})
+	fakeClient := &sessionExecClient{Client: kubectltesting.Client{Client: kubeClient}}
+	client := NewClient(fakeClient, log.Discard)
+
+	exitCode, err := client.OpenSession(context.Background(), targetselector.NewTargetSelector(targetselector.NewEmptyOptions().WithLabelSelector("app=test")), NewTerminalOptions(
+		WithCommand([]string{"echo", "test"}),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, exitCode, 0)
+	assert.DeepEqual(t, fakeClient.streams, [][]string{{"echo", "test"}})
+
+	sessions, err := client.ListSessions(context.Background(), "", selector.Selector{LabelSelector: "app=test"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sessions, []SessionInfo{{Namespace: "testNamespace", Pod: "pod", Container: "app", Multiplexer: "screen", Name: "dev"}})
+
+	err = client.CloseSession(context.Background(), sessions[0])
+	assert.NilError(t, err)
+	assert.DeepEqual(t, fakeClient.commands[len(fakeClient.commands)-1], []string{"screen", "-S", "dev", "-X", "quit"})
+}
@@ -0,0 +1,101 @@
+package terminal
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ContainerEnvVar is the environment variable that selects the container of StartTerminalFromCMD
+// without asking the target selector. The value has the format pod/container, e.g. my-pod/app, and
+// the pod is looked up in the namespace of the terminal. Values without a slash are ignored, because
+// DevSpace itself sets DEVSPACE_CONTAINER to the plain container name in terminals with injected metadata.
+// The variable is read once when the terminal is started and not used anymore after following a replaced pod.
+const ContainerEnvVar = "DEVSPACE_CONTAINER"
+
+// containerEnvPollInterval is the interval in which the container set by ContainerEnvVar is checked until it runs
+var containerEnvPollInterval = time.Second
+
+// containerFromEnv returns the container set by value, the value of ContainerEnvVar, or nil if it isn't set.
+// The container can also be an ephemeral container. If it isn't running yet, containerFromEnv waits until
+// it runs.
+func containerFromEnv(ctx devspacecontext.Context, namespace string, value string) (*selector.SelectedPodContainer, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	podName, containerName, ok := strings.Cut(value, "/")
+	if !ok {
+		ctx.Log().Debugf("Ignoring %s=%s, because it is not in the format pod/container", ContainerEnvVar, value)
+		return nil, nil
+	} else if podName == "" || containerName == "" {
+		return nil, errors.Errorf("%s=%s: expected the format pod/container", ContainerEnvVar, value)
+	}
+
+	var container *selector.SelectedPodContainer
+	waiting := false
+	err := wait.PollUntilContextTimeout(ctx.Context(), containerEnvPollInterval, waitForRunningTimeout, true, func(pollCtx context.Context) (bool, error) {
+		pod, err := ctx.KubeClient().KubeClient().CoreV1().Pods(namespace).Get(pollCtx, podName, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "get pod %s/%s set by %s", namespace, podName, ContainerEnvVar)
+		}
+
+		container = findContainer(pod, containerName)
+		if container == nil {
+			return false, errors.Errorf("container %s set by %s doesn't exist in pod %s/%s", containerName, ContainerEnvVar, namespace, podName)
+		} else if containerRunning(pod, containerName) {
+			return true, nil
+		} else if !waiting {
+			ctx.Log().Infof("Waiting for container %s:%s set by %s to run", podName, containerName, ContainerEnvVar)
+			waiting = true
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		if wait.Interrupted(err) && ctx.Context().Err() == nil {
+			return nil, errors.Errorf("container %s set by %s in pod %s/%s is not running", containerName, ContainerEnvVar, namespace, podName)
+		}
+
+		return nil, err
+	}
+
+	return container, nil
+}
+
+// findContainer returns the container or ephemeral container with the given name or nil if the pod has none
+func findContainer(pod *corev1.Pod, name string) *selector.SelectedPodContainer {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &selector.SelectedPodContainer{Pod: pod, Container: &pod.Spec.Containers[i]}
+		}
+	}
+	for _, ephemeralContainer := range pod.Spec.EphemeralContainers {
+		if ephemeralContainer.Name == name {
+			container := corev1.Container(ephemeralContainer.EphemeralContainerCommon)
+			return &selector.SelectedPodContainer{Pod: pod, Container: &container}
+		}
+	}
+
+	return nil
+}
+
+// containerRunning returns true if the container or ephemeral container with the given name is running
+func containerRunning(pod *corev1.Pod, name string) bool {
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+		for _, status := range statuses {
+			if status.Name == name {
+				return status.State.Running != nil
+			}
+		}
+	}
+
+	return false
+}
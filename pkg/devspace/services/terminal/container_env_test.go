@@ -0,0 +1,193 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type containerFromEnvTestCase struct {
+	name  string
+	value string
+
+	expectedPod       string
+	expectedContainer string
+	expectedErr       string
+}
+
+func newContainerEnvPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "testNamespace"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "sidecar"}, {Name: "app"}, {Name: "init"}},
+			EphemeralContainers: []corev1.EphemeralContainer{
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug", Image: "busybox"}},
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "sidecar", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				{Name: "app", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				{Name: "init", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}},
+			},
+			EphemeralContainerStatuses: []corev1.ContainerStatus{
+				{Name: "debug", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+}
+
+func TestContainerFromEnv(t *testing.T) {
+	testCases := []containerFromEnvTestCase{
+		{
+			name: "Not set",
+		},
+		{
+			name:  "Plain container name set by an injected terminal",
+			value: "app",
+		},
+		{
+			name:              "Pod and container",
+			value:             "pod/app",
+			expectedPod:       "pod",
+			expectedContainer: "app",
+		},
+		{
+			name:              "Ephemeral container",
+			value:             "pod/debug",
+			expectedPod:       "pod",
+			expectedContainer: "debug",
+		},
+		{
+			name:        "Container not running",
+			value:       "pod/init",
+			expectedErr: "container init set by DEVSPACE_CONTAINER in pod testNamespace/pod is not running",
+		},
+		{
+			name:        "Empty pod",
+			value:       "/app",
+			expectedErr: "expected the format pod/container",
+		},
+		{
+			name:        "Pod not found",
+			value:       "missing/app",
+			expectedErr: "get pod testNamespace/missing set by DEVSPACE_CONTAINER",
+		},
+		{
+			name:        "Container not found",
+			value:       "pod/missing",
+			expectedErr: "container missing set by DEVSPACE_CONTAINER doesn't exist in pod testNamespace/pod",
+		},
+	}
+
+	defer func(interval, timeout time.Duration) {
+		containerEnvPollInterval, waitForRunningTimeout = interval, timeout
+	}(containerEnvPollInterval, waitForRunningTimeout)
+	containerEnvPollInterval, waitForRunningTimeout = time.Millisecond, time.Millisecond*20
+
+	client := &kubectltesting.Client{Client: fake.NewSimpleClientset(newContainerEnvPod())}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	for _, testCase := range testCases {
+		container, err := containerFromEnv(ctx, "testNamespace", testCase.value)
+		if testCase.expectedErr != "" {
+			assert.ErrorContains(t, err, testCase.expectedErr, "Unexpected error in test case %s", testCase.name)
+			continue
+		}
+
+		assert.NilError(t, err, "Unexpected error in test case %s", testCase.name)
+		if testCase.expectedPod == "" {
+			assert.Assert(t, container == nil, "Expected no container in test case %s", testCase.name)
+			continue
+		}
+		assert.Equal(t, container.Pod.Name, testCase.expectedPod, "Unexpected pod in test case %s", testCase.name)
+		assert.Equal(t, container.Container.Name, testCase.expectedContainer, "Unexpected container in test case %s", testCase.name)
+	}
+}
+
+func TestStartTerminalFromCMDContainerEnv(t *testing.T) {
+	t.Setenv(ContainerEnvVar, "pod/app")
+
+	client := &recordingExecClient{Client: kubectltesting.Client{Client: fake.NewSimpleClientset(newContainerEnvPod())}}
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "testNamespace"}},
+		Container: &corev1.Container{Name: "other"},
+	}}
+
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"echo", "test"}),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, client.options.Pod.Name, "pod")
+	assert.Equal(t, client.options.Container, "app")
+	assert.Assert(t, strings.Contains(out.String(), "Using container pod:app set by DEVSPACE_CONTAINER instead of the configured selector"), "Expected a warning, got %s", out.String())
+}
+
+func TestContainerFromEnvWaitsUntilRunning(t *testing.T) {
+	defer func(interval time.Duration) { containerEnvPollInterval = interval }(containerEnvPollInterval)
+	containerEnvPollInterval = time.Millisecond * 10
+
+	kubeClient := fake.NewSimpleClientset(newContainerEnvPod())
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&kubectltesting.Client{Client: kubeClient})
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		pod := newContainerEnvPod()
+		pod.Status.ContainerStatuses[2].State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+		_, _ = kubeClient.CoreV1().Pods("testNamespace").UpdateStatus(context.Background(), pod, metav1.UpdateOptions{})
+	}()
+
+	container, err := containerFromEnv(ctx, "testNamespace", "pod/init")
+	assert.NilError(t, err)
+	assert.Equal(t, container.Container.Name, "init")
+}
+
+// envChangingExecClient changes ContainerEnvVar during the first session and loses its connection
+type envChangingExecClient struct {
+	recordingExecClient
+
+	containers []string
+}
+
+func (c *envChangingExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.containers = append(c.containers, options.Container)
+	if len(c.containers) == 1 {
+		_ = os.Setenv(ContainerEnvVar, "pod/sidecar")
+		return errors.New("connection reset by peer")
+	}
+
+	return nil
+}
+
+func TestStartTerminalFromCMDContainerEnvReadOnce(t *testing.T) {
+	t.Setenv(ContainerEnvVar, "pod/app")
+
+	client := &envChangingExecClient{recordingExecClient: recordingExecClient{Client: kubectltesting.Client{Client: fake.NewSimpleClientset(newContainerEnvPod())}}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	_, err := StartTerminalFromCMDWithOptions(ctx, &fixedSelector{}, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithRestart(1),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, client.containers, []string{"app", "app"})
+}
@@ -0,0 +1,254 @@
+package terminal
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// CopyToContainer copies the local file or directory to remotePath in the container like kubectl cp.
+// Directories are copied recursively, symlinks are copied as symlinks and file modes are preserved.
+// This requires tar to be present in the container.
+func CopyToContainer(ctx devspacecontext.Context, pod *corev1.Pod, container string, localPath string, remotePath string) error {
+	ctx = withExecLimit(ctx)
+	remotePath = path.Clean(remotePath)
+	reader, writer := io.Pipe()
+	go func() {
+		_ = writer.CloseWithError(writeCopyTar(writer, localPath, path.Base(remotePath)))
+	}()
+	defer reader.Close()
+
+	// the parent directory might not exist yet and is created like mkdir -p would
+	script := fmt.Sprintf("mkdir -p %s && tar xpf - -C %s", shellquote.Join(path.Dir(remotePath)), shellquote.Join(path.Dir(remotePath)))
+	_, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), pod, container, []string{"sh", "-c", script}, reader)
+	if err != nil {
+		return errors.Errorf("error copying %s to %s in container %s: %s %v", localPath, remotePath, container, strings.TrimSpace(string(stderr)), err)
+	}
+
+	return nil
+}
+
+// CopyFromContainer copies the file or directory at remotePath in the container to localPath like kubectl cp.
+// Directories are copied recursively and file modes are preserved. Symlinks are recreated locally unless they
+// point outside of the copied directory, as they would reference files on the local machine then.
+// This requires tar to be present in the container.
+func CopyFromContainer(ctx devspacecontext.Context, pod *corev1.Pod, container string, remotePath string, localPath string) error {
+	ctx = withExecLimit(ctx)
+	remotePath = path.Clean(remotePath)
+	reader, writer := io.Pipe()
+	stderr := &bytes.Buffer{}
+	errChan := make(chan error, 1)
+	go func() {
+		err := ctx.KubeClient().ExecStream(ctx.Context(), &kubectl.ExecStreamOptions{
+			Pod:       pod,
+			Container: container,
+			Command:   []string{"tar", "cf", "-", "-C", path.Dir(remotePath), path.Base(remotePath)},
+			Stdout:    writer,
+			Stderr:    stderr,
+		})
+		_ = writer.CloseWithError(err)
+		errChan <- err
+	}()
+
+	err := readCopyTar(ctx, reader, path.Base(remotePath), localPath)
+	_ = reader.CloseWithError(err)
+	if execErr := <-errChan; execErr != nil {
+		return errors.Errorf("error copying %s in container %s to %s: %s %v", remotePath, container, localPath, strings.TrimSpace(stderr.String()), execErr)
+	}
+
+	return err
+}
+
+// writeCopyTar writes localPath to the tar stream with its contents named after name
+func writeCopyTar(writer io.Writer, localPath string, name string) error {
+	tarWriter := tar.NewWriter(writer)
+	err := filepath.Walk(localPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(file)
+			if err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(name, filepath.ToSlash(relative))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return err
+		} else if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tarWriter, f)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "archive %s", localPath)
+	}
+
+	return tarWriter.Close()
+}
+
+// readCopyTar extracts the tar stream to localPath, the entries are expected to be named after name.
+// Symlinks that were already extracted are resolved on disk, so that no entry is written outside of
+// localPath through them.
+func readCopyTar(ctx devspacecontext.Context, reader io.Reader, name string, localPath string) error {
+	root, err := resolvePath(localPath)
+	if err != nil {
+		return errors.Wrapf(err, "resolve %s", localPath)
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "read tar")
+		}
+
+		// entries are only extracted within localPath
+		relative := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		if relative != name && !strings.HasPrefix(relative, name+"/") {
+			ctx.Log().Debugf("Skipping unexpected entry %s", header.Name)
+			continue
+		}
+		target := filepath.Join(localPath, filepath.FromSlash(strings.TrimPrefix(relative, name)))
+
+		// the parent directory of the target might be a symlink extracted before
+		resolvedTarget := root
+		if relative != name {
+			parent, err := resolvePath(filepath.Dir(target))
+			if err != nil {
+				return errors.Wrapf(err, "resolve %s", header.Name)
+			}
+			resolvedTarget = filepath.Join(parent, filepath.Base(target))
+			if !isWithin(root, resolvedTarget) {
+				return errors.Errorf("refusing to extract %s, because it would be written outside of %s to %s", header.Name, localPath, resolvedTarget)
+			}
+		}
+
+		// existing symlinks are replaced instead of followed
+		if stat, err := os.Lstat(target); err == nil && stat.Mode()&os.ModeSymlink != 0 && relative != name {
+			err = os.Remove(target)
+			if err != nil {
+				return errors.Wrapf(err, "extract %s", header.Name)
+			}
+		}
+
+		mode := os.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+			if err == nil {
+				err = os.Chmod(target, mode)
+			}
+		case tar.TypeReg:
+			err = extractFile(tarReader, target, mode)
+		case tar.TypeSymlink:
+			linkTarget := path.Join(path.Dir(relative), header.Linkname)
+			if path.IsAbs(header.Linkname) || (linkTarget != name && !strings.HasPrefix(linkTarget, name+"/")) ||
+				!isWithin(root, filepath.Join(filepath.Dir(resolvedTarget), filepath.FromSlash(header.Linkname))) {
+				ctx.Log().Warnf("Skipping symlink %s, because it points outside of the copied directory to %s", header.Name, header.Linkname)
+				continue
+			}
+
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err == nil {
+				err = os.Symlink(header.Linkname, target)
+			}
+		default:
+			ctx.Log().Debugf("Skipping %s, because it is no regular file, directory or symlink", header.Name)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "extract %s", header.Name)
+		}
+	}
+}
+
+// resolvePath resolves the symlinks of the existing part of the path, the missing part is appended as is
+func resolvePath(file string) (string, error) {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+
+	missing := ""
+	for {
+		resolved, err := filepath.EvalSymlinks(file)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		} else if _, err := os.Lstat(file); err == nil {
+			return "", errors.Errorf("%s is a symlink to a missing file", file)
+		}
+
+		parent := filepath.Dir(file)
+		if parent == file {
+			return filepath.Join(file, missing), nil
+		}
+		missing = filepath.Join(filepath.Base(file), missing)
+		file = parent
+	}
+}
+
+// isWithin returns true if file is root or within root, both have to be absolute and clean
+func isWithin(root string, file string) bool {
+	relative, err := filepath.Rel(root, file)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+func extractFile(reader io.Reader, target string, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, reader)
+	if err != nil {
+		return err
+	}
+
+	// the mode passed to open is subject to the umask
+	return f.Chmod(mode)
+}
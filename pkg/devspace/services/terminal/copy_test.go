@@ -0,0 +1,172 @@
+package terminal
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// tarExecClient serves archive as the output of tar in the container and stores the archive it receives
+type tarExecClient struct {
+	kubectltesting.Client
+
+	archive  []byte
+	commands [][]string
+}
+
+func (c *tarExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.commands = append(c.commands, options.Command)
+	_, err := options.Stdout.Write(c.archive)
+	return err
+}
+
+func (c *tarExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	c.commands = append(c.commands, command)
+	archive, err := io.ReadAll(input)
+	c.archive = archive
+	return nil, nil, err
+}
+
+func writeTestTar(t *testing.T, headers ...*tar.Header) []byte {
+	buffer := &bytes.Buffer{}
+	tarWriter := tar.NewWriter(buffer)
+	for _, header := range headers {
+		header.Size = int64(len(header.Name))
+		if header.Typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		assert.NilError(t, tarWriter.WriteHeader(header))
+		if header.Typeflag == tar.TypeReg {
+			_, err := tarWriter.Write([]byte(header.Name))
+			assert.NilError(t, err)
+		}
+	}
+	assert.NilError(t, tarWriter.Close())
+	return buffer.Bytes()
+}
+
+func TestCopyFromContainer(t *testing.T) {
+	client := &tarExecClient{archive: writeTestTar(t,
+		&tar.Header{Name: "app/", Typeflag: tar.TypeDir, Mode: 0750},
+		&tar.Header{Name: "app/run.sh", Typeflag: tar.TypeReg, Mode: 0755},
+		&tar.Header{Name: "app/config/settings.yaml", Typeflag: tar.TypeReg, Mode: 0600},
+		&tar.Header{Name: "app/settings.yaml", Typeflag: tar.TypeSymlink, Linkname: "config/settings.yaml"},
+		&tar.Header{Name: "app/passwd", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		&tar.Header{Name: "app/parent", Typeflag: tar.TypeSymlink, Linkname: "../.."},
+		&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0644},
+	)}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+	localPath := filepath.Join(t.TempDir(), "local")
+
+	err := CopyFromContainer(ctx, pod, "app", "/srv/app/", localPath)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, client.commands, [][]string{{"tar", "cf", "-", "-C", "/srv", "app"}})
+
+	stat, err := os.Stat(localPath)
+	assert.NilError(t, err)
+	assert.Equal(t, stat.Mode().Perm(), os.FileMode(0750))
+	stat, err = os.Stat(filepath.Join(localPath, "run.sh"))
+	assert.NilError(t, err)
+	assert.Equal(t, stat.Mode().Perm(), os.FileMode(0755))
+	content, err := os.ReadFile(filepath.Join(localPath, "settings.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "app/config/settings.yaml")
+
+	for _, skipped := range []string{"passwd", "parent", "../escape"} {
+		_, err = os.Lstat(filepath.Join(localPath, skipped))
+		assert.Assert(t, os.IsNotExist(err), "Expected %s to be skipped", skipped)
+	}
+}
+
+func TestCopyToContainer(t *testing.T) {
+	localPath := filepath.Join(t.TempDir(), "local")
+	assert.NilError(t, os.MkdirAll(filepath.Join(localPath, "bin"), 0755))
+	assert.NilError(t, os.WriteFile(filepath.Join(localPath, "bin", "run.sh"), []byte("echo hello"), 0755))
+	assert.NilError(t, os.Symlink("bin/run.sh", filepath.Join(localPath, "run.sh")))
+
+	client := &tarExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+
+	err := CopyToContainer(ctx, pod, "app", localPath, "/srv/my app")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, client.commands, [][]string{{"sh", "-c", `mkdir -p /srv && tar xpf - -C /srv`}})
+
+	entries := map[string]*tar.Header{}
+	tarReader := tar.NewReader(bytes.NewReader(client.archive))
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		entries[header.Name] = header
+	}
+	assert.Equal(t, len(entries), 4)
+	assert.Equal(t, entries["my app/bin/run.sh"].Mode&0777, int64(0755))
+	assert.Equal(t, entries["my app/run.sh"].Typeflag, byte(tar.TypeSymlink))
+	assert.Equal(t, entries["my app/run.sh"].Linkname, "bin/run.sh")
+
+	// the archive sent to the container can be copied back again
+	copied := filepath.Join(t.TempDir(), "copied")
+	err = CopyFromContainer(ctx, pod, "app", "/srv/my app", copied)
+	assert.NilError(t, err)
+	content, err := os.ReadFile(filepath.Join(copied, "run.sh"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "echo hello")
+}
+
+func TestCopyFromContainerSymlinkChain(t *testing.T) {
+	// each symlink points into the copied directory on its own, but together they point to its parent
+	client := &tarExecClient{archive: writeTestTar(t,
+		&tar.Header{Name: "app/", Typeflag: tar.TypeDir, Mode: 0755},
+		&tar.Header{Name: "app/a", Typeflag: tar.TypeSymlink, Linkname: "."},
+		&tar.Header{Name: "app/a/t", Typeflag: tar.TypeSymlink, Linkname: ".."},
+		&tar.Header{Name: "app/a/t/x", Typeflag: tar.TypeReg, Mode: 0644},
+	)}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+	parent := t.TempDir()
+	localPath := filepath.Join(parent, "local")
+
+	err := CopyFromContainer(ctx, pod, "app", "/srv/app", localPath)
+	assert.NilError(t, err)
+
+	_, err = os.Lstat(filepath.Join(parent, "x"))
+	assert.Assert(t, os.IsNotExist(err), "Expected no file outside of the copied directory")
+	stat, err := os.Lstat(filepath.Join(localPath, "t"))
+	assert.NilError(t, err)
+	assert.Assert(t, stat.IsDir(), "Expected the symlink to the parent to be skipped")
+	_, err = os.Stat(filepath.Join(localPath, "t", "x"))
+	assert.NilError(t, err)
+}
+
+func TestCopyFromContainerThroughExistingSymlink(t *testing.T) {
+	client := &tarExecClient{archive: writeTestTar(t,
+		&tar.Header{Name: "app/out/x", Typeflag: tar.TypeReg, Mode: 0644},
+	)}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+	parent := t.TempDir()
+	localPath := filepath.Join(parent, "local")
+	assert.NilError(t, os.MkdirAll(localPath, 0755))
+	assert.NilError(t, os.Symlink(parent, filepath.Join(localPath, "out")))
+
+	err := CopyFromContainer(ctx, pod, "app", "/srv/app", localPath)
+	assert.ErrorContains(t, err, "refusing to extract app/out/x, because it would be written outside of")
+	_, err = os.Lstat(filepath.Join(parent, "x"))
+	assert.Assert(t, os.IsNotExist(err), "Expected no file outside of the copied directory")
+}
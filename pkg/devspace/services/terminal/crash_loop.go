@@ -0,0 +1,109 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultWaitForRunningTimeout is the maximum time to wait for a crash-looping container to run
+const DefaultWaitForRunningTimeout = time.Minute * 5
+
+// crashLoopPollInterval is the interval the pod of a crash-looping container is checked in
+var crashLoopPollInterval = time.Second
+
+// waitForRunningTimeout is replaced in tests
+var waitForRunningTimeout = DefaultWaitForRunningTimeout
+
+// CrashLoopError is returned if the selected container is in CrashLoopBackOff
+type CrashLoopError struct {
+	Pod       string
+	Container string
+
+	// Reason and ExitCode are the reason and exit code of the last termination of the container
+	Reason   string
+	ExitCode int32
+}
+
+func (e *CrashLoopError) Error() string {
+	message := fmt.Sprintf("pod %s is crash-looping, cannot open terminal to container %s", e.Pod, e.Container)
+	if e.Reason != "" {
+		message += fmt.Sprintf(": last terminated with reason %s and exit code %d", e.Reason, e.ExitCode)
+	}
+
+	return message
+}
+
+// crashLoopError returns a *CrashLoopError if the container is waiting in CrashLoopBackOff
+func crashLoopError(container *selector.SelectedPodContainer) *CrashLoopError {
+	for _, status := range container.Pod.Status.ContainerStatuses {
+		if status.Name != container.Container.Name || status.State.Waiting == nil || status.State.Waiting.Reason != "CrashLoopBackOff" {
+			continue
+		}
+
+		err := &CrashLoopError{Pod: container.Pod.Name, Container: container.Container.Name}
+		if status.LastTerminationState.Terminated != nil {
+			err.Reason = status.LastTerminationState.Terminated.Reason
+			err.ExitCode = status.LastTerminationState.Terminated.ExitCode
+		}
+		return err
+	}
+
+	return nil
+}
+
+// checkCrashLoop returns a *CrashLoopError if the container is crash-looping. If waitForRunning is
+// true, it waits instead until the container runs in between its restarts and returns it with the
+// updated pod, or returns the error if it doesn't run within DefaultWaitForRunningTimeout.
+func checkCrashLoop(ctx devspacecontext.Context, container *selector.SelectedPodContainer, waitForRunning bool) (*selector.SelectedPodContainer, error) {
+	crashLoopErr := crashLoopError(container)
+	if crashLoopErr == nil {
+		return container, nil
+	} else if !waitForRunning {
+		return nil, crashLoopErr
+	}
+
+	ctx.Log().Infof("Pod %s is crash-looping, waiting for container %s to run", container.Pod.Name, container.Container.Name)
+	var running *selector.SelectedPodContainer
+	err := wait.PollUntilContextTimeout(ctx.Context(), crashLoopPollInterval, waitForRunningTimeout, false, func(pollCtx context.Context) (bool, error) {
+		pod, err := ctx.KubeClient().KubeClient().CoreV1().Pods(container.Pod.Namespace).Get(pollCtx, container.Pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.Wrapf(err, "get pod %s", container.Pod.Name)
+		}
+
+		next := &selector.SelectedPodContainer{Pod: pod, Container: container.Container}
+		if isContainerRunning(next) {
+			running = next
+			return true, nil
+		}
+		if nextErr := crashLoopError(next); nextErr != nil {
+			crashLoopErr = nextErr
+		}
+		return false, nil
+	})
+	if err != nil {
+		if wait.Interrupted(err) && ctx.Context().Err() == nil {
+			return nil, crashLoopErr
+		}
+
+		return nil, err
+	}
+
+	return running, nil
+}
+
+func isContainerRunning(container *selector.SelectedPodContainer) bool {
+	for _, status := range container.Pod.Status.ContainerStatuses {
+		if status.Name == container.Container.Name {
+			return status.State.Running != nil
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func crashLoopingPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "app",
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+		}}},
+	}
+}
+
+type crashLoopTestCase struct {
+	name           string
+	waitForRunning bool
+	runningAfter   int
+
+	expectedErr     string
+	expectedStreams int
+}
+
+func TestStartTerminalCrashLoop(t *testing.T) {
+	testCases := []crashLoopTestCase{
+		{
+			name:        "Crash-looping",
+			expectedErr: "pod api is crash-looping, cannot open terminal to container app: last terminated with reason Error and exit code 1",
+		},
+		{
+			name:            "Wait for running",
+			waitForRunning:  true,
+			runningAfter:    2,
+			expectedStreams: 1,
+		},
+		{
+			name:           "Not running in time",
+			waitForRunning: true,
+			runningAfter:   1000,
+			expectedErr:    "pod api is crash-looping, cannot open terminal to container app: last terminated with reason Error and exit code 1",
+		},
+	}
+
+	defer func(interval, timeout time.Duration) {
+		crashLoopPollInterval, waitForRunningTimeout = interval, timeout
+	}(crashLoopPollInterval, waitForRunningTimeout)
+	crashLoopPollInterval, waitForRunningTimeout = time.Millisecond, time.Millisecond*200
+
+	for _, testCase := range testCases {
+		gets := 0
+		kubeClient := fake.NewSimpleClientset()
+		kubeClient.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			gets++
+			pod := crashLoopingPod()
+			if gets >= testCase.runningAfter {
+				pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+			}
+			return true, pod, nil
+		})
+
+		client := &ephemeralExecClient{Client: kubectltesting.Client{Client: kubeClient}}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       crashLoopingPod(),
+			Container: &corev1.Container{Name: "app"},
+		}}
+
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithRestart(RestartUnlimited),
+			WithWaitForRunning(testCase.waitForRunning),
+			WithDisableSessionFile(true),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		if testCase.expectedErr == "" {
+			assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		} else {
+			assert.Error(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+		}
+		assert.Equal(t, len(client.streams), testCase.expectedStreams, "Unexpected exec streams in "+testCase.name)
+	}
+}
@@ -0,0 +1,285 @@
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultEphemeralContainerName is the name of the ephemeral container DevSpace launches
+const DefaultEphemeralContainerName = "devspace-debug"
+
+// DefaultEphemeralContainerPullPolicy is the image pull policy of the ephemeral container
+const DefaultEphemeralContainerPullPolicy = corev1.PullIfNotPresent
+
+// DefaultEphemeralImage is the image of the ephemeral container if none is configured. It provides the shell
+// and tools that images built for production, e.g. distroless ones, usually lack.
+const DefaultEphemeralImage = "busybox"
+
+// ephemeralContainerStopFile is the file that tells the ephemeral container to exit
+const ephemeralContainerStopFile = "/tmp/.devspace-ephemeral-stop"
+
+// ephemeralContainerTimeout is the maximum time to wait for the ephemeral container to start
+var ephemeralContainerTimeout = time.Minute * 2
+
+// ephemeralContainerInterval is the interval in which the status of the ephemeral container is checked
+var ephemeralContainerInterval = time.Second
+
+// ephemeralContainerCommand keeps the ephemeral container running until it is stopped, so
+// that the terminal command can be executed in it like in any other container
+var ephemeralContainerCommand = []string{"sh", "-c", "trap 'exit 0' TERM INT; while [ ! -f " + ephemeralContainerStopFile + " ]; do sleep 1; done"}
+
+// EphemeralContainerOptions configure the ephemeral debug container DevSpace launches
+// alongside the selected container if TerminalOptions.UseEphemeralContainer is enabled
+type EphemeralContainerOptions struct {
+	// Name is the name of the ephemeral container, defaults to DefaultEphemeralContainerName.
+	// A running ephemeral container with this name is reused. As terminated ephemeral containers
+	// cannot be restarted, the name gets a numeric suffix like -2 if the container has terminated.
+	Name string
+
+	// Image is the image of the ephemeral container, defaults to DefaultEphemeralImage
+	Image string
+
+	// ImagePullPolicy is the pull policy of the image, defaults to DefaultEphemeralContainerPullPolicy
+	ImagePullPolicy string
+
+	// MountSharedVolume mounts an emptyDir volume at SharedVolumePath in both the ephemeral and
+	// the selected container. If the selected container has no such volume yet, the workload of
+	// the pod is patched after the user confirmed it, which replaces the pod. The patch is reverted
+	// when the terminal ends.
+	MountSharedVolume bool
+
+	// SharedVolumePath is the path of the shared volume, defaults to DefaultSharedVolumePath
+	SharedVolumePath string
+
+	// ExtraContainerSpec is merged over the computed spec of the ephemeral container, e.g. to run it
+	// privileged. Objects are merged recursively, scalar fields of the extra spec win and arrays like
+	// the command or env are replaced. Empty fields of the extra spec cannot unset computed fields.
+	ExtraContainerSpec *corev1.EphemeralContainerCommon
+}
+
+// newEphemeralContainer creates the ephemeral container spec that targets the given container
+func newEphemeralContainer(target *selector.SelectedPodContainer, options EphemeralContainerOptions) corev1.EphemeralContainer {
+	name := options.Name
+	if name == "" {
+		name = DefaultEphemeralContainerName
+	}
+	image := options.Image
+	if image == "" {
+		image = DefaultEphemeralImage
+	}
+	pullPolicy := corev1.PullPolicy(options.ImagePullPolicy)
+	if pullPolicy == "" {
+		pullPolicy = DefaultEphemeralContainerPullPolicy
+	}
+
+	ephemeralContainer := corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:            name,
+			Image:           image,
+			ImagePullPolicy: pullPolicy,
+			Command:         ephemeralContainerCommand,
+		},
+		TargetContainerName: target.Container.Name,
+	}
+	if options.MountSharedVolume {
+		ephemeralContainer.VolumeMounts = []corev1.VolumeMount{
+			{
+				Name:      SharedVolumeName,
+				MountPath: options.sharedVolumePath(),
+			},
+		}
+	}
+
+	return ephemeralContainer
+}
+
+// mergeExtraContainerSpec merges the extra spec over the ephemeral container with json merge patch semantics
+func mergeExtraContainerSpec(ephemeralContainer corev1.EphemeralContainer, extra *corev1.EphemeralContainerCommon) (corev1.EphemeralContainer, error) {
+	if extra == nil {
+		return ephemeralContainer, nil
+	}
+
+	original, err := json.Marshal(ephemeralContainer.EphemeralContainerCommon)
+	if err != nil {
+		return ephemeralContainer, err
+	}
+
+	// the name is the only field that is serialized when empty
+	patchSpec := *extra
+	if patchSpec.Name == "" {
+		patchSpec.Name = ephemeralContainer.Name
+	}
+	patch, err := json.Marshal(patchSpec)
+	if err != nil {
+		return ephemeralContainer, err
+	}
+	merged, err := jsonpatch.MergePatch(original, patch)
+	if err != nil {
+		return ephemeralContainer, errors.Wrap(err, "merge extra container spec")
+	}
+
+	common := corev1.EphemeralContainerCommon{}
+	err = json.Unmarshal(merged, &common)
+	if err != nil {
+		return ephemeralContainer, errors.Wrap(err, "merge extra container spec")
+	}
+
+	ephemeralContainer.EphemeralContainerCommon = common
+	return ephemeralContainer, nil
+}
+
+func (o EphemeralContainerOptions) sharedVolumePath() string {
+	if o.SharedVolumePath == "" {
+		return DefaultSharedVolumePath
+	}
+
+	return o.SharedVolumePath
+}
+
+// startEphemeralContainer launches an ephemeral container in the pod of the target container and waits until it is
+// running. The returned function reverts the changes to the workload of the pod and has to be called once the terminal ended.
+func startEphemeralContainer(ctx devspacecontext.Context, target *selector.SelectedPodContainer, options EphemeralContainerOptions) (_ *selector.SelectedPodContainer, _ func(), err error) {
+	ephemeralContainer, err := mergeExtraContainerSpec(newEphemeralContainer(target, options), options.ExtraContainerSpec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pods := ctx.KubeClient().KubeClient().CoreV1().Pods(target.Pod.Namespace)
+	pod, err := pods.Get(ctx.Context(), target.Pod.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get pod")
+	}
+	revert := func() {}
+	if options.MountSharedVolume && !hasSharedVolume(pod, target.Container.Name) {
+		revert, err = mountSharedVolume(ctx, target, options.sharedVolumePath())
+		if err != nil {
+			return nil, nil, err
+		}
+		defer func() {
+			if err != nil {
+				revert()
+			}
+		}()
+
+		target, err = waitForSharedVolume(ctx, target)
+		if err != nil {
+			return nil, nil, err
+		}
+		pod = target.Pod
+	}
+
+	name, exists := availableEphemeralContainerName(pod, ephemeralContainer.Name)
+	if name != ephemeralContainer.Name {
+		ctx.Log().Debugf("Ephemeral container %s in pod %s has terminated, using %s instead", ephemeralContainer.Name, pod.Name, name)
+		ephemeralContainer.Name = name
+	}
+	if exists {
+		ctx.Log().Debugf("Reusing ephemeral container %s in pod %s", ephemeralContainer.Name, pod.Name)
+	} else {
+		ctx.Log().Infof("Starting ephemeral container %s with image %s in pod %s", ephemeralContainer.Name, ephemeralContainer.Image, pod.Name)
+		pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, ephemeralContainer)
+		pod, err = pods.UpdateEphemeralContainers(ctx.Context(), pod.Name, pod, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "create ephemeral container")
+		}
+	}
+
+	err = wait.PollUntilContextTimeout(ctx.Context(), ephemeralContainerInterval, ephemeralContainerTimeout, true, func(waitCtx context.Context) (bool, error) {
+		pod, err = pods.Get(waitCtx, target.Pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name != ephemeralContainer.Name {
+				continue
+			} else if status.State.Running != nil {
+				return true, nil
+			} else if status.State.Terminated != nil {
+				return false, fmt.Errorf("ephemeral container %s in pod %s has terminated", ephemeralContainer.Name, pod.Name)
+			}
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "wait for ephemeral container %s", ephemeralContainer.Name)
+	}
+
+	container := corev1.Container(ephemeralContainer.EphemeralContainerCommon)
+	return &selector.SelectedPodContainer{
+		Pod:       pod,
+		Container: &container,
+	}, revert, nil
+}
+
+// availableEphemeralContainerName returns the first of name, name-2, name-3 and so on that is not the name
+// of a terminated ephemeral container in the pod and whether an ephemeral container with that name exists
+func availableEphemeralContainerName(pod *corev1.Pod, name string) (string, bool) {
+	terminated := map[string]bool{}
+	for _, status := range pod.Status.EphemeralContainerStatuses {
+		terminated[status.Name] = status.State.Terminated != nil
+	}
+	exists := map[string]bool{}
+	for _, container := range pod.Spec.EphemeralContainers {
+		exists[container.Name] = true
+	}
+
+	candidate := name
+	for i := 2; terminated[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+
+	return candidate, exists[candidate]
+}
+
+// stopEphemeralContainer tells the ephemeral container to exit. Kubernetes does not allow to remove
+// ephemeral containers from a pod, so the container stays registered in the pod spec as terminated.
+func stopEphemeralContainer(ctx devspacecontext.Context, container *selector.SelectedPodContainer) {
+	_, stderr, err := ctx.KubeClient().ExecBuffered(context.Background(), container.Pod, container.Container.Name, []string{"touch", ephemeralContainerStopFile}, nil)
+	if err != nil {
+		ctx.Log().Debugf("Error stopping ephemeral container %s in pod %s: %s %v", container.Container.Name, container.Pod.Name, string(stderr), err)
+		return
+	}
+
+	ctx.Log().Debugf("Stopped ephemeral container %s in pod %s", container.Container.Name, container.Pod.Name)
+}
+
+// ephemeralContainerList are the ephemeral containers of a terminal, shared across restarts
+type ephemeralContainerList []*selector.SelectedPodContainer
+
+// trackEphemeralContainer remembers the ephemeral container a session runs in, so that it is stopped
+// once the terminal ended instead of after every session, which allows restarts to reuse it
+func (o *TerminalOptions) trackEphemeralContainer(container *selector.SelectedPodContainer) {
+	if o.ephemeralContainers == nil {
+		return
+	}
+	for _, tracked := range *o.ephemeralContainers {
+		if tracked.Pod.UID == container.Pod.UID && tracked.Pod.Name == container.Pod.Name && tracked.Container.Name == container.Container.Name {
+			return
+		}
+	}
+
+	*o.ephemeralContainers = append(*o.ephemeralContainers, container)
+}
+
+// stopEphemeralContainers stops the ephemeral containers the sessions of the terminal ran in
+func (o *TerminalOptions) stopEphemeralContainers(ctx devspacecontext.Context) {
+	if o.ephemeralContainers == nil {
+		return
+	}
+
+	for _, container := range *o.ephemeralContainers {
+		stopEphemeralContainer(ctx, container)
+	}
+}
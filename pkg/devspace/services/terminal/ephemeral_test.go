@@ -0,0 +1,361 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/ptr"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type newEphemeralContainerTestCase struct {
+	name    string
+	options EphemeralContainerOptions
+
+	expectedName       string
+	expectedImage      string
+	expectedPullPolicy corev1.PullPolicy
+}
+
+func TestNewEphemeralContainer(t *testing.T) {
+	testCases := []newEphemeralContainerTestCase{
+		{
+			name:               "Defaults",
+			expectedName:       DefaultEphemeralContainerName,
+			expectedImage:      DefaultEphemeralImage,
+			expectedPullPolicy: corev1.PullIfNotPresent,
+		},
+		{
+			name: "Overrides",
+			options: EphemeralContainerOptions{
+				Name:            "debug",
+				Image:           "alpine",
+				ImagePullPolicy: "Always",
+			},
+			expectedName:       "debug",
+			expectedImage:      "alpine",
+			expectedPullPolicy: corev1.PullAlways,
+		},
+	}
+
+	target := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{},
+		Container: &corev1.Container{Name: "app", Image: "app:latest"},
+	}
+	for _, testCase := range testCases {
+		container := newEphemeralContainer(target, testCase.options)
+		assert.Equal(t, container.Name, testCase.expectedName, "Unexpected name in "+testCase.name)
+		assert.Equal(t, container.Image, testCase.expectedImage, "Unexpected image in "+testCase.name)
+		assert.Equal(t, container.ImagePullPolicy, testCase.expectedPullPolicy, "Unexpected pull policy in "+testCase.name)
+		assert.Equal(t, container.TargetContainerName, "app", "Unexpected target container in "+testCase.name)
+	}
+}
+
+type mergeExtraContainerSpecTestCase struct {
+	name  string
+	extra *corev1.EphemeralContainerCommon
+
+	expectedCommon corev1.EphemeralContainerCommon
+}
+
+func TestMergeExtraContainerSpec(t *testing.T) {
+	computed := corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:            "devspace-debug",
+			Image:           "busybox",
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{"sh", "-c", "sleep"},
+			SecurityContext: &corev1.SecurityContext{RunAsUser: ptr.Int64(1000)},
+		},
+		TargetContainerName: "app",
+	}
+	testCases := []mergeExtraContainerSpecTestCase{
+		{
+			name:           "No extra spec",
+			expectedCommon: computed.EphemeralContainerCommon,
+		},
+		{
+			name: "Privileged",
+			extra: &corev1.EphemeralContainerCommon{
+				SecurityContext: &corev1.SecurityContext{Privileged: ptr.Bool(true)},
+			},
+			expectedCommon: corev1.EphemeralContainerCommon{
+				Name:            "devspace-debug",
+				Image:           "busybox",
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"sh", "-c", "sleep"},
+				SecurityContext: &corev1.SecurityContext{RunAsUser: ptr.Int64(1000), Privileged: ptr.Bool(true)},
+			},
+		},
+		{
+			name: "Scalars win and arrays are replaced",
+			extra: &corev1.EphemeralContainerCommon{
+				Image:   "alpine",
+				Command: []string{"sleep", "infinity"},
+				SecurityContext: &corev1.SecurityContext{
+					RunAsUser:    ptr.Int64(0),
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_PTRACE"}},
+				},
+			},
+			expectedCommon: corev1.EphemeralContainerCommon{
+				Name:            "devspace-debug",
+				Image:           "alpine",
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"sleep", "infinity"},
+				SecurityContext: &corev1.SecurityContext{
+					RunAsUser:    ptr.Int64(0),
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_PTRACE"}},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		merged, err := mergeExtraContainerSpec(computed, testCase.extra)
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.DeepEqual(t, merged.EphemeralContainerCommon, testCase.expectedCommon)
+		assert.Equal(t, merged.TargetContainerName, "app", "Unexpected target in "+testCase.name)
+	}
+}
+
+func TestStartEphemeralContainerReuse(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "testNamespace"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app:latest"}},
+			EphemeralContainers: []corev1.EphemeralContainer{
+				newEphemeralContainer(&selector.SelectedPodContainer{Container: &corev1.Container{Name: "app", Image: "app:latest"}}, EphemeralContainerOptions{}),
+			},
+		},
+		Status: corev1.PodStatus{
+			EphemeralContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  DefaultEphemeralContainerName,
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				},
+			},
+		},
+	}
+	kubeClient := fake.NewSimpleClientset(pod)
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&kubectltesting.Client{Client: kubeClient})
+
+	container, _, err := startEphemeralContainer(ctx, &selector.SelectedPodContainer{Pod: pod, Container: &pod.Spec.Containers[0]}, EphemeralContainerOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, container.Container.Name, DefaultEphemeralContainerName)
+	assert.Equal(t, container.Pod.Name, "pod")
+	for _, action := range kubeClient.Actions() {
+		assert.Assert(t, action.GetVerb() != "update", "Unexpected update of the pod")
+	}
+}
+
+// ephemeralExecClient records the containers exec requests are sent to
+type ephemeralExecClient struct {
+	kubectltesting.Client
+
+	m         sync.Mutex
+	streams   []string
+	commands  [][]string
+	streamErr error
+}
+
+func (c *ephemeralExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.streams = append(c.streams, options.Container)
+	err := c.streamErr
+	c.streamErr = nil
+	return err
+}
+
+func (c *ephemeralExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.commands = append(c.commands, command)
+	return nil, nil, nil
+}
+
+type availableEphemeralContainerNameTestCase struct {
+	name string
+
+	containers []corev1.ContainerStatus
+
+	expectedName   string
+	expectedExists bool
+}
+
+func TestAvailableEphemeralContainerName(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	terminated := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}
+	testCases := []availableEphemeralContainerNameTestCase{
+		{
+			name:         "No ephemeral containers",
+			expectedName: "debug",
+		},
+		{
+			name:           "Running container is reused",
+			containers:     []corev1.ContainerStatus{{Name: "debug", State: running}},
+			expectedName:   "debug",
+			expectedExists: true,
+		},
+		{
+			name:         "Terminated container",
+			containers:   []corev1.ContainerStatus{{Name: "debug", State: terminated}},
+			expectedName: "debug-2",
+		},
+		{
+			name:           "Running replacement of a terminated container is reused",
+			containers:     []corev1.ContainerStatus{{Name: "debug", State: terminated}, {Name: "debug-2", State: running}},
+			expectedName:   "debug-2",
+			expectedExists: true,
+		},
+		{
+			name:         "Terminated replacement",
+			containers:   []corev1.ContainerStatus{{Name: "debug", State: terminated}, {Name: "debug-2", State: terminated}},
+			expectedName: "debug-3",
+		},
+	}
+
+	for _, testCase := range testCases {
+		pod := &corev1.Pod{Status: corev1.PodStatus{EphemeralContainerStatuses: testCase.containers}}
+		for _, status := range testCase.containers {
+			pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: status.Name}})
+		}
+
+		name, exists := availableEphemeralContainerName(pod, "debug")
+		assert.Equal(t, name, testCase.expectedName, "Unexpected name in test case %s", testCase.name)
+		assert.Equal(t, exists, testCase.expectedExists, "Unexpected exists in test case %s", testCase.name)
+	}
+}
+
+// startEphemeralContainers acts like the kubelet and starts every ephemeral container that is added to the pod
+func startEphemeralContainers(t *testing.T, kubeClient *fake.Clientset) func() {
+	watcher, err := kubeClient.CoreV1().Pods("testNamespace").Watch(context.Background(), metav1.ListOptions{})
+	assert.NilError(t, err)
+
+	go func() {
+		for event := range watcher.ResultChan() {
+			updated, ok := event.Object.(*corev1.Pod)
+			if !ok || event.Type != watch.Modified || len(updated.Spec.EphemeralContainers) == len(updated.Status.EphemeralContainerStatuses) {
+				continue
+			}
+
+			updated = updated.DeepCopy()
+			for _, container := range updated.Spec.EphemeralContainers[len(updated.Status.EphemeralContainerStatuses):] {
+				updated.Status.EphemeralContainerStatuses = append(updated.Status.EphemeralContainerStatuses, corev1.ContainerStatus{
+					Name:  container.Name,
+					Image: container.Image,
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				})
+			}
+			_, _ = kubeClient.CoreV1().Pods("testNamespace").UpdateStatus(context.Background(), updated, metav1.UpdateOptions{})
+		}
+	}()
+
+	return watcher.Stop
+}
+
+func TestStartTerminalEphemeralContainer(t *testing.T) {
+	defer func(original time.Duration) { ephemeralContainerInterval = original }(ephemeralContainerInterval)
+	ephemeralContainerInterval = time.Millisecond * 10
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "testNamespace"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "distroless"}},
+		},
+	}
+	kubeClient := fake.NewSimpleClientset(pod)
+	defer startEphemeralContainers(t, kubeClient)()
+
+	client := &ephemeralExecClient{Client: kubectltesting.Client{Client: kubeClient}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{Pod: pod, Container: &pod.Spec.Containers[0]}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{DisableScreen: true, UseEphemeralContainer: true}}
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+
+	updated, err := kubeClient.CoreV1().Pods("testNamespace").Get(context.Background(), "pod", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(updated.Spec.EphemeralContainers), 1)
+	ephemeralContainer := updated.Spec.EphemeralContainers[0]
+	assert.Equal(t, ephemeralContainer.Image, DefaultEphemeralImage)
+	assert.Equal(t, ephemeralContainer.TargetContainerName, "app")
+	assert.Assert(t, strings.HasPrefix(ephemeralContainer.Name, DefaultEphemeralContainerName+"-"))
+
+	client.m.Lock()
+	defer client.m.Unlock()
+	assert.DeepEqual(t, client.streams, []string{ephemeralContainer.Name})
+	assert.DeepEqual(t, client.commands[len(client.commands)-1], []string{"touch", ephemeralContainerStopFile})
+}
+
+func TestStartTerminalEphemeralContainerRestart(t *testing.T) {
+	defer func(original time.Duration) { ephemeralContainerInterval = original }(ephemeralContainerInterval)
+	ephemeralContainerInterval = time.Millisecond * 10
+	defer func(original time.Duration) { restartDelay = original }(restartDelay)
+	restartDelay = time.Millisecond
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "testNamespace"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "distroless"}},
+		},
+	}
+	kubeClient := fake.NewSimpleClientset(pod)
+	defer startEphemeralContainers(t, kubeClient)()
+
+	client := &ephemeralExecClient{Client: kubectltesting.Client{Client: kubeClient}, streamErr: errors.New("connection lost")}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{Pod: pod, Container: &pod.Spec.Containers[0]}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{DisableScreen: true, UseEphemeralContainer: true}}
+
+	// keep the parent alive across the restart like the dev pipeline does
+	parent := &tomb.Tomb{}
+	parent.Go(func() error {
+		<-parent.Dying()
+		return nil
+	})
+	defer parent.Kill(nil)
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, parent, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		WithShouldRestart(func(err error, restarts int) bool { return restarts == 0 }),
+	))
+	assert.NilError(t, err)
+
+	updated, err := kubeClient.CoreV1().Pods("testNamespace").Get(context.Background(), "pod", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(updated.Spec.EphemeralContainers), 1, "Expected the restart to reuse the ephemeral container")
+	name := updated.Spec.EphemeralContainers[0].Name
+
+	client.m.Lock()
+	defer client.m.Unlock()
+	assert.DeepEqual(t, client.streams, []string{name, name})
+	stops := 0
+	for _, command := range client.commands {
+		if len(command) == 2 && command[0] == "touch" && command[1] == ephemeralContainerStopFile {
+			stops++
+		}
+	}
+	assert.Equal(t, stops, 1, "Expected the ephemeral container to be stopped once the terminal ended")
+}
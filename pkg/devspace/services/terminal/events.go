@@ -0,0 +1,143 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// EventFormatJSON writes the terminal lifecycle events as one-line JSON objects
+const EventFormatJSON = "json"
+
+// EventType is the type of a terminal lifecycle event
+type EventType string
+
+const (
+	// EventSessionStarted is emitted when a terminal to a container is opened
+	EventSessionStarted EventType = "session_started"
+
+	// EventSessionConnected is emitted when the exec stream to the container is started
+	EventSessionConnected EventType = "session_connected"
+
+	// EventSessionRestarted is emitted when a terminal is restarted after it was interrupted
+	EventSessionRestarted EventType = "session_restarted"
+
+	// EventSessionEnded is emitted when the exec stream to the container has ended
+	EventSessionEnded EventType = "session_ended"
+)
+
+// Event describes a change in the lifecycle of a terminal session
+type Event struct {
+	Type      EventType `json:"type"`
+	Pod       string    `json:"pod,omitempty"`
+	Container string    `json:"container,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Reason    string    `json:"reason,omitempty"`
+
+	// ExitCode is the exit code of the command, only set for session_ended
+	// events if the command exited instead of losing the connection
+	ExitCode *int `json:"exitCode,omitempty"`
+
+	// ContainerSelectMs is the time in milliseconds it took to select the container,
+	// including waiting for it, only set for session_started events
+	ContainerSelectMs *int64 `json:"containerSelectMs,omitempty"`
+}
+
+// TerminalEvent is published to the event bus of the context, see devspacecontext.Context.EventBus,
+// when the lifecycle of a terminal session changes
+type TerminalEvent struct {
+	Type          EventType
+	PodName       string
+	ContainerName string
+
+	// SessionID identifies the terminal, it stays the same across restarts
+	SessionID string
+
+	// ExitCode is the exit code of the command for EventSessionEnded, or -1 if
+	// the session ended without one, e.g. because the connection was lost
+	ExitCode int
+
+	Timestamp time.Time
+}
+
+func newEvent(eventType EventType, pod string, container string, reason error) Event {
+	event := Event{
+		Type:      eventType,
+		Pod:       pod,
+		Container: container,
+		Timestamp: time.Now(),
+	}
+	if reason != nil {
+		event.Reason = reason.Error()
+	}
+
+	return event
+}
+
+func newSessionStartedEvent(pod string, container string, selectDuration time.Duration) Event {
+	event := newEvent(EventSessionStarted, pod, container, nil)
+	selectMs := selectDuration.Milliseconds()
+	event.ContainerSelectMs = &selectMs
+	return event
+}
+
+func newSessionEndedEvent(pod string, container string, err error) Event {
+	event := newEvent(EventSessionEnded, pod, container, err)
+	if err == nil {
+		exitCode := 0
+		event.ExitCode = &exitCode
+	} else if exitError, ok := err.(kubectlExec.CodeExitError); ok {
+		exitCode := exitError.Code
+		event.ExitCode = &exitCode
+	}
+
+	return event
+}
+
+func validateEventFormat(eventFormat string) error {
+	if eventFormat != "" && eventFormat != EventFormatJSON {
+		return fmt.Errorf("unsupported event format %s, only %s is supported", eventFormat, EventFormatJSON)
+	}
+
+	return nil
+}
+
+// emitEvent writes the event to the event writer if events are enabled, publishes it to the
+// event bus and sends the state the terminal is in after the event to the state channel
+func (o *TerminalOptions) emitEvent(ctx devspacecontext.Context, event Event) {
+	o.sendState(eventStates[event.Type])
+	o.publishEvent(ctx, event)
+	if o.EventFormat != EventFormatJSON || o.EventWriter == nil {
+		return
+	}
+
+	out, err := json.Marshal(event)
+	if err == nil {
+		_, err = o.EventWriter.Write(append(out, '\n'))
+	}
+	if err != nil {
+		ctx.Log().Debugf("Error writing terminal event %s: %v", event.Type, err)
+	}
+}
+
+// publishEvent publishes the event as TerminalEvent to the event bus of the context
+func (o *TerminalOptions) publishEvent(ctx devspacecontext.Context, event Event) {
+	exitCode := 0
+	if event.ExitCode != nil {
+		exitCode = *event.ExitCode
+	} else if event.Type == EventSessionEnded {
+		exitCode = -1
+	}
+
+	ctx.EventBus().Publish(TerminalEvent{
+		Type:          event.Type,
+		PodName:       event.Pod,
+		ContainerName: event.Container,
+		SessionID:     o.sessionID,
+		ExitCode:      exitCode,
+		Timestamp:     event.Timestamp,
+	})
+}
@@ -0,0 +1,111 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEmitEvents(t *testing.T) {
+	client := &eofExecClient{remaining: 1}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test-container"},
+	}}
+
+	events := &bytes.Buffer{}
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithTTY(false),
+		WithRestart(1),
+		WithEvents(EventFormatJSON, events),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+
+	types := []EventType{}
+	for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+		event := Event{}
+		assert.NilError(t, json.Unmarshal([]byte(line), &event))
+		assert.Equal(t, event.Pod, "test-pod")
+		assert.Equal(t, event.Container, "test-container")
+		assert.Assert(t, !event.Timestamp.IsZero(), "Expected timestamp in %s", line)
+		if event.Type == EventSessionRestarted {
+			assert.Equal(t, event.Reason, io.EOF.Error())
+		}
+		if event.Type == EventSessionStarted {
+			assert.Assert(t, event.ContainerSelectMs != nil, "Expected container select time in %s", line)
+		} else {
+			assert.Assert(t, event.ContainerSelectMs == nil, "Unexpected container select time in %s", line)
+		}
+		types = append(types, event.Type)
+	}
+	assert.DeepEqual(t, types, []EventType{
+		EventSessionStarted,
+		EventSessionConnected,
+		EventSessionEnded,
+		EventSessionRestarted,
+		EventSessionStarted,
+		EventSessionConnected,
+		EventSessionEnded,
+	})
+	assert.Assert(t, strings.HasSuffix(strings.TrimSpace(events.String()), `"exitCode":0}`), "Expected exit code in last event")
+
+	_, err = StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(WithEvents("yaml", events)))
+	assert.Error(t, err, "unsupported event format yaml, only json is supported")
+}
+
+func TestPublishTerminalEvents(t *testing.T) {
+	client := &eofExecClient{remaining: 1}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test-container"},
+	}}
+
+	events, unsubscribe := ctx.EventBus().Subscribe(16)
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithTTY(false),
+		WithRestart(1),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	unsubscribe()
+
+	received := []string{}
+	sessionID := ""
+	for event := range events {
+		terminalEvent, ok := event.(TerminalEvent)
+		assert.Assert(t, ok, "Unexpected event %#v", event)
+		assert.Equal(t, terminalEvent.PodName, "test-pod")
+		assert.Equal(t, terminalEvent.ContainerName, "test-container")
+		assert.Assert(t, terminalEvent.SessionID != "", "Expected session id")
+		if sessionID == "" {
+			sessionID = terminalEvent.SessionID
+		}
+		assert.Equal(t, terminalEvent.SessionID, sessionID, "Expected the session id to stay the same across restarts")
+		received = append(received, fmt.Sprintf("%s %d", terminalEvent.Type, terminalEvent.ExitCode))
+	}
+	assert.DeepEqual(t, received, []string{
+		"session_started 0",
+		"session_connected 0",
+		"session_ended -1",
+		"session_restarted 0",
+		"session_started 0",
+		"session_connected 0",
+		"session_ended 0",
+	})
+}
@@ -0,0 +1,175 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// execPriority is the priority of an exec waiting for the exec limit, higher priorities are served first
+type execPriority int
+
+const (
+	// execPriorityProbe is used by ExecBuffered, e.g. for the screen install and other probes
+	execPriorityProbe execPriority = iota
+
+	// execPrioritySession is used by ExecStream, e.g. for the interactive sessions
+	execPrioritySession
+)
+
+// execLimit limits the concurrent execs of the terminal package, see SetExecConcurrency
+var execLimit = &execLimiter{}
+
+// SetExecConcurrency limits the number of concurrent ExecStream and ExecBuffered calls of the terminal
+// package, e.g. to stay below the exec limits of the api server if a process opens many terminals.
+// Calls above the limit wait for a running one to finish instead of failing, where sessions are served
+// before probes. Note that a session occupies its slot until it ends. Zero or less disables the limit,
+// which is the default. Sessions executed with the native kubectl and the helper execs that belong to a
+// running session, e.g. forwarded sockets, are not limited.
+func SetExecConcurrency(limit int) {
+	execLimit.setLimit(limit)
+}
+
+// execLimiter is a semaphore whose waiters are served by priority and then in order
+type execLimiter struct {
+	m sync.Mutex
+
+	limit   int
+	active  int
+	waiting [execPrioritySession + 1][]chan struct{}
+}
+
+func (l *execLimiter) setLimit(limit int) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.limit = limit
+	l.grant()
+}
+
+// acquire waits for a free slot until ctx is done and returns the function that frees the slot again
+func (l *execLimiter) acquire(ctx context.Context, priority execPriority, log log.Logger) (func(), error) {
+	l.m.Lock()
+	if l.limit <= 0 {
+		l.m.Unlock()
+		return func() {}, nil
+	}
+
+	// waiters with the same or a higher priority are served first
+	queued := false
+	for p := priority; p <= execPrioritySession; p++ {
+		queued = queued || len(l.waiting[p]) > 0
+	}
+	if l.active < l.limit && !queued {
+		l.active++
+		l.m.Unlock()
+		return l.release, nil
+	}
+
+	granted := make(chan struct{})
+	l.waiting[priority] = append(l.waiting[priority], granted)
+	log.Debugf("Waiting for a free exec slot, %d of %d execs are running", l.active, l.limit)
+	l.m.Unlock()
+
+	select {
+	case <-granted:
+		return l.release, nil
+	case <-ctx.Done():
+		l.m.Lock()
+		defer l.m.Unlock()
+
+		select {
+		case <-granted:
+			// the slot was granted meanwhile and is passed on
+			l.active--
+			l.grant()
+		default:
+			l.remove(priority, granted)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (l *execLimiter) release() {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.active > 0 {
+		l.active--
+	}
+	l.grant()
+}
+
+// grant passes free slots on to the waiters, the limiter has to be locked
+func (l *execLimiter) grant() {
+	for p := execPrioritySession; p >= execPriorityProbe; p-- {
+		for len(l.waiting[p]) > 0 && (l.limit <= 0 || l.active < l.limit) {
+			close(l.waiting[p][0])
+			l.waiting[p] = l.waiting[p][1:]
+			l.active++
+		}
+	}
+}
+
+// remove removes a waiter that gave up, the limiter has to be locked
+func (l *execLimiter) remove(priority execPriority, granted chan struct{}) {
+	for i, waiting := range l.waiting[priority] {
+		if waiting == granted {
+			l.waiting[priority] = append(l.waiting[priority][:i], l.waiting[priority][i+1:]...)
+			return
+		}
+	}
+}
+
+// limitedExecClient applies the exec limit to the execs of the client
+type limitedExecClient struct {
+	kubectl.Client
+
+	log log.Logger
+}
+
+func (c *limitedExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	release, err := execLimit.acquire(ctx, execPrioritySession, c.log)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return c.Client.ExecStream(ctx, options)
+}
+
+func (c *limitedExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	release, err := execLimit.acquire(ctx, execPriorityProbe, c.log)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	return c.Client.ExecBuffered(ctx, pod, container, command, input)
+}
+
+// withExecLimit returns the context with a kube client whose execs are limited by SetExecConcurrency
+func withExecLimit(ctx devspacecontext.Context) devspacecontext.Context {
+	if ctx.KubeClient() == nil {
+		return ctx
+	} else if _, ok := ctx.KubeClient().(*limitedExecClient); ok {
+		return ctx
+	}
+
+	return ctx.WithKubeClient(&limitedExecClient{Client: ctx.KubeClient(), log: ctx.Log()})
+}
+
+// unlimitedClient returns the client without the exec limit. This is used for the helper execs of a
+// session, which would otherwise wait for the slot that is held by the session itself.
+func unlimitedClient(client kubectl.Client) kubectl.Client {
+	if limited, ok := client.(*limitedExecClient); ok {
+		return limited.Client
+	}
+
+	return client
+}
@@ -0,0 +1,184 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// concurrencyExecClient records the maximum number of concurrent execs
+type concurrencyExecClient struct {
+	kubectltesting.Client
+
+	m      sync.Mutex
+	active int
+	max    int
+}
+
+func (c *concurrencyExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.m.Lock()
+	c.active++
+	if c.active > c.max {
+		c.max = c.active
+	}
+	c.m.Unlock()
+
+	time.Sleep(time.Millisecond * 20)
+
+	c.m.Lock()
+	c.active--
+	c.m.Unlock()
+	return nil
+}
+
+func TestSetExecConcurrency(t *testing.T) {
+	const terminals = 8
+	const limit = 3
+	SetExecConcurrency(limit)
+	defer SetExecConcurrency(0)
+
+	client := &concurrencyExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, terminals)
+	for i := 0; i < terminals; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+				WithCommand([]string{"sh"}),
+				WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+			))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NilError(t, err)
+	}
+	assert.Assert(t, client.max <= limit, "Expected at most %d concurrent execs, got %d", limit, client.max)
+	assert.Assert(t, client.max > 1, "Expected concurrent execs")
+}
+
+// forwardExecClient blocks the socket forward execs until they are canceled and
+// ends a session only after its socket forward is running
+type forwardExecClient struct {
+	kubectltesting.Client
+
+	forwards chan struct{}
+}
+
+func (c *forwardExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	if options.Command[0] != "socat" {
+		<-c.forwards
+		return nil
+	}
+
+	c.forwards <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestExecLimitSocketForwards(t *testing.T) {
+	SetExecConcurrency(1)
+	defer SetExecConcurrency(0)
+
+	localPath := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", localPath)
+	assert.NilError(t, err)
+	defer listener.Close()
+
+	client := &forwardExecClient{forwards: make(chan struct{}, 10)}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+
+	// the forwarded socket doesn't occupy the only slot the session needs
+	done := make(chan error)
+	go func() {
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithDisableSessionFile(true),
+			WithForwardSockets(SocketForward{LocalPath: localPath, RemotePath: "/var/run/agent.sock"}),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NilError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("Expected the session not to wait for its own socket forward")
+	}
+}
+
+func TestExecLimiterPriority(t *testing.T) {
+	limiter := &execLimiter{limit: 1}
+	release, err := limiter.acquire(context.Background(), execPriorityProbe, log.Discard)
+	assert.NilError(t, err)
+
+	// a waiting session is served before a probe that waited longer
+	order := make(chan execPriority, 2)
+	wg := sync.WaitGroup{}
+	for _, priority := range []execPriority{execPriorityProbe, execPrioritySession} {
+		wg.Add(1)
+		go func(priority execPriority) {
+			defer wg.Done()
+			release, err := limiter.acquire(context.Background(), priority, log.Discard)
+			assert.NilError(t, err)
+			order <- priority
+			release()
+		}(priority)
+
+		// wait until the goroutine is queued
+		for queued := false; !queued; {
+			limiter.m.Lock()
+			queued = len(limiter.waiting[priority]) > 0
+			limiter.m.Unlock()
+		}
+	}
+
+	release()
+	wg.Wait()
+	assert.Equal(t, <-order, execPrioritySession)
+	assert.Equal(t, <-order, execPriorityProbe)
+
+	// a waiter gives up if its context is done
+	release, err = limiter.acquire(context.Background(), execPrioritySession, log.Discard)
+	assert.NilError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	out := &bytes.Buffer{}
+	_, err = limiter.acquire(ctx, execPrioritySession, log.NewStreamLogger(out, out, logrus.DebugLevel))
+	assert.Equal(t, err, context.DeadlineExceeded)
+	assert.Assert(t, strings.Contains(out.String(), "Waiting for a free exec slot, 1 of 1 execs are running"), "Expected a debug message, got %s", out.String())
+	release()
+	assert.Equal(t, limiter.active, 0)
+	assert.Equal(t, len(limiter.waiting[execPrioritySession]), 0)
+}
@@ -0,0 +1,106 @@
+package terminal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/pkg/errors"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// exportScript writes a tar archive of the files on the root filesystem of the container that were changed
+// after the container was started, which is the equivalent of docker diff. Other filesystems like volumes
+// are skipped. The start time is the reference timestamp of find, given as UTC in the format of touch -t.
+// Files are compared by their change time, so that files that were moved or extracted with their original
+// modification time are found as well, unless find doesn't support -cnewer. The exit status and errors of
+// tar are passed on, e.g. if a file cannot be read, and 1 means that a file changed while it was read.
+const exportScript = `command -v tar >/dev/null 2>&1 || { echo 'tar not found in container' >&2; exit 127; }
+ref=/tmp/.devspace-export-$$
+trap 'rm -f "$ref"' EXIT
+TZ=UTC0 touch -t "$1" "$ref" || exit 2
+newer=-cnewer
+find "$ref" -cnewer "$ref" >/dev/null 2>&1 || newer=-newer
+find / -xdev $newer "$ref" ! -path "$ref" \( -type f -o -type l \) -print 2>/dev/null | tar -cf - -T -`
+
+// exportCommand returns the command that exports the files changed since the start of the container
+func exportCommand(startedAt time.Time) []string {
+	return []string{"sh", "-c", exportScript, "devspace-export", startedAt.UTC().Format("200601021504.05")}
+}
+
+// containerStartedAt returns the time the running container was started at
+func containerStartedAt(container *selector.SelectedPodContainer) (time.Time, error) {
+	for _, status := range container.Pod.Status.ContainerStatuses {
+		if status.Name != container.Container.Name {
+			continue
+		} else if status.State.Running == nil {
+			break
+		}
+
+		return status.State.Running.StartedAt.Time, nil
+	}
+
+	return time.Time{}, errors.Errorf("container %s:%s is not running", container.Pod.Name, container.Container.Name)
+}
+
+// ExportFilesystem writes the files that were changed in the container since it started as tar archive to outputPath,
+// e.g. to keep the changes made during a debugging session. Deleted files are not part of the archive.
+func ExportFilesystem(ctx devspacecontext.Context, devContainer *latest.DevContainer, selector targetselector.TargetSelector, outputPath string) error {
+	ctx = withExecLimit(ctx)
+	if devContainer != nil && devContainer.Container != "" {
+		selector = selector.WithContainer(devContainer.Container)
+	}
+
+	container, err := selector.SelectSingleContainer(ctx.Context(), ctx.KubeClient(), ctx.Log())
+	if err != nil {
+		return errors.Wrap(err, "select container")
+	}
+
+	startedAt, err := containerStartedAt(container)
+	if err != nil {
+		return err
+	}
+
+	// the archive is written to a temporary file first, so that a failed export doesn't leave a truncated archive
+	out, err := os.CreateTemp(filepath.Dir(outputPath), ".devspace-export-*")
+	if err != nil {
+		return errors.Wrap(err, "create archive")
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	ctx.Log().Infof("Exporting changed files of %s:%s...", container.Pod.Name, container.Container.Name)
+	stderr := &bytes.Buffer{}
+	err = ctx.KubeClient().ExecStream(ctx.Context(), &kubectl.ExecStreamOptions{
+		Pod:       container.Pod,
+		Container: container.Container.Name,
+		Command:   exportCommand(startedAt),
+		Stdout:    out,
+		Stderr:    stderr,
+	})
+	if exitError, ok := err.(kubectlExec.CodeExitError); ok && exitError.Code == 1 {
+		// tar exits with 1 if a file changed while it was read, which is still part of the archive
+		ctx.Log().Warnf("Some files of %s:%s changed during the export: %s", container.Pod.Name, container.Container.Name, strings.TrimSpace(stderr.String()))
+	} else if err != nil {
+		return errors.Wrapf(err, "export files of %s:%s: %s", container.Pod.Name, container.Container.Name, stderr.String())
+	}
+
+	err = out.Close()
+	if err != nil {
+		return errors.Wrap(err, "write archive")
+	}
+	err = os.Rename(out.Name(), outputPath)
+	if err != nil {
+		return errors.Wrap(err, "write archive")
+	}
+
+	ctx.Log().Donef("Exported changed files of %s:%s to %s", container.Pod.Name, container.Container.Name, outputPath)
+	return nil
+}
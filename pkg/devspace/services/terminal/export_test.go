@@ -0,0 +1,164 @@
+package terminal
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// archiveExecClient writes a tar archive with the given files to stdout
+type archiveExecClient struct {
+	kubectltesting.Client
+
+	files    map[string]string
+	err      error
+	exitCode int
+	options  *kubectl.ExecStreamOptions
+}
+
+func (c *archiveExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.options = options
+	if c.err != nil {
+		_, _ = options.Stdout.Write([]byte("partial"))
+		return c.err
+	}
+
+	writer := tar.NewWriter(options.Stdout)
+	for name, content := range c.files {
+		err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))})
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write([]byte(content))
+		if err != nil {
+			return err
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return err
+	} else if c.exitCode != 0 {
+		_, _ = options.Stderr.Write([]byte("tar: /var/log/app.log: file changed as we read it"))
+		return kubectlExec.CodeExitError{Err: fmt.Errorf("command terminated with exit code %d", c.exitCode), Code: c.exitCode}
+	}
+
+	return nil
+}
+
+func TestExportFilesystem(t *testing.T) {
+	client := &archiveExecClient{files: map[string]string{"etc/app.conf": "debug=true"}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	startedAt := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(startedAt.In(time.FixedZone("CET", 3600)))}}},
+			}},
+		},
+		Container: &corev1.Container{Name: "app"},
+	}}
+
+	outputPath := filepath.Join(t.TempDir(), "debug.tar")
+	err := ExportFilesystem(ctx, &latest.DevContainer{Container: "app"}, podSelector, outputPath)
+	assert.NilError(t, err)
+	assert.Equal(t, client.options.Container, "app")
+	assert.DeepEqual(t, client.options.Command, []string{"sh", "-c", exportScript, "devspace-export", "202201020304.05"})
+
+	archive, err := os.Open(outputPath)
+	assert.NilError(t, err)
+	defer archive.Close()
+	header, err := tar.NewReader(archive).Next()
+	assert.NilError(t, err)
+	assert.Equal(t, header.Name, "etc/app.conf")
+
+	// a failed export doesn't leave a truncated archive behind
+	client.err = fmt.Errorf("connection reset by peer")
+	failedPath := filepath.Join(filepath.Dir(outputPath), "failed.tar")
+	err = ExportFilesystem(ctx, nil, podSelector, failedPath)
+	assert.ErrorContains(t, err, "export files of pod:app")
+	entries, err := os.ReadDir(filepath.Dir(outputPath))
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+}
+
+func TestExportFilesystemFileChanged(t *testing.T) {
+	client := &archiveExecClient{files: map[string]string{"var/log/app.log": "started"}, exitCode: 1}
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.Now()}}},
+			}},
+		},
+		Container: &corev1.Container{Name: "app"},
+	}}
+
+	// a file that changed while tar read it is only a warning
+	outputPath := filepath.Join(t.TempDir(), "debug.tar")
+	err := ExportFilesystem(ctx, nil, podSelector, outputPath)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(out.String(), "file changed as we read it"), "Expected a warning, got %s", out.String())
+	_, err = os.Stat(outputPath)
+	assert.NilError(t, err)
+
+	// other exit codes of tar still fail the export
+	client.exitCode = 2
+	err = ExportFilesystem(ctx, nil, podSelector, filepath.Join(t.TempDir(), "failed.tar"))
+	assert.ErrorContains(t, err, "export files of pod:app")
+}
+
+func TestExportFilesystemNotRunning(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&archiveExecClient{})
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+
+	err := ExportFilesystem(ctx, nil, podSelector, filepath.Join(t.TempDir(), "debug.tar"))
+	assert.ErrorContains(t, err, "container pod:app is not running")
+}
+
+func TestExportScriptTarError(t *testing.T) {
+	for _, tool := range []string{"sh", "tar", "touch"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not available", tool)
+		}
+	}
+
+	// the fake find reports a file that tar cannot read
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "find"), []byte("#!/bin/sh\necho /devspace-missing-file\n"), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	stderr := &bytes.Buffer{}
+	command := exec.Command("sh", "-c", exportScript, "devspace-export", "202201020304.05")
+	command.Stdout = io.Discard
+	command.Stderr = stderr
+	err := command.Run()
+	assert.Assert(t, err != nil, "Expected the error of tar to fail the export")
+	assert.Assert(t, strings.Contains(stderr.String(), "devspace-missing-file"), "Expected the error of tar, got %s", stderr.String())
+}
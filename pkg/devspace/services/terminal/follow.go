@@ -0,0 +1,140 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// DefaultMaxFollowRetries is the number of times a terminal follows a replaced pod if TerminalOptions.MaxFollowRetries is not set
+const DefaultMaxFollowRetries = 10
+
+// followPodTimeout is the maximum time to wait for the replacement of a pod
+var followPodTimeout = time.Minute * 5
+
+// generatedLabels are set by controllers on their pods and differ between the old and the new pod
+var generatedLabels = []string{
+	appsv1.DefaultDeploymentUniqueLabelKey,
+	appsv1.ControllerRevisionHashLabelKey,
+	appsv1.StatefulSetPodNameLabel,
+	"pod-template-generation",
+}
+
+// podGone checks if the pod the session ended with err was connected to was deleted or replaced
+func podGone(ctx devspacecontext.Context, pod *corev1.Pod, err error) bool {
+	if kerrors.IsNotFound(err) {
+		return true
+	}
+
+	current, getErr := ctx.KubeClient().KubeClient().CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	if getErr != nil {
+		return kerrors.IsNotFound(getErr)
+	}
+
+	return current.UID != pod.UID || current.DeletionTimestamp != nil
+}
+
+// followLabelSelector returns the label selector that matches the replacements of the pod
+func followLabelSelector(pod *corev1.Pod) (labels.Selector, error) {
+	podLabels := labels.Set{}
+	for key, value := range pod.Labels {
+		podLabels[key] = value
+	}
+	for _, key := range generatedLabels {
+		delete(podLabels, key)
+	}
+	if len(podLabels) == 0 {
+		return nil, fmt.Errorf("cannot follow pod %s, because it has no labels", pod.Name)
+	}
+
+	return podLabels.AsSelector(), nil
+}
+
+// waitForReplacement watches the namespace of the pod until a different pod with the same labels
+// has the container running and ready and returns it
+func waitForReplacement(ctx devspacecontext.Context, container *selector.SelectedPodContainer) (*selector.SelectedPodContainer, error) {
+	labelSelector, err := followLabelSelector(container.Pod)
+	if err != nil {
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx.Context(), followPodTimeout)
+	defer cancel()
+
+	ctx.Log().Infof("Pod %s is gone, waiting for a new pod with labels %s", container.Pod.Name, labelSelector.String())
+	watcher, err := ctx.KubeClient().KubeClient().CoreV1().Pods(container.Pod.Namespace).Watch(watchCtx, metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return nil, errors.Wrap(err, "watch pods")
+	}
+	defer watcher.Stop()
+
+	// pods that exist already are not reported by the watch if the replacement was faster
+	podList, err := ctx.KubeClient().KubeClient().CoreV1().Pods(container.Pod.Namespace).List(watchCtx, metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return nil, errors.Wrap(err, "list pods")
+	}
+	for i := range podList.Items {
+		if replacement := replacementContainer(container, &podList.Items[i]); replacement != nil {
+			return replacement, nil
+		}
+	}
+
+	for {
+		select {
+		case <-watchCtx.Done():
+			return nil, fmt.Errorf("timed out waiting for a pod to replace %s after %s", container.Pod.Name, followPodTimeout)
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil, fmt.Errorf("watch for a pod to replace %s has been closed", container.Pod.Name)
+			}
+
+			pod, isPod := event.Object.(*corev1.Pod)
+			if !isPod || (event.Type != watch.Added && event.Type != watch.Modified) {
+				continue
+			} else if replacement := replacementContainer(container, pod); replacement != nil {
+				return replacement, nil
+			}
+		}
+	}
+}
+
+// replacementContainer returns the container in pod if it is ready and pod replaces the pod of the given container
+func replacementContainer(container *selector.SelectedPodContainer, pod *corev1.Pod) *selector.SelectedPodContainer {
+	if pod.UID == container.Pod.UID || pod.DeletionTimestamp != nil {
+		return nil
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != container.Container.Name || !status.Ready || status.State.Running == nil {
+			continue
+		}
+
+		for i := range pod.Spec.Containers {
+			if pod.Spec.Containers[i].Name == container.Container.Name {
+				return &selector.SelectedPodContainer{Pod: pod, Container: &pod.Spec.Containers[i]}
+			}
+		}
+	}
+
+	return nil
+}
+
+// followSelector selects the given pod and container
+func followSelector(container *selector.SelectedPodContainer) targetselector.TargetSelector {
+	return targetselector.NewTargetSelector(
+		targetselector.NewOptionsFromFlags(container.Container.Name, "", nil, container.Pod.Namespace, container.Pod.Name).
+			WithPick(false).
+			WithWait(false),
+	)
+}
@@ -0,0 +1,180 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// rolloutExecClient replaces the pod of every session with a new one and ends the session with an error
+type rolloutExecClient struct {
+	kubectltesting.Client
+
+	rollouts int
+	pods     []string
+}
+
+func (c *rolloutExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.pods = append(c.pods, options.Pod.Name)
+	if len(c.pods) > c.rollouts {
+		return nil
+	}
+
+	pods := c.KubeClient().CoreV1().Pods(options.Pod.Namespace)
+	err := pods.Delete(ctx, options.Pod.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+
+	_, err = pods.Create(ctx, newFollowPod(fmt.Sprintf("api-%d", len(c.pods))), metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("error dialing backend: connection reset by peer")
+}
+
+func newFollowPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID(name),
+			Labels:    map[string]string{"app": "api", "pod-template-hash": name},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+}
+
+type followPodLifecycleTestCase struct {
+	name             string
+	rollouts         int
+	maxFollowRetries int
+
+	expectedPods []string
+	expectedErr  string
+}
+
+func TestStartTerminalFollowPodLifecycle(t *testing.T) {
+	testCases := []followPodLifecycleTestCase{
+		{
+			name:         "Follow rollout",
+			rollouts:     1,
+			expectedPods: []string{"api-0", "api-1"},
+		},
+		{
+			name:             "Follow multiple rollouts",
+			rollouts:         2,
+			maxFollowRetries: 2,
+			expectedPods:     []string{"api-0", "api-1", "api-2"},
+		},
+		{
+			name:             "Exceed follow retries",
+			rollouts:         2,
+			maxFollowRetries: 1,
+			expectedPods:     []string{"api-0", "api-1"},
+			expectedErr:      "connection reset by peer",
+		},
+	}
+
+	for _, testCase := range testCases {
+		pod := newFollowPod("api-0")
+		client := &rolloutExecClient{Client: kubectltesting.Client{Client: fake.NewSimpleClientset(pod)}, rollouts: testCase.rollouts}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{Pod: pod, Container: &pod.Spec.Containers[0]}}
+
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithFollowPodLifecycle(testCase.maxFollowRetries),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		if testCase.expectedErr != "" {
+			assert.ErrorContains(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+		} else {
+			assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		}
+		assert.DeepEqual(t, client.pods, testCase.expectedPods)
+	}
+}
+
+func TestStartTerminalRestartReselectsContainer(t *testing.T) {
+	client := &rolloutExecClient{Client: kubectltesting.Client{Client: fake.NewSimpleClientset(newFollowPod("api-0"))}, rollouts: 2}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := targetselector.NewTargetSelector(targetselector.NewOptionsFromFlags("app", "app=api", nil, "default", "").WithWait(false))
+
+	// every restart has to select the replacement instead of reusing the deleted pod
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithRestart(2),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, client.pods, []string{"api-0", "api-1", "api-2"})
+}
+
+func TestFollowLabelSelector(t *testing.T) {
+	labelSelector, err := followLabelSelector(newFollowPod("api-0"))
+	assert.NilError(t, err)
+	assert.Equal(t, labelSelector.String(), "app=api")
+
+	_, err = followLabelSelector(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test"}})
+	assert.ErrorContains(t, err, "cannot follow pod test")
+}
+
+// recreateExecClient recreates the pod with a new uid and ends the first sessions with an error
+type recreateExecClient struct {
+	eofExecClient
+
+	pod *corev1.Pod
+}
+
+func (c *recreateExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.pod.UID = types.UID(string(c.pod.UID) + "-recreated")
+	return c.eofExecClient.ExecStream(ctx, options)
+}
+
+func TestStartTerminalPodRecreated(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default", UID: "uid"}}
+	client := &recreateExecClient{eofExecClient: eofExecClient{remaining: 1}, pod: pod}
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{Pod: pod, Container: &corev1.Container{Name: "app"}}}
+
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithRestart(1),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Count(out.String(), "Pod api-0 was recreated, connecting to new instance"), 1, "Unexpected output %s", out.String())
+
+	// a replacement with a different name is a new pod, not a recreated one
+	out.Reset()
+	rollout := &rolloutExecClient{Client: kubectltesting.Client{Client: fake.NewSimpleClientset(newFollowPod("api-0"))}, rollouts: 1}
+	ctx = ctx.WithKubeClient(rollout)
+	_, err = StartTerminalFromCMDWithOptions(ctx, targetselector.NewTargetSelector(targetselector.NewOptionsFromFlags("app", "app=api", nil, "default", "").WithWait(false)), NewTerminalOptions(
+		WithRestart(1),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, rollout.pods, []string{"api-0", "api-1"})
+	assert.Assert(t, !strings.Contains(out.String(), "was recreated"), "Unexpected output %s", out.String())
+}
@@ -0,0 +1,70 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	kubectlExec "k8s.io/client-go/util/exec"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// DefaultCommandHintTimeout is the time after connecting in which exit code 126 or 127 means
+// that the command itself couldn't be executed, instead of a command typed later in the shell
+const DefaultCommandHintTimeout = time.Second * 3
+
+// commandName returns the program of the first command in a shell command line. Environment
+// variable assignments and exec are skipped. An empty string is returned if it can't be parsed.
+func commandName(command string) string {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil || len(file.Stmts) == 0 {
+		return ""
+	}
+
+	// the first command of e.g. a && b is a
+	cmd := file.Stmts[0].Cmd
+	for {
+		binary, ok := cmd.(*syntax.BinaryCmd)
+		if !ok {
+			break
+		}
+		cmd = binary.X.Cmd
+	}
+	call, ok := cmd.(*syntax.CallExpr)
+	if !ok {
+		return ""
+	}
+	for _, arg := range call.Args {
+		name, err := expand.Literal(nil, arg)
+		if err != nil {
+			return ""
+		} else if name != "exec" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// commandHint returns a hint for the user if the command exited with 126 or 127 within timeout after
+// connecting, which means the shell couldn't find or execute it. An empty string is returned otherwise.
+// Zero uses DefaultCommandHintTimeout, a negative timeout disables the hint.
+func commandHint(err error, elapsed time.Duration, timeout time.Duration, command string, container string) string {
+	if timeout == 0 {
+		timeout = DefaultCommandHintTimeout
+	}
+	exitError, ok := err.(kubectlExec.CodeExitError)
+	if !ok || command == "" || timeout < 0 || elapsed > timeout {
+		return ""
+	}
+
+	switch exitError.Code {
+	case 126:
+		return fmt.Sprintf("Command %s exited with code 126 right after connecting, it is probably not executable in container %s. Please check the permissions of %s in the image", command, container, command)
+	case 127:
+		return fmt.Sprintf("Command %s exited with code 127 right after connecting, it is probably not installed in container %s. Please check that the image contains %s and that it is in the PATH", command, container, command)
+	}
+
+	return ""
+}
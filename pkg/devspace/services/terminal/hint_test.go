@@ -0,0 +1,128 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+type commandHintTestCase struct {
+	name    string
+	err     error
+	elapsed time.Duration
+	timeout time.Duration
+
+	expectedHint string
+}
+
+func TestCommandHint(t *testing.T) {
+	testCases := []commandHintTestCase{
+		{
+			name:         "Command not found",
+			err:          kubectlExec.CodeExitError{Err: fmt.Errorf("exit 127"), Code: 127},
+			elapsed:      time.Second,
+			expectedHint: "Command mytool exited with code 127 right after connecting, it is probably not installed in container app. Please check that the image contains mytool and that it is in the PATH",
+		},
+		{
+			name:         "Command not executable",
+			err:          kubectlExec.CodeExitError{Err: fmt.Errorf("exit 126"), Code: 126},
+			elapsed:      time.Second,
+			expectedHint: "Command mytool exited with code 126 right after connecting, it is probably not executable in container app. Please check the permissions of mytool in the image",
+		},
+		{
+			name:    "Later in the session",
+			err:     kubectlExec.CodeExitError{Err: fmt.Errorf("exit 127"), Code: 127},
+			elapsed: time.Minute,
+		},
+		{
+			name:         "Custom timeout",
+			err:          kubectlExec.CodeExitError{Err: fmt.Errorf("exit 127"), Code: 127},
+			elapsed:      time.Minute,
+			timeout:      time.Hour,
+			expectedHint: "Command mytool exited with code 127 right after connecting, it is probably not installed in container app. Please check that the image contains mytool and that it is in the PATH",
+		},
+		{
+			name:    "Disabled",
+			err:     kubectlExec.CodeExitError{Err: fmt.Errorf("exit 127"), Code: 127},
+			timeout: -1,
+		},
+		{
+			name: "Other exit code",
+			err:  kubectlExec.CodeExitError{Err: fmt.Errorf("exit 1"), Code: 1},
+		},
+		{
+			name: "No exit code",
+			err:  fmt.Errorf("connection lost"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		hint := commandHint(testCase.err, testCase.elapsed, testCase.timeout, "mytool", "app")
+		assert.Equal(t, hint, testCase.expectedHint, "Unexpected hint in "+testCase.name)
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	assert.Equal(t, commandName("mytool --flag"), "mytool")
+	assert.Equal(t, commandName("PS1='[pod] ' FOO=bar mytool"), "mytool")
+	assert.Equal(t, commandName("exec '/opt/my tool' --serve"), "/opt/my tool")
+	assert.Equal(t, commandName("mytool && bash"), "mytool")
+	assert.Equal(t, commandName(""), "")
+}
+
+func TestStartTerminalCommandHint(t *testing.T) {
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(&exitCodeExecClient{exitCode: 127})
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Command:            "FOO=bar mytool --serve",
+		Shell:              "sh",
+		DisableScreen:      true,
+		DisableSessionFile: true,
+	}}
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(out.String(), "Command mytool exited with code 127 right after connecting"), "Expected hint, got %s", out.String())
+}
+
+func TestStartTerminalCommandHintShellPath(t *testing.T) {
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(&exitCodeExecClient{exitCode: 127})
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Shell:              "sh",
+		ShellPath:          "/busybox/sh",
+		DisableScreen:      true,
+		DisableSessionFile: true,
+	}}
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(out.String(), "Command /busybox/sh exited with code 127 right after connecting"), "Expected hint, got %s", out.String())
+}
@@ -0,0 +1,36 @@
+package terminal
+
+import (
+	"fmt"
+	"path"
+)
+
+// historyEnvVars returns the environment variables that isolate or disable the history of the interactive
+// shell started by command. The history file is unique per id, so that multiple sessions to the same
+// container don't interleave their histories. Only bash and zsh read these variables, for other shells
+// nil and false are returned. An empty command starts bash if available, so it is treated as bash.
+func historyEnvVars(command string, id string, isolate bool, noHistory bool) (map[string]string, bool) {
+	if !isolate && !noHistory {
+		return nil, true
+	}
+
+	shell := "bash"
+	if command != "" {
+		shell = path.Base(commandName(command))
+	}
+
+	switch shell {
+	case "bash":
+		if noHistory {
+			return map[string]string{"HISTFILE": "/dev/null", "HISTSIZE": "0"}, true
+		}
+		return map[string]string{"HISTFILE": fmt.Sprintf("/tmp/.devspace-bash-history-%s", id)}, true
+	case "zsh":
+		if noHistory {
+			return map[string]string{"HISTFILE": "/dev/null", "HISTSIZE": "0", "SAVEHIST": "0"}, true
+		}
+		return map[string]string{"HISTFILE": fmt.Sprintf("/tmp/.devspace-zsh-history-%s", id)}, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,123 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type historyEnvVarsTestCase struct {
+	name      string
+	command   string
+	isolate   bool
+	noHistory bool
+
+	expectedEnv map[string]string
+	expectedOk  bool
+}
+
+func TestHistoryEnvVars(t *testing.T) {
+	testCases := []historyEnvVarsTestCase{
+		{
+			name:       "Disabled",
+			command:    "bash",
+			expectedOk: true,
+		},
+		{
+			name:        "Isolated bash history",
+			command:     "bash",
+			isolate:     true,
+			expectedEnv: map[string]string{"HISTFILE": "/tmp/.devspace-bash-history-run"},
+			expectedOk:  true,
+		},
+		{
+			name:        "Default command is treated as bash",
+			isolate:     true,
+			expectedEnv: map[string]string{"HISTFILE": "/tmp/.devspace-bash-history-run"},
+			expectedOk:  true,
+		},
+		{
+			name:        "Isolated zsh history",
+			command:     "exec /bin/zsh -l",
+			isolate:     true,
+			expectedEnv: map[string]string{"HISTFILE": "/tmp/.devspace-zsh-history-run"},
+			expectedOk:  true,
+		},
+		{
+			name:        "No bash history",
+			command:     "bash",
+			isolate:     true,
+			noHistory:   true,
+			expectedEnv: map[string]string{"HISTFILE": "/dev/null", "HISTSIZE": "0"},
+			expectedOk:  true,
+		},
+		{
+			name:        "No zsh history",
+			command:     "zsh",
+			noHistory:   true,
+			expectedEnv: map[string]string{"HISTFILE": "/dev/null", "HISTSIZE": "0", "SAVEHIST": "0"},
+			expectedOk:  true,
+		},
+		{
+			name:    "sh is not supported",
+			command: "sh",
+			isolate: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		env, ok := historyEnvVars(testCase.command, "run", testCase.isolate, testCase.noHistory)
+		assert.Equal(t, ok, testCase.expectedOk, "Unexpected ok in test case %s", testCase.name)
+		assert.DeepEqual(t, env, testCase.expectedEnv)
+	}
+}
+
+var histFileRegexp = regexp.MustCompile(`HISTFILE=/tmp/\.devspace-bash-history-[a-z0-9]+`)
+
+func TestStartTerminalIsolateHistory(t *testing.T) {
+	client := &recordingExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Command:            "bash",
+		Shell:              "sh",
+		IsolateHistory:     true,
+		DisableScreen:      true,
+		DisableSessionFile: true,
+	}}
+
+	// every session of the run has its own history file
+	histFiles := []string{}
+	for i := 0; i < 2; i++ {
+		err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err)
+		histFile := histFileRegexp.FindString(strings.Join(client.options.Command, " "))
+		assert.Assert(t, histFile != "", "Expected HISTFILE to be set, got %v", client.options.Command)
+		histFiles = append(histFiles, histFile)
+	}
+	assert.Assert(t, histFiles[0] != histFiles[1], "Expected a history file per session, got %v", histFiles)
+
+	// sh has no history file, so nothing is set
+	devContainer.Terminal.Command = "sh"
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(strings.Join(client.options.Command, " "), "HISTFILE"), "Expected no HISTFILE, got %v", client.options.Command)
+}
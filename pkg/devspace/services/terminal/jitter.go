@@ -0,0 +1,35 @@
+package terminal
+
+import (
+	"math/rand"
+	"time"
+)
+
+// DefaultRestartJitter is the fraction of the restart delay the delay is randomly changed by
+const DefaultRestartJitter = 0.2
+
+// restartDelay is the time to wait before a terminal is restarted
+var restartDelay = time.Second * 3
+
+// minRestartDelay is the minimum time to wait before a terminal is restarted, regardless of the jitter
+const minRestartDelay = time.Millisecond * 100
+
+// jitter randomly changes delay by up to fraction of it in both directions, so that terminals to
+// multiple replicas don't restart at the same time. The returned delay is never below minRestartDelay.
+// A zero fraction uses DefaultRestartJitter, a negative one disables the jitter.
+func jitter(delay time.Duration, fraction float64) time.Duration {
+	if fraction == 0 {
+		fraction = DefaultRestartJitter
+	} else if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+
+	jittered := delay + time.Duration((rand.Float64()*2-1)*fraction*float64(delay))
+	if jittered < minRestartDelay {
+		return minRestartDelay
+	}
+
+	return jittered
+}
@@ -0,0 +1,56 @@
+package terminal
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type jitterTestCase struct {
+	name     string
+	delay    time.Duration
+	fraction float64
+
+	expectedMin time.Duration
+	expectedMax time.Duration
+}
+
+func TestJitter(t *testing.T) {
+	testCases := []jitterTestCase{
+		{
+			name:        "Default fraction",
+			delay:       time.Second * 3,
+			expectedMin: time.Millisecond * 2400,
+			expectedMax: time.Millisecond * 3600,
+		},
+		{
+			name:        "Custom fraction",
+			delay:       time.Second,
+			fraction:    0.5,
+			expectedMin: time.Millisecond * 500,
+			expectedMax: time.Millisecond * 1500,
+		},
+		{
+			name:        "Disabled",
+			delay:       time.Second,
+			fraction:    -1,
+			expectedMin: time.Second,
+			expectedMax: time.Second,
+		},
+		{
+			name:        "Never below minimum",
+			delay:       time.Second,
+			fraction:    5,
+			expectedMin: minRestartDelay,
+			expectedMax: time.Second * 2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		for i := 0; i < 1000; i++ {
+			delay := jitter(testCase.delay, testCase.fraction)
+			assert.Assert(t, delay >= testCase.expectedMin && delay <= testCase.expectedMax, "Unexpected delay %s in %s", delay, testCase.name)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package terminal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/survey"
+	"github.com/pkg/errors"
+)
+
+// DefaultKillSignal is the signal KillTerminalSession sends if none is given
+const DefaultKillSignal = "SIGTERM"
+
+var signalRegEx = regexp.MustCompile(`^[A-Z0-9]+$`)
+
+// KillTerminalSession sends the signal to all processes in the containers matched by podSelector, except
+// for the main process of the container. If multiple containers match, the user has to confirm first.
+// devContainer is optional and only used to determine the container name and shell.
+func KillTerminalSession(ctx devspacecontext.Context, devContainer *latest.DevContainer, podSelector selector.Selector, signal string) error {
+	ctx = withExecLimit(ctx)
+	signal, err := normalizeSignal(signal)
+	if err != nil {
+		return err
+	}
+	if devContainer != nil && podSelector.ContainerName == "" {
+		podSelector.ContainerName = devContainer.Container
+	}
+	if podSelector.FilterContainer == nil {
+		podSelector.FilterContainer = selector.FilterNonRunningContainers
+	}
+
+	containers, err := selector.NewFilter(ctx.KubeClient()).SelectContainers(ctx.Context(), podSelector)
+	if err != nil {
+		return errors.Wrap(err, "select containers")
+	} else if len(containers) == 0 {
+		return fmt.Errorf("couldn't find a running container")
+	} else if len(containers) > 1 {
+		names := []string{}
+		for _, container := range containers {
+			names = append(names, container.Pod.Name+":"+container.Container.Name)
+		}
+
+		answer, err := ctx.Log().Question(&survey.QuestionOptions{
+			Question: fmt.Sprintf("Do you want to send SIG%s to all processes in %s?", signal, strings.Join(names, ", ")),
+			Options:  []string{"No", "Yes"},
+		})
+		if err != nil {
+			return err
+		} else if answer != "Yes" {
+			return nil
+		}
+	}
+
+	for _, container := range containers {
+		shell := ""
+		windowsShell := ""
+		if devContainer != nil && devContainer.Terminal != nil {
+			shell, windowsShell = devContainer.Terminal.Shell, devContainer.Terminal.WindowsShell
+		}
+		if shell == "" {
+			shell = detectShell(ctx, container, windowsShell)
+		}
+
+		_, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, killCommand(shell, signal), nil)
+		if err != nil {
+			return errors.Wrapf(err, "send SIG%s to processes in %s:%s: %s", signal, container.Pod.Name, container.Container.Name, string(stderr))
+		}
+
+		ctx.Log().Donef("Sent SIG%s to all processes in %s:%s", signal, container.Pod.Name, container.Container.Name)
+	}
+
+	return nil
+}
+
+// normalizeSignal returns the signal name without SIG prefix, e.g. TERM for sigterm
+func normalizeSignal(signal string) (string, error) {
+	if signal == "" {
+		signal = DefaultKillSignal
+	}
+
+	normalized := strings.TrimPrefix(strings.ToUpper(signal), "SIG")
+	if !signalRegEx.MatchString(normalized) {
+		return "", fmt.Errorf("invalid signal %s", signal)
+	}
+
+	return normalized, nil
+}
+
+// windowsKillScript terminates all processes except for the system processes and powershell itself
+// with Process.Kill, which is used if taskkill is not available in the container
+const windowsKillScript = `Get-Process | Where-Object { $_.Id -gt 4 -and $_.Id -ne $PID } | ForEach-Object { $_.Kill() }`
+
+// killCommand returns the command that sends the signal to all processes the calling process is allowed
+// to signal. Windows has no signals, so all processes are terminated there regardless of the signal, with
+// taskkill or, if it is missing or fails, with windowsKillScript.
+func killCommand(shell string, signal string) []string {
+	switch shell {
+	case ShellPowershell:
+		return []string{"powershell", "-NoLogo", "-NonInteractive", "-Command", "$killed = $false; if (Get-Command taskkill -ErrorAction SilentlyContinue) { taskkill /F /FI 'PID gt 4'; $killed = $LASTEXITCODE -eq 0 }; if (-not $killed) { " + windowsKillScript + " }"}
+	case ShellCmd:
+		return []string{"cmd.exe", "/S", "/C", `where /q taskkill && taskkill /F /FI "PID gt 4" || powershell -NoLogo -NonInteractive -Command "` + windowsKillScript + `"`}
+	}
+
+	return []string{"sh", "-c", "kill -s " + signal + " -1"}
+}
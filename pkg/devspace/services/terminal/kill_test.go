@@ -0,0 +1,124 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	logtesting "github.com/loft-sh/devspace/pkg/util/log/testing"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// killRecordingClient records the containers and commands of all exec requests
+type killRecordingClient struct {
+	kubectltesting.Client
+
+	execs map[string][]string
+}
+
+func (c *killRecordingClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	c.execs[pod.Name] = command
+	return nil, nil, nil
+}
+
+type killTerminalSessionTestCase struct {
+	name   string
+	pods   []string
+	signal string
+	shell  string
+	answer string
+
+	expectedExecs map[string][]string
+}
+
+func TestKillTerminalSession(t *testing.T) {
+	testCases := []killTerminalSessionTestCase{
+		{
+			name:          "Single container",
+			pods:          []string{"first"},
+			expectedExecs: map[string][]string{"first": {"sh", "-c", "kill -s TERM -1"}},
+		},
+		{
+			name:   "Multiple containers confirmed",
+			pods:   []string{"first", "second"},
+			signal: "sigkill",
+			answer: "Yes",
+			expectedExecs: map[string][]string{
+				"first":  {"sh", "-c", "kill -s KILL -1"},
+				"second": {"sh", "-c", "kill -s KILL -1"},
+			},
+		},
+		{
+			name:          "Windows container with cmd",
+			pods:          []string{"first"},
+			shell:         ShellCmd,
+			expectedExecs: map[string][]string{"first": {"cmd.exe", "/S", "/C", `where /q taskkill && taskkill /F /FI "PID gt 4" || powershell -NoLogo -NonInteractive -Command "` + windowsKillScript + `"`}},
+		},
+		{
+			name:          "Windows container with powershell",
+			pods:          []string{"first"},
+			shell:         ShellPowershell,
+			expectedExecs: map[string][]string{"first": {"powershell", "-NoLogo", "-NonInteractive", "-Command", "$killed = $false; if (Get-Command taskkill -ErrorAction SilentlyContinue) { taskkill /F /FI 'PID gt 4'; $killed = $LASTEXITCODE -eq 0 }; if (-not $killed) { " + windowsKillScript + " }"}},
+		},
+		{
+			name:          "Multiple containers declined",
+			pods:          []string{"first", "second"},
+			answer:        "No",
+			expectedExecs: map[string][]string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		kubeClient := fake.NewSimpleClientset()
+		for _, name := range testCase.pods {
+			_, err := kubeClient.CoreV1().Pods("testNamespace").Create(context.Background(), &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "testNamespace", Labels: map[string]string{"app": "test"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name:  "app",
+							Ready: true,
+							State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+						},
+					},
+				},
+			}, metav1.CreateOptions{})
+			assert.NilError(t, err)
+		}
+
+		logger := logtesting.NewFakeLogger()
+		if testCase.answer != "" {
+			logger.SetAnswer(testCase.answer)
+		}
+		client := &killRecordingClient{Client: kubectltesting.Client{Client: kubeClient}, execs: map[string][]string{}}
+		ctx := devspacecontext.NewContext(context.Background(), nil, logger).WithKubeClient(client)
+		shell := testCase.shell
+		if shell == "" {
+			shell = ShellSh
+		}
+		devContainer := &latest.DevContainer{Terminal: &latest.Terminal{Shell: shell}}
+
+		err := KillTerminalSession(ctx, devContainer, selector.Selector{LabelSelector: "app=test"}, testCase.signal)
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.DeepEqual(t, client.execs, testCase.expectedExecs)
+	}
+}
+
+func TestNormalizeSignal(t *testing.T) {
+	for signal, expected := range map[string]string{"": "TERM", "SIGTERM": "TERM", "kill": "KILL", "9": "9"} {
+		normalized, err := normalizeSignal(signal)
+		assert.NilError(t, err)
+		assert.Equal(t, normalized, expected)
+	}
+
+	_, err := normalizeSignal("TERM; rm -rf /")
+	assert.ErrorContains(t, err, "invalid signal")
+}
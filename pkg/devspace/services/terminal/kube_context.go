@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/mgutz/ansi"
+	"github.com/pkg/errors"
+)
+
+// switchKubeContext returns a context with a kube client for the given kube context
+// if it differs from the active one, so that the terminal is opened in the right cluster.
+// The client uses the given namespace or the default namespace of the kube context if it is empty.
+func switchKubeContext(ctx devspacecontext.Context, kubeContext string, namespace string) (devspacecontext.Context, error) {
+	if kubeContext == "" || kubeContext == ctx.KubeClient().CurrentContext() {
+		return ctx, nil
+	}
+
+	client, err := kubectl.NewClientFromContext(kubeContext, namespace, false, ctx.KubeClient().KubeConfigLoader())
+	if err != nil {
+		return nil, errors.Wrapf(err, "create kube client for context %s", kubeContext)
+	}
+
+	ctx.Log().Warnf("Switching kube context from %s to %s for the terminal", ansi.Color(ctx.KubeClient().CurrentContext(), "white+b"), ansi.Color(kubeContext, "white+b"))
+	return ctx.WithKubeClient(client), nil
+}
+
+// sessionKubeContext returns a context with the kube client of the session, which is used by StartTerminalFromCMD
+// and StartTerminalWithOptions. The KubeClient of the options is preferred, otherwise the client is switched to
+// configuredKubeContext, the kube context of the dev container, or the KubeContext of the options. Only the kube
+// client of the context is replaced, so that cancelling the context still interrupts the session.
+func sessionKubeContext(ctx devspacecontext.Context, options *TerminalOptions, configuredKubeContext string) (devspacecontext.Context, error) {
+	if options.KubeClient != nil {
+		return ctx.WithKubeClient(options.KubeClient), nil
+	}
+
+	kubeContext := options.KubeContext
+	if configuredKubeContext != "" {
+		kubeContext = configuredKubeContext
+	}
+
+	return switchKubeContext(ctx, kubeContext, options.NamespaceOverride)
+}
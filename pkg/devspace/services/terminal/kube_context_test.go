@@ -0,0 +1,143 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	kubeconfigtesting "github.com/loft-sh/devspace/pkg/util/kubeconfig/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+type switchKubeContextTestCase struct {
+	name        string
+	kubeContext string
+	namespace   string
+
+	expectedContext   string
+	expectedNamespace string
+}
+
+func TestSwitchKubeContext(t *testing.T) {
+	loader := &kubeconfigtesting.Loader{
+		RawConfig: &api.Config{
+			Clusters: map[string]*api.Cluster{
+				"production": {Server: "https://production"},
+				"staging":    {Server: "https://staging"},
+			},
+			Contexts: map[string]*api.Context{
+				"production": {Cluster: "production"},
+				"staging":    {Cluster: "staging", Namespace: "staging-namespace"},
+			},
+			CurrentContext: "production",
+		},
+	}
+
+	testCases := []switchKubeContextTestCase{
+		{
+			name:              "No kube context configured",
+			expectedContext:   "production",
+			expectedNamespace: "testNamespace",
+		},
+		{
+			name:              "Same kube context",
+			kubeContext:       "production",
+			namespace:         "other",
+			expectedContext:   "production",
+			expectedNamespace: "testNamespace",
+		},
+		{
+			name:              "Different kube context with its default namespace",
+			kubeContext:       "staging",
+			expectedContext:   "staging",
+			expectedNamespace: "staging-namespace",
+		},
+		{
+			name:              "Different kube context with namespace override",
+			kubeContext:       "staging",
+			namespace:         "other",
+			expectedContext:   "staging",
+			expectedNamespace: "other",
+		},
+	}
+
+	for _, testCase := range testCases {
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&kubectltesting.Client{
+			Context:    "production",
+			KubeLoader: loader,
+		})
+
+		ctx, err := switchKubeContext(ctx, testCase.kubeContext, testCase.namespace)
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, ctx.KubeClient().CurrentContext(), testCase.expectedContext, "Unexpected context in "+testCase.name)
+		assert.Equal(t, ctx.KubeClient().Namespace(), testCase.expectedNamespace, "Unexpected namespace in "+testCase.name)
+	}
+}
+
+// blockingExecClient blocks every exec stream until the context is cancelled
+type blockingExecClient struct {
+	kubectltesting.Client
+
+	started chan struct{}
+}
+
+func (c *blockingExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	close(c.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestStartTerminalKubeClientOverride(t *testing.T) {
+	ambient := &recordingExecClient{}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(ambient)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "container"},
+	}}
+
+	// a session to the other cluster is interrupted with the context as usual
+	staging := &blockingExecClient{started: make(chan struct{})}
+	stagingDone := make(chan error)
+	go func() {
+		devContainer := &latest.DevContainer{Terminal: &latest.Terminal{Shell: ShellSh, DisableScreen: true, DisableSessionFile: true}}
+		stagingDone <- StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+			WithKubeClient(staging),
+			WithRestart(RestartUnlimited),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+	}()
+	<-staging.started
+
+	production := &recordingExecClient{}
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithKubeClient(production),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Assert(t, production.options != nil, "Expected the session to use the override client")
+	assert.Assert(t, ambient.options == nil, "Unexpected exec stream with the ambient client")
+
+	cancel()
+	select {
+	case err = <-stagingDone:
+		assert.NilError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("Expected the session to end after the context was cancelled")
+	}
+}
@@ -0,0 +1,79 @@
+package terminal
+
+import (
+	"strings"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// InjectedKubeconfigPathPrefix is the prefix of the path in the container the kubeconfig is written to if
+// TerminalOptions.InjectKubeconfig is set. Every terminal writes its own kubeconfig named after its session
+// id, which exists while the session is connected.
+const InjectedKubeconfigPathPrefix = "/tmp/.devspace-kubeconfig-"
+
+// kubeconfigCleanupScript removes the kubeconfig given as first argument once the command has ended. The command
+// is not exec'd, so that the shell survives it, and signals are turned into a regular exit.
+const kubeconfigCleanupScript = `kubeconfig="$1"; shift; trap 'rm -f "$kubeconfig"' EXIT; trap 'exit 129' HUP; trap 'exit 130' INT; trap 'exit 143' TERM; "$@"`
+
+// injectKubeconfig writes the sanitized kubeconfig of the client to kubeconfigPath in the container
+func injectKubeconfig(ctx devspacecontext.Context, container *selector.SelectedPodContainer, kubeconfigPath string) error {
+	kubeconfig, err := sanitizedKubeconfig(ctx.KubeClient())
+	if err != nil {
+		return err
+	}
+
+	ctx.Log().Debugf("Writing kubeconfig to %s...", kubeconfigPath)
+	stdout, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{"sh", "-c", "umask 077 && cat > " + kubeconfigPath}, strings.NewReader(string(kubeconfig)))
+	if err != nil {
+		return errors.Errorf("error writing kubeconfig: %s %s %v", string(stdout), string(stderr), err)
+	}
+
+	return nil
+}
+
+// withKubeconfigCleanup wraps the command into a shell that removes the injected kubeconfig once the command has ended
+func withKubeconfigCleanup(command []string, kubeconfigPath string) []string {
+	return append([]string{"sh", "-c", kubeconfigCleanupScript, "devspace-kubeconfig", kubeconfigPath}, command...)
+}
+
+// sanitizedKubeconfig returns the kubeconfig of the current context of the client with the certificates
+// inlined, as local files cannot be read in the container. Exec credential plugins, auth providers and
+// token files are removed, because their binaries and files don't exist in the container either.
+func sanitizedKubeconfig(client kubectl.Client) ([]byte, error) {
+	if client.ClientConfig() == nil {
+		return nil, errors.New("the kube client has no kubeconfig")
+	}
+
+	config, err := client.ClientConfig().RawConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "load kubeconfig")
+	}
+	if client.CurrentContext() != "" {
+		config.CurrentContext = client.CurrentContext()
+	}
+
+	err = clientcmdapi.MinifyConfig(&config)
+	if err != nil {
+		return nil, errors.Wrap(err, "minify kubeconfig")
+	}
+	err = clientcmdapi.FlattenConfig(&config)
+	if err != nil {
+		return nil, errors.Wrap(err, "flatten kubeconfig")
+	}
+
+	for _, kubeContext := range config.Contexts {
+		kubeContext.Namespace = client.Namespace()
+	}
+	for _, authInfo := range config.AuthInfos {
+		authInfo.Exec = nil
+		authInfo.AuthProvider = nil
+		authInfo.TokenFile = ""
+	}
+
+	return clientcmd.Write(config)
+}
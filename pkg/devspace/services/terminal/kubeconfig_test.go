@@ -0,0 +1,134 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// kubeconfigExecClient has a kubeconfig and records the files written with ExecBuffered
+type kubeconfigExecClient struct {
+	recordingExecClient
+
+	written map[string]string
+}
+
+func (c *kubeconfigExecClient) CurrentContext() string {
+	return "dev"
+}
+
+func (c *kubeconfigExecClient) ClientConfig() clientcmd.ClientConfig {
+	return clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+		CurrentContext: "prod",
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"dev":  {Server: "https://dev.example.com", CertificateAuthorityData: []byte("ca")},
+			"prod": {Server: "https://prod.example.com"},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"dev": {
+				Token:     "token",
+				TokenFile: "/home/user/.token",
+				Exec:      &clientcmdapi.ExecConfig{Command: "aws", APIVersion: "client.authentication.k8s.io/v1"},
+			},
+			"prod": {Token: "prod-token"},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"dev":  {Cluster: "dev", AuthInfo: "dev"},
+			"prod": {Cluster: "prod", AuthInfo: "prod"},
+		},
+	}, &clientcmd.ConfigOverrides{})
+}
+
+func (c *kubeconfigExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	out, err := io.ReadAll(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.written[command[len(command)-1]] = string(out)
+	return nil, nil, nil
+}
+
+func TestSanitizedKubeconfig(t *testing.T) {
+	out, err := sanitizedKubeconfig(&kubeconfigExecClient{})
+	assert.NilError(t, err)
+	config, err := clientcmd.Load(out)
+	assert.NilError(t, err)
+
+	// only the current context of the client is kept
+	assert.Equal(t, config.CurrentContext, "dev")
+	assert.Equal(t, len(config.Contexts), 1)
+	assert.Equal(t, config.Contexts["dev"].Namespace, "testNamespace")
+	assert.Equal(t, len(config.Clusters), 1)
+	assert.Equal(t, config.Clusters["dev"].Server, "https://dev.example.com")
+	assert.Equal(t, string(config.Clusters["dev"].CertificateAuthorityData), "ca")
+	assert.Equal(t, len(config.AuthInfos), 1)
+	assert.Equal(t, config.AuthInfos["dev"].Token, "token")
+	assert.Assert(t, config.AuthInfos["dev"].Exec == nil, "Expected exec credentials to be removed")
+	assert.Equal(t, config.AuthInfos["dev"].TokenFile, "")
+}
+
+func TestStartTerminalInjectKubeconfig(t *testing.T) {
+	client := &kubeconfigExecClient{written: map[string]string{}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Command:            "bash",
+		Shell:              "sh",
+		DisableScreen:      true,
+		DisableSessionFile: true,
+	}}
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithInjectKubeconfig(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, len(client.written), 1)
+	for script, kubeconfig := range client.written {
+		kubeconfigPath := strings.TrimPrefix(script, "umask 077 && cat > ")
+		assert.Assert(t, strings.HasPrefix(kubeconfigPath, InjectedKubeconfigPathPrefix) && len(kubeconfigPath) > len(InjectedKubeconfigPathPrefix), "Expected a kubeconfig per session, got %s", kubeconfigPath)
+		assert.Assert(t, strings.Contains(kubeconfig, "https://dev.example.com"), "Expected the kubeconfig to be written, got %s", kubeconfig)
+
+		// the kubeconfig is removed once the session has ended
+		command := strings.Join(client.options.Command, " ")
+		assert.Assert(t, strings.HasPrefix(command, strings.Join(withKubeconfigCleanup(nil, kubeconfigPath), " ")), "Expected the kubeconfig to be removed, got %v", client.options.Command)
+		assert.Assert(t, strings.Contains(command, "KUBECONFIG="+kubeconfigPath), "Expected KUBECONFIG to be set, got %v", client.options.Command)
+	}
+}
+
+func TestKubeconfigCleanup(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	kubeconfigPath := filepath.Join(t.TempDir(), "kubeconfig")
+	assert.NilError(t, os.WriteFile(kubeconfigPath, []byte("{}"), 0600))
+
+	command := withKubeconfigCleanup([]string{"sh", "-c", "exit 3"}, kubeconfigPath)
+	err := exec.Command(command[0], command[1:]...).Run()
+	exitErr := &exec.ExitError{}
+	assert.Assert(t, errors.As(err, &exitErr), "Expected the exit code to be passed on, got %v", err)
+	assert.Equal(t, exitErr.ExitCode(), 3)
+	_, err = os.Stat(kubeconfigPath)
+	assert.Assert(t, os.IsNotExist(err), "Expected the kubeconfig to be removed")
+}
@@ -0,0 +1,145 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+)
+
+// contextLoggerColor is the color of the prefix of the context logger
+const contextLoggerColor = "yellow+b"
+
+// NewContextLogger returns a logger that prefixes every line of base with the colored name of
+// the terminal, e.g. [terminal:app], so that the output of concurrent terminals can be told apart
+func NewContextLogger(base log.Logger, prefix string) log.Logger {
+	return &contextLogger{Logger: base.WithPrefixColor("[terminal:"+prefix+"] ", contextLoggerColor)}
+}
+
+// silencedBaseLogger tracks the sessions that silenced the base logger and its level before
+var silencedBaseLogger = struct {
+	sync.Mutex
+
+	sessions int
+	level    logrus.Level
+}{}
+
+// silenceBaseLogger silences the base logger until the returned function is called. The level is
+// restored once all sessions that silenced it have ended, so concurrent sessions don't restore
+// the silenced level of each other.
+func silenceBaseLogger() func() {
+	silencedBaseLogger.Lock()
+	defer silencedBaseLogger.Unlock()
+
+	if silencedBaseLogger.sessions == 0 {
+		silencedBaseLogger.level = log.GetBaseInstance().GetLevel()
+		log.GetBaseInstance().SetLevel(logrus.PanicLevel)
+	}
+	silencedBaseLogger.sessions++
+
+	return func() {
+		silencedBaseLogger.Lock()
+		defer silencedBaseLogger.Unlock()
+
+		silencedBaseLogger.sessions--
+		if silencedBaseLogger.sessions == 0 {
+			log.GetBaseInstance().SetLevel(silencedBaseLogger.level)
+		}
+	}
+}
+
+// contextLogger splits messages into lines, as the prefix is only added to the first line of a message otherwise
+type contextLogger struct {
+	log.Logger
+}
+
+func (c *contextLogger) lines(message string, fn func(args ...interface{})) {
+	for _, line := range strings.Split(message, "\n") {
+		fn(line)
+	}
+}
+
+func (c *contextLogger) Debug(args ...interface{}) {
+	c.lines(fmt.Sprint(args...), c.Logger.Debug)
+}
+
+func (c *contextLogger) Debugf(format string, args ...interface{}) {
+	c.lines(fmt.Sprintf(format, args...), c.Logger.Debug)
+}
+
+func (c *contextLogger) Info(args ...interface{}) {
+	c.lines(fmt.Sprint(args...), c.Logger.Info)
+}
+
+func (c *contextLogger) Infof(format string, args ...interface{}) {
+	c.lines(fmt.Sprintf(format, args...), c.Logger.Info)
+}
+
+func (c *contextLogger) Done(args ...interface{}) {
+	c.lines(fmt.Sprint(args...), c.Logger.Done)
+}
+
+func (c *contextLogger) Donef(format string, args ...interface{}) {
+	c.lines(fmt.Sprintf(format, args...), c.Logger.Done)
+}
+
+func (c *contextLogger) Warn(args ...interface{}) {
+	c.lines(fmt.Sprint(args...), c.Logger.Warn)
+}
+
+func (c *contextLogger) Warnf(format string, args ...interface{}) {
+	c.lines(fmt.Sprintf(format, args...), c.Logger.Warn)
+}
+
+func (c *contextLogger) Error(args ...interface{}) {
+	c.lines(fmt.Sprint(args...), c.Logger.Error)
+}
+
+func (c *contextLogger) Errorf(format string, args ...interface{}) {
+	c.lines(fmt.Sprintf(format, args...), c.Logger.Error)
+}
+
+// Fatal prints all but the last line as error, as the logger exits after the first fatal line
+func (c *contextLogger) Fatal(args ...interface{}) {
+	lines := strings.Split(fmt.Sprint(args...), "\n")
+	for _, line := range lines[:len(lines)-1] {
+		c.Logger.Error(line)
+	}
+	c.Logger.Fatal(lines[len(lines)-1])
+}
+
+func (c *contextLogger) Fatalf(format string, args ...interface{}) {
+	c.Fatal(fmt.Sprintf(format, args...))
+}
+
+func (c *contextLogger) Print(level logrus.Level, args ...interface{}) {
+	c.lines(fmt.Sprint(args...), func(args ...interface{}) {
+		c.Logger.Print(level, args...)
+	})
+}
+
+func (c *contextLogger) Printf(level logrus.Level, format string, args ...interface{}) {
+	c.Print(level, fmt.Sprintf(format, args...))
+}
+
+func (c *contextLogger) WithLevel(level logrus.Level) log.Logger {
+	return &contextLogger{Logger: c.Logger.WithLevel(level)}
+}
+
+func (c *contextLogger) ErrorStreamOnly() log.Logger {
+	return &contextLogger{Logger: c.Logger.ErrorStreamOnly()}
+}
+
+func (c *contextLogger) WithPrefix(prefix string) log.Logger {
+	return &contextLogger{Logger: c.Logger.WithPrefix(prefix)}
+}
+
+func (c *contextLogger) WithPrefixColor(prefix, color string) log.Logger {
+	return &contextLogger{Logger: c.Logger.WithPrefixColor(prefix, color)}
+}
+
+func (c *contextLogger) WithSink(sink log.Logger) log.Logger {
+	return &contextLogger{Logger: c.Logger.WithSink(sink)}
+}
@@ -0,0 +1,41 @@
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestNewContextLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewContextLogger(log.NewStreamLogger(out, out, logrus.DebugLevel), "myapp")
+
+	logger.Info("Opening shell")
+	logger.Debugf("Starting terminal...")
+	logger.Warnf("Couldn't negotiate a tty:\nunable to upgrade connection")
+	logger.Donef("Stopped terminal")
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Equal(t, len(lines), 5)
+	for _, line := range lines {
+		assert.Assert(t, strings.Contains(line, "[terminal:myapp] "), "Expected prefix in line %q", line)
+	}
+}
+
+func TestSilenceBaseLogger(t *testing.T) {
+	defer log.GetBaseInstance().SetLevel(log.GetBaseInstance().GetLevel())
+	log.GetBaseInstance().SetLevel(logrus.InfoLevel)
+
+	// the level is restored once the last of concurrent sessions has ended
+	restoreFirst := silenceBaseLogger()
+	restoreSecond := silenceBaseLogger()
+	assert.Equal(t, log.GetBaseInstance().GetLevel(), logrus.PanicLevel)
+	restoreFirst()
+	assert.Equal(t, log.GetBaseInstance().GetLevel(), logrus.PanicLevel)
+	restoreSecond()
+	assert.Equal(t, log.GetBaseInstance().GetLevel(), logrus.InfoLevel)
+}
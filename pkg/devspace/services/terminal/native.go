@@ -0,0 +1,137 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// lookPath is replaced in tests to simulate a missing kubectl binary
+var lookPath = exec.LookPath
+
+// nativeKubectlPath returns the path of the kubectl binary if the terminal should use it instead
+// of the SPDY client. If kubectl cannot be found, the terminal falls back to the SPDY client
+// unless TerminalOptions.RequireNativeKubectl is set.
+func nativeKubectlPath(ctx devspacecontext.Context, options *TerminalOptions) (string, error) {
+	if !options.UseNativeKubectl && !options.RequireNativeKubectl {
+		return "", nil
+	}
+
+	path, err := lookPath("kubectl")
+	if err != nil {
+		if options.RequireNativeKubectl {
+			return "", fmt.Errorf("couldn't find kubectl in PATH, which is required to open the terminal: %w", err)
+		}
+
+		ctx.Log().Warnf("Couldn't find kubectl in PATH, falling back to the builtin exec client: %v", err)
+		return "", nil
+	}
+
+	return path, nil
+}
+
+// nativeKubeconfig writes the kubeconfig of the client to a temporary file, so that kubectl connects with
+// the same kubeconfig and kube context as the client, e.g. the ones set by --kubeconfig and --kube-context.
+// The certificates are inlined, as relative paths would otherwise be resolved against the temporary file.
+// An empty path is returned if the client has no kubeconfig. The returned function removes the file.
+func nativeKubeconfig(client kubectl.Client) (string, func(), error) {
+	if client.ClientConfig() == nil {
+		return "", func() {}, nil
+	}
+
+	config, err := client.ClientConfig().RawConfig()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "load kubeconfig")
+	}
+	if client.CurrentContext() != "" {
+		config.CurrentContext = client.CurrentContext()
+	}
+	err = clientcmdapi.FlattenConfig(&config)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "flatten kubeconfig")
+	}
+
+	file, err := os.CreateTemp("", "devspace-kubeconfig-")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "create kubeconfig")
+	}
+	_ = file.Close()
+	remove := func() { _ = os.Remove(file.Name()) }
+	err = clientcmd.WriteToFile(config, file.Name())
+	if err != nil {
+		remove()
+		return "", nil, errors.Wrap(err, "write kubeconfig")
+	}
+
+	return file.Name(), remove, nil
+}
+
+// nativeKubectlArgs returns the arguments for kubectl to execute the exec stream
+func nativeKubectlArgs(kubeconfigPath string, kubeContext string, options *kubectl.ExecStreamOptions) []string {
+	subResource := options.SubResource
+	if subResource == "" {
+		subResource = kubectl.SubResourceExec
+	}
+
+	args := []string{string(subResource)}
+	if kubeconfigPath != "" {
+		args = append(args, "--kubeconfig", kubeconfigPath)
+	}
+	if kubeContext != "" {
+		args = append(args, "--context", kubeContext)
+	}
+	args = append(args, "--namespace", options.Pod.Namespace, options.Pod.Name, "--container", options.Container)
+	if options.Stdin != nil {
+		args = append(args, "--stdin")
+	}
+	if options.TTY || options.ForceTTY {
+		args = append(args, "--tty")
+	}
+	if subResource == kubectl.SubResourceExec {
+		args = append(args, "--")
+		args = append(args, options.Command...)
+	}
+
+	return args
+}
+
+// nativeExecStream runs the exec stream as kubectl subprocess with the kubeconfig and kube context of the
+// client. The exit code of the command is returned as kubectlExec.CodeExitError like the SPDY client does.
+func nativeExecStream(ctx context.Context, kubectlPath string, client kubectl.Client, options *kubectl.ExecStreamOptions) error {
+	kubeconfigPath, removeKubeconfig, err := nativeKubeconfig(client)
+	if err != nil {
+		return err
+	}
+	defer removeKubeconfig()
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, kubectlPath, nativeKubectlArgs(kubeconfigPath, client.CurrentContext(), options)...)
+	cmd.Env = append(os.Environ(), kubectl.ProxyEnv(options.HTTPProxy, options.NoProxy)...)
+	cmd.Stdin = options.Stdin
+	cmd.Stdout = options.Stdout
+	cmd.Stderr = options.Stderr
+	err = cmd.Run()
+	if options.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return kubectl.ErrExecTimeout
+	}
+
+	exitError := &exec.ExitError{}
+	if errors.As(err, &exitError) && exitError.ExitCode() >= 0 {
+		return kubectlExec.CodeExitError{Err: err, Code: exitError.ExitCode()}
+	}
+
+	return err
+}
@@ -0,0 +1,159 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+type nativeKubectlArgsTestCase struct {
+	name           string
+	kubeconfigPath string
+	kubeContext    string
+	options        kubectl.ExecStreamOptions
+
+	expected []string
+}
+
+func TestNativeKubectlArgs(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+	testCases := []nativeKubectlArgsTestCase{
+		{
+			name:     "Exec without stdin",
+			options:  kubectl.ExecStreamOptions{Pod: pod, Container: "app", Command: []string{"ls", "-l"}},
+			expected: []string{"exec", "--namespace", "default", "pod", "--container", "app", "--", "ls", "-l"},
+		},
+		{
+			name:        "Interactive exec",
+			kubeContext: "dev",
+			options:     kubectl.ExecStreamOptions{Pod: pod, Container: "app", Command: []string{"sh"}, Stdin: strings.NewReader(""), TTY: true},
+			expected:    []string{"exec", "--context", "dev", "--namespace", "default", "pod", "--container", "app", "--stdin", "--tty", "--", "sh"},
+		},
+		{
+			name:           "Kubeconfig",
+			kubeconfigPath: "/tmp/kubeconfig",
+			kubeContext:    "dev",
+			options:        kubectl.ExecStreamOptions{Pod: pod, Container: "app", Command: []string{"sh"}},
+			expected:       []string{"exec", "--kubeconfig", "/tmp/kubeconfig", "--context", "dev", "--namespace", "default", "pod", "--container", "app", "--", "sh"},
+		},
+		{
+			name:     "Attach",
+			options:  kubectl.ExecStreamOptions{Pod: pod, Container: "app", Stdin: strings.NewReader(""), SubResource: kubectl.SubResourceAttach},
+			expected: []string{"attach", "--namespace", "default", "pod", "--container", "app", "--stdin"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.DeepEqual(t, nativeKubectlArgs(testCase.kubeconfigPath, testCase.kubeContext, &testCase.options), testCase.expected)
+	}
+}
+
+type nativeKubectlPathTestCase struct {
+	name    string
+	options TerminalOptions
+	found   bool
+
+	expectedPath string
+	expectedErr  string
+}
+
+func TestNativeKubectlPath(t *testing.T) {
+	defer func(original func(string) (string, error)) { lookPath = original }(lookPath)
+
+	testCases := []nativeKubectlPathTestCase{
+		{
+			name:  "Disabled",
+			found: true,
+		},
+		{
+			name:         "Found",
+			options:      TerminalOptions{UseNativeKubectl: true},
+			found:        true,
+			expectedPath: "/usr/bin/kubectl",
+		},
+		{
+			name:    "Fallback to builtin client",
+			options: TerminalOptions{UseNativeKubectl: true},
+		},
+		{
+			name:        "Required",
+			options:     TerminalOptions{UseNativeKubectl: true, RequireNativeKubectl: true},
+			expectedErr: "couldn't find kubectl in PATH",
+		},
+	}
+
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard)
+	for _, testCase := range testCases {
+		found := testCase.found
+		lookPath = func(file string) (string, error) {
+			if !found {
+				return "", fmt.Errorf("executable file not found in $PATH")
+			}
+
+			return "/usr/bin/" + file, nil
+		}
+
+		path, err := nativeKubectlPath(ctx, &testCase.options)
+		if testCase.expectedErr != "" {
+			assert.ErrorContains(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+			continue
+		}
+
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, path, testCase.expectedPath, "Unexpected path in "+testCase.name)
+	}
+}
+
+func TestNativeExecStreamExitCode(t *testing.T) {
+	kubectlPath := filepath.Join(t.TempDir(), "kubectl")
+	err := os.WriteFile(kubectlPath, []byte("#!/bin/sh\necho \"$@\"\nexit 3\n"), 0755)
+	assert.NilError(t, err)
+
+	stdout := &bytes.Buffer{}
+	err = nativeExecStream(context.Background(), kubectlPath, &kubectltesting.Client{}, &kubectl.ExecStreamOptions{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: "app",
+		Command:   []string{"false"},
+		Stdout:    stdout,
+	})
+	exitError, ok := err.(kubectlExec.CodeExitError)
+	assert.Assert(t, ok, "Expected an exit code error, got %v", err)
+	assert.Equal(t, exitError.Code, 3)
+	assert.Equal(t, stdout.String(), "exec --namespace default pod --container app -- false\n")
+}
+
+func TestNativeExecStreamKubeconfig(t *testing.T) {
+	// the fake kubectl prints the kube context of the kubeconfig it was called with
+	kubectlPath := filepath.Join(t.TempDir(), "kubectl")
+	err := os.WriteFile(kubectlPath, []byte("#!/bin/sh\necho \"$3\"\necho \"$5\"\ngrep current-context \"$3\"\n"), 0755)
+	assert.NilError(t, err)
+
+	stdout := &bytes.Buffer{}
+	err = nativeExecStream(context.Background(), kubectlPath, &kubeconfigExecClient{}, &kubectl.ExecStreamOptions{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: "app",
+		Command:   []string{"sh"},
+		Stdout:    stdout,
+	})
+	assert.NilError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Equal(t, len(lines), 3, "Unexpected output %s", stdout.String())
+	assert.Equal(t, lines[1], "dev")
+	assert.Equal(t, lines[2], "current-context: dev")
+	_, err = os.Stat(lines[0])
+	assert.Assert(t, os.IsNotExist(err), "Expected the kubeconfig %s to be removed", lines[0])
+}
@@ -0,0 +1,829 @@
+package terminal
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/remotecommand"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// RestartUnlimited can be used as TerminalOptions.Restart to restart the terminal without limit
+const RestartUnlimited = -1
+
+// DefaultCloseGracePeriod is the time a session gets to flush its output once the terminal is closed,
+// sessions are closed immediately by default
+const DefaultCloseGracePeriod = time.Duration(0)
+
+// TerminalOptions holds the options for starting a terminal
+type TerminalOptions struct {
+	// Command is the command to execute in the container. Only used by
+	// StartTerminalFromCMDWithOptions, StartTerminalWithOptions uses the
+	// command of the dev container instead.
+	Command []string
+
+	// TTY allocates a tty for the command. Only used by StartTerminalFromCMDWithOptions.
+	TTY bool
+
+	// ForceTTY allocates a tty even if stdin is not a terminal, e.g. for expect scripts that pipe their input.
+	// The output might be garbled if the remote shell detects that its input isn't a terminal. Stdout has to be
+	// set and support raw mode if it is a terminal. Not supported with native kubectl. Only used by
+	// StartTerminalFromCMDWithOptions.
+	ForceTTY bool
+
+	// Cols and Rows are the fixed terminal size used if stdin is not a terminal. If both are set, a tty is
+	// allocated even for non-interactive stdin, which allows to run programs that require a terminal size.
+	Cols uint16
+	Rows uint16
+
+	// StdinRateLimit limits the bytes per second sent from stdin to the container, e.g. to not overwhelm
+	// slow links with a huge paste. Zero means unlimited. Not supported with native kubectl.
+	StdinRateLimit int64
+
+	// TerminalSizeQueue reports the terminal size if stdin is not a terminal, e.g. the size of a web frontend.
+	// It takes precedence over Cols and Rows and also allocates a tty for non-interactive stdin.
+	TerminalSizeQueue remotecommand.TerminalSizeQueue
+
+	// DisableTTYFallback tells DevSpace to fail instead of retrying without a tty once
+	// if the tty of the session cannot be negotiated, e.g. because of a proxy
+	DisableTTYFallback bool
+
+	// UseNativeKubectl executes the command with the kubectl binary found in PATH instead of the builtin
+	// SPDY client, e.g. because a proxy rejects the SPDY connection. If kubectl cannot be found, the
+	// builtin client is used instead.
+	UseNativeKubectl bool
+
+	// RequireNativeKubectl fails instead of falling back to the builtin client if kubectl cannot be found
+	RequireNativeKubectl bool
+
+	// HTTPProxy is the proxy the session is connected through instead of the proxy from the
+	// HTTPS_PROXY and HTTP_PROXY environment variables, if non-empty
+	HTTPProxy string
+
+	// NoProxy is a comma separated list of hosts that are not connected through the proxy instead
+	// of the hosts from the NO_PROXY environment variable, if non-empty
+	NoProxy string
+
+	// CommandValidator is consulted by StartTerminalFromCMDWithOptions with the command before anything is
+	// executed, e.g. to enforce an allowlist of commands in shared environments. If it returns an error
+	// the terminal is not opened and the error is returned. Nil allows every command.
+	CommandValidator CommandValidator
+
+	// PreExecHook is a local command that is executed before the container is selected, e.g. to
+	// log in to a registry. Its output is written to Stderr and the terminal is not opened if it
+	// fails. Only used by StartTerminalFromCMDWithOptions and not executed again on restarts.
+	PreExecHook []string
+
+	// SubResource is the sub resource used to connect to the container, defaults to kubectl.SubResourceExec.
+	// If kubectl.SubResourceAttach is used, Command has to be empty. StartTerminalWithOptions ignores the
+	// command and screen of the dev container instead.
+	SubResource kubectl.SubResource
+
+	// BannerColor is the ansi style of the pod and container name in the banner that is
+	// printed when the terminal is opened, defaults to DefaultBannerColor
+	BannerColor string
+
+	// Banner is a message that is printed to Stdout once the first session is connected, before its
+	// first output. It is only printed if Stdout is a terminal. See renderBanner for the supported
+	// escape sequences and variables.
+	Banner string
+
+	// bannerOnce prints the Banner only once, shared across restarts
+	bannerOnce *sync.Once
+
+	// CheckRBAC checks with a SelfSubjectAccessReview if the user is allowed to exec into the selected
+	// pod before connecting to it and returns an *ExecPermissionError if not, which is not restarted
+	CheckRBAC bool
+
+	// WarnOnStaleBuild prints a warning to Stderr before opening the shell if the container runs a different
+	// tag of an image than the last one built by DevSpace, e.g. because the pods haven't been redeployed yet
+	WarnOnStaleBuild bool
+
+	// KubeClient is the kube client used to select and connect to the container instead of the kube
+	// client of the context, e.g. to open terminals to different clusters from the same process. It takes
+	// precedence over KubeContext and the kubeContext of the dev container.
+	KubeClient kubectl.Client
+
+	// KubeContext is the kube context used to select and connect to the container instead of the current
+	// context of the kube client, if non-empty. It is ignored if KubeClient is set. StartTerminalWithOptions
+	// uses the kubeContext of the dev container instead if set. After switching, the container is selected in
+	// NamespaceOverride or the default namespace of the kube context if NamespaceOverride is empty.
+	KubeContext string
+
+	// WaitForRunning waits until a crash-looping container runs in between its restarts before opening the
+	// terminal, instead of returning a *CrashLoopError, which is not restarted. StartTerminalWithOptions
+	// also waits if waitForRunning of the dev container is set. Ephemeral containers are not checked, as
+	// they can be used to debug a crash-looping container.
+	WaitForRunning bool
+
+	// NamespaceOverride is the namespace the container is selected in instead of the
+	// namespace of the selector or the current kube context, if non-empty
+	NamespaceOverride string
+
+	// AvoidScalingDownPods skips pods that are about to be removed, because the replica set owning
+	// them has more pods than desired, e.g. while a HorizontalPodAutoscaler scales down. An error is
+	// returned if all matching pods are being scaled down. Only used by StartTerminalFromCMDWithOptions.
+	AvoidScalingDownPods bool
+
+	// UseEphemeralContainer launches an ephemeral container alongside the selected container and
+	// opens the terminal to it instead. Only used by StartTerminalFromCMDWithOptions.
+	UseEphemeralContainer bool
+
+	// EphemeralContainer configures the ephemeral container if UseEphemeralContainer is enabled
+	EphemeralContainer EphemeralContainerOptions
+
+	// Screen starts the command within a screen session. Only used by StartTerminalFromCMDWithOptions.
+	Screen bool
+
+	// ScreenSession is the name of the screen session to create or connect to. Terminals
+	// with different session names to the same container run in separate screen sessions.
+	// StartTerminalWithOptions uses the screenSessionName of the dev container instead if set and
+	// defaults to DefaultScreenSessionName if both are empty.
+	ScreenSession string
+
+	// ScreenInstallTimeout is the maximum time to wait for screen to be installed in the
+	// container before falling back to a plain shell. Defaults to DefaultScreenInstallTimeout.
+	ScreenInstallTimeout time.Duration
+
+	// ScreenInstallScript is the script used to install screen in the container.
+	// Defaults to DefaultScreenInstallScript.
+	ScreenInstallScript string
+
+	// ScreenLogPath is the path of the screen log in the container, which defaults to a file per screen
+	// session under /tmp. Screen versions before 4.06 don't support a custom path and write screenlog.0
+	// to the working directory instead.
+	ScreenLogPath string
+
+	// NoPackageInstall tells DevSpace to never install packages in the container. Screen is only used
+	// if it is already present, otherwise the terminal silently falls back to a plain shell. This takes
+	// precedence over ScreenInstallScript.
+	NoPackageInstall bool
+
+	// DisableSessionFile tells DevSpace to not write the session metadata to a file starting with
+	// SessionFilePathPrefix in the container, e.g. for security-sensitive containers. Only used with kubectl.SubResourceExec.
+	DisableSessionFile bool
+
+	// SkipScreenrc tells DevSpace to not create a default .screenrc in the container, so that
+	// screen uses whatever configuration exists
+	SkipScreenrc bool
+
+	// OnScreenFallback is called if screen is not disabled, but cannot be used in the container, and the
+	// terminal falls back to a plain shell. reason is a *ScreenFallbackError with the output of the install
+	// script. It is called at most once per terminal, even if the terminal is restarted.
+	OnScreenFallback func(reason error)
+
+	// screenFallbackOnce makes sure OnScreenFallback is only called once, shared across restarts
+	screenFallbackOnce *sync.Once
+
+	// Restart is the number of times the terminal is restarted after it was
+	// interrupted. RestartUnlimited restarts the terminal without limit.
+	Restart int
+
+	// ShouldRestart overrides the default restart decision if set. It receives the error the
+	// terminal ended with as well as the number of restarts so far and returns whether the
+	// terminal should be restarted. If set, Restart is ignored.
+	ShouldRestart func(err error, restarts int) bool
+
+	// RestartJitter is the fraction of the delay before a restart the delay is randomly changed by in both
+	// directions, so that terminals to multiple replicas don't restart at the same time. Zero uses
+	// DefaultRestartJitter, a negative value disables the jitter.
+	RestartJitter float64
+
+	// restarts is the number of times the terminal was restarted already
+	restarts int
+
+	// CommandHintTimeout is the time after connecting in which an exit code of 126 or 127 prints a hint
+	// that the command is probably missing in the container. A later exit with these codes is most likely
+	// caused by a command the user typed in the shell. Zero uses DefaultCommandHintTimeout, a negative
+	// value disables the hint.
+	CommandHintTimeout time.Duration
+
+	// hintCommand is the program named in the hint, defaults to the first element of the command
+	hintCommand string
+
+	// ShowCommand logs the final command that is executed in the container before connecting to it,
+	// with values of environment variables whose names look like secrets redacted
+	ShowCommand bool
+
+	// redactEnv are environment variables of the command that are not part of EnvVars,
+	// e.g. the env of the dev container, and are redacted from the shown command
+	redactEnv map[string]string
+
+	// Quiet skips the summary that is printed after the terminal has ended
+	Quiet bool
+
+	// summary collects the summary printed after the terminal has ended, shared across restarts
+	summary *sessionSummary
+
+	// SessionStore records the session while it is open, so that sessions of crashed DevSpace
+	// processes can be found afterwards. Nil doesn't record the session.
+	SessionStore *SessionStore
+
+	// sessionRecord is the record of the session in the SessionStore, shared across restarts
+	sessionRecord *SessionRecord
+
+	// sessionBytesIn and sessionBytesOut count the bytes transferred by the sessions of the terminal
+	// for the SessionStore, shared across restarts
+	sessionBytesIn  *int64
+	sessionBytesOut *int64
+
+	// sessionID identifies the terminal in the events published to the event bus, shared across restarts
+	sessionID string
+
+	// ephemeralContainers are the ephemeral containers the sessions started, which are stopped once the terminal ended
+	ephemeralContainers *ephemeralContainerList
+
+	// podName and podUID identify the pod the last session connected to, passed on to restarts
+	podName string
+	podUID  types.UID
+
+	// FollowPodLifecycle reconnects the terminal to the pod that replaces the selected pod, e.g. after a
+	// rollout of the deployment, if the session ended because the pod was deleted. The replacement is found
+	// by the labels of the selected pod. Only used by StartTerminalFromCMDWithOptions.
+	FollowPodLifecycle bool
+
+	// MaxFollowRetries is the maximum number of times the terminal follows a replaced pod,
+	// defaults to DefaultMaxFollowRetries
+	MaxFollowRetries int
+
+	// followRetries is the number of times the terminal followed a replaced pod already
+	followRetries int
+
+	// containerEnv is the value of ContainerEnvVar when the terminal was started. It is cleared once
+	// the terminal followed a replaced pod, as the container it names doesn't exist anymore.
+	containerEnv string
+
+	// Resume continues the output of a restarted session instead of printing it from the start
+	// again. It only applies to sessions without tty and requires the command to support resuming,
+	// see ResumeCommand. Only stdout is resumed. Only used by StartTerminalFromCMDWithOptions.
+	Resume bool
+
+	// ResumeCommand computes the command that resumes the output of a restarted session,
+	// defaults to DefaultResumeCommand
+	ResumeCommand ResumeFunc
+
+	// resumeOffset is the amount of stdout bytes received by all sessions, shared across restarts
+	resumeOffset *int64
+
+	// MaxSessionDuration limits how long a single terminal session can stay connected.
+	// If the duration is exceeded, the session is closed and restarted. Zero means no limit.
+	MaxSessionDuration time.Duration
+
+	// CloseGracePeriod is the time a session gets to end on its own if the context is done, e.g. so that
+	// the final output of a recording isn't truncated. Stdin is closed first, so the remote command can
+	// exit on EOF, before the session is closed. Zero or a negative value closes the session immediately.
+	// Not supported with native kubectl.
+	CloseGracePeriod time.Duration
+
+	// stdinPump reads Stdin for all sessions of the terminal, so that restarts don't lose input
+	stdinPump *kubectl.StdinPump
+
+	// WatchdogInterval is the interval in which the duration of a connected session is logged
+	// to WatchdogLog. Zero disables the watchdog.
+	WatchdogInterval time.Duration
+
+	// WatchdogLog is the log the watchdog writes to, defaults to the terminal file log
+	WatchdogLog log.Logger
+
+	// TeeFile is a local file the output of the terminal is additionally written to
+	TeeFile string
+
+	// EventFormat enables machine-readable lifecycle events of the terminal sessions if set to
+	// EventFormatJSON. Human-readable log messages are printed regardless of this option.
+	EventFormat string
+
+	// EventWriter is the writer the lifecycle events are written to, separate from the terminal streams
+	EventWriter io.Writer
+
+	// StateChan receives the connection state of the terminal. States are dropped if the channel is not
+	// ready to receive them. The channel is closed after StateEnded was sent.
+	StateChan chan<- TerminalState
+
+	// OnSessionEnd is called synchronously after the exec stream of a session has returned
+	OnSessionEnd NotifyFunc
+
+	// SnapshotOnExit creates a checkpoint of the container if a session ends with a non-zero exit code,
+	// so that its state can be analyzed later. This is experimental and only enabled if the
+	// devspace_experimental_checkpoint feature flag is set, see CheckpointFeatureFlag.
+	SnapshotOnExit bool
+
+	// ForwardSockets are local UNIX sockets that are forwarded into the container while the
+	// session is connected. This requires socat to be present in the container.
+	ForwardSockets []SocketForward
+
+	// EnvVars are additional environment variables the command is executed with
+	EnvVars map[string]string
+
+	// InjectKubeconfig writes the kubeconfig of the current context to InjectedKubeconfigPathPrefix in the
+	// container and sets KUBECONFIG, so that tools like kubectl can access the same cluster. Exec
+	// credential plugins, auth providers and token files are removed, as they don't work inside the
+	// container. Only used by StartTerminalWithOptions and not supported in windows containers.
+	InjectKubeconfig bool
+
+	// injectedKubeconfigPath is the kubeconfig injected into the container for the session, which
+	// is removed once the session has ended
+	injectedKubeconfigPath string
+
+	// initScriptPath is the init script uploaded into the container for the session, which is
+	// removed once the session has ended
+	initScriptPath string
+
+	// WaitForPrompt delays the session_connected event and StateConnected until the shell printed its first
+	// prompt, e.g. for automation that types commands after connecting. The prompt is detected by a marker
+	// that PROMPT_COMMAND prints and that is removed from the output again, so only bash is supported and a
+	// PROMPT_COMMAND set by the container is replaced. Screen might not pass the marker through. If no prompt
+	// is seen within DefaultPromptTimeout, the session is treated as connected anyway. Disabled by default to
+	// leave the prompt untouched.
+	WaitForPrompt bool
+
+	// ValidateWorkDir checks that the work dir of the dev container exists before connecting to it and
+	// returns an error otherwise, as the command would be started in a different directory. Only used
+	// by StartTerminalWithOptions and not supported in windows containers.
+	ValidateWorkDir bool
+
+	// CreateWorkDir creates a missing work dir instead of returning an error. Only used if
+	// ValidateWorkDir is set.
+	CreateWorkDir bool
+
+	// ProxyToken is the token a connection to the proxy server has to send as its first line before the
+	// streams of the terminal. Empty generates a random token that is logged. Only used by
+	// StartTerminalProxyServer.
+	ProxyToken string
+
+	// ProxyAllowRemote allows the proxy server to listen on an address that is not a loopback address,
+	// which exposes the terminal to the network. Only used by StartTerminalProxyServer.
+	ProxyAllowRemote bool
+
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+}
+
+// NotifyFunc is called after a terminal session has ended. exitCode is -1 if the command
+// did not exit, e.g. because the connection was lost, err holds the reason then.
+type NotifyFunc func(podName, containerName string, exitCode int, err error)
+
+// CommandValidator returns an error if the command is not allowed to run in the container
+type CommandValidator func(command []string) error
+
+// OptionFunc modifies the given terminal options
+type OptionFunc func(*TerminalOptions)
+
+// NewTerminalOptions creates new terminal options with the given option functions applied
+func NewTerminalOptions(opts ...OptionFunc) *TerminalOptions {
+	options := &TerminalOptions{
+		TTY:         true,
+		SubResource: kubectl.SubResourceExec,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		Stdin:       os.Stdin,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+// DefaultTerminalOptions returns the options of NewTerminalOptions with all documented defaults set explicitly,
+// e.g. to show or adjust them. The zero values of these fields fall back to the same defaults, so both options
+// behave the same. Restart stays zero, which doesn't restart the terminal, and ScreenSession stays empty, as
+// only StartTerminalWithOptions defaults it to DefaultScreenSessionName.
+func DefaultTerminalOptions() *TerminalOptions {
+	options := NewTerminalOptions()
+	options.BannerColor = DefaultBannerColor
+	options.ScreenInstallTimeout = DefaultScreenInstallTimeout
+	options.ScreenInstallScript = DefaultScreenInstallScript
+	options.RestartJitter = DefaultRestartJitter
+	options.CommandHintTimeout = DefaultCommandHintTimeout
+	options.MaxFollowRetries = DefaultMaxFollowRetries
+	options.ResumeCommand = DefaultResumeCommand
+	options.CloseGracePeriod = DefaultCloseGracePeriod
+	return options
+}
+
+func WithCommand(command []string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.Command = command
+	}
+}
+
+func WithForceTTY(forceTTY bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.ForceTTY = forceTTY
+	}
+}
+
+func WithTTY(tty bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.TTY = tty
+	}
+}
+
+func WithSize(cols, rows uint16) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.Cols = cols
+		options.Rows = rows
+	}
+}
+
+func WithShowCommand(showCommand bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.ShowCommand = showCommand
+	}
+}
+
+func WithStdinRateLimit(bytesPerSecond int64) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.StdinRateLimit = bytesPerSecond
+	}
+}
+
+func WithTerminalSizeQueue(sizeQueue remotecommand.TerminalSizeQueue) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.TerminalSizeQueue = sizeQueue
+	}
+}
+
+func WithNativeKubectl(require bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.UseNativeKubectl = true
+		options.RequireNativeKubectl = require
+	}
+}
+
+func WithProxy(httpProxy, noProxy string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.HTTPProxy = httpProxy
+		options.NoProxy = noProxy
+	}
+}
+
+func WithPreExecHook(command ...string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.PreExecHook = command
+	}
+}
+
+func WithSubResource(subResource kubectl.SubResource) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.SubResource = subResource
+	}
+}
+
+func WithCheckRBAC(checkRBAC bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.CheckRBAC = checkRBAC
+	}
+}
+
+func WithQuiet(quiet bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.Quiet = quiet
+	}
+}
+
+func WithWarnOnStaleBuild(warnOnStaleBuild bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.WarnOnStaleBuild = warnOnStaleBuild
+	}
+}
+
+func WithKubeClient(client kubectl.Client) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.KubeClient = client
+	}
+}
+
+func WithKubeContext(kubeContext string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.KubeContext = kubeContext
+	}
+}
+
+func WithWaitForRunning(waitForRunning bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.WaitForRunning = waitForRunning
+	}
+}
+
+func WithSessionStore(store *SessionStore) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.SessionStore = store
+	}
+}
+
+func WithNamespaceOverride(namespace string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.NamespaceOverride = namespace
+	}
+}
+
+func WithAvoidScalingDownPods(avoidScalingDownPods bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.AvoidScalingDownPods = avoidScalingDownPods
+	}
+}
+
+func WithEphemeralContainer(ephemeralContainer EphemeralContainerOptions) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.UseEphemeralContainer = true
+		options.EphemeralContainer = ephemeralContainer
+	}
+}
+
+func WithScreen(screen bool, screenSession string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.Screen = screen
+		options.ScreenSession = screenSession
+	}
+}
+
+func WithScreenSession(screenSession string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.ScreenSession = screenSession
+	}
+}
+
+func WithDisableSessionFile(disableSessionFile bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.DisableSessionFile = disableSessionFile
+	}
+}
+
+func WithScreenLogPath(screenLogPath string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.ScreenLogPath = screenLogPath
+	}
+}
+
+func WithOnScreenFallback(onScreenFallback func(reason error)) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.OnScreenFallback = onScreenFallback
+	}
+}
+
+func WithRestart(n int) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.Restart = n
+	}
+}
+
+func WithRestartJitter(fraction float64) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.RestartJitter = fraction
+	}
+}
+
+func WithCommandHintTimeout(timeout time.Duration) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.CommandHintTimeout = timeout
+	}
+}
+
+func WithShouldRestart(shouldRestart func(err error, restarts int) bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.ShouldRestart = shouldRestart
+	}
+}
+
+func WithResume(resumeCommand ResumeFunc) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.Resume = true
+		options.ResumeCommand = resumeCommand
+	}
+}
+
+func WithFollowPodLifecycle(maxFollowRetries int) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.FollowPodLifecycle = true
+		options.MaxFollowRetries = maxFollowRetries
+	}
+}
+
+func WithMaxSessionDuration(duration time.Duration) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.MaxSessionDuration = duration
+	}
+}
+
+func WithCloseGracePeriod(gracePeriod time.Duration) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.CloseGracePeriod = gracePeriod
+	}
+}
+
+func WithWatchdog(interval time.Duration, logger log.Logger) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.WatchdogInterval = interval
+		options.WatchdogLog = logger
+	}
+}
+
+func WithTeeFile(path string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.TeeFile = path
+	}
+}
+
+func WithEvents(eventFormat string, eventWriter io.Writer) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.EventFormat = eventFormat
+		options.EventWriter = eventWriter
+	}
+}
+
+func WithStateChan(stateChan chan<- TerminalState) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.StateChan = stateChan
+	}
+}
+
+func WithCommandValidator(commandValidator CommandValidator) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.CommandValidator = commandValidator
+	}
+}
+
+func WithSnapshotOnExit(snapshotOnExit bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.SnapshotOnExit = snapshotOnExit
+	}
+}
+
+func WithOnSessionEnd(onSessionEnd NotifyFunc) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.OnSessionEnd = onSessionEnd
+	}
+}
+
+func WithForwardSockets(forwardSockets ...SocketForward) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.ForwardSockets = forwardSockets
+	}
+}
+
+func WithInjectKubeconfig(injectKubeconfig bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.InjectKubeconfig = injectKubeconfig
+	}
+}
+
+func WithWaitForPrompt(waitForPrompt bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.WaitForPrompt = waitForPrompt
+	}
+}
+
+func WithValidateWorkDir(validateWorkDir bool, createWorkDir bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.ValidateWorkDir = validateWorkDir
+		options.CreateWorkDir = createWorkDir
+	}
+}
+
+func WithEnvVars(envVars map[string]string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.EnvVars = envVars
+	}
+}
+
+func WithProxyToken(token string) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.ProxyToken = token
+	}
+}
+
+func WithProxyAllowRemote(allowRemote bool) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.ProxyAllowRemote = allowRemote
+	}
+}
+
+func WithStreams(stdout io.Writer, stderr io.Writer, stdin io.Reader) OptionFunc {
+	return func(options *TerminalOptions) {
+		options.Stdout = stdout
+		options.Stderr = stderr
+		options.Stdin = stdin
+	}
+}
+
+// namespace returns the namespace the container is selected in
+func (o *TerminalOptions) namespace(ctx devspacecontext.Context) string {
+	if o.NamespaceOverride != "" {
+		return o.NamespaceOverride
+	}
+
+	return ctx.KubeClient().Namespace()
+}
+
+// shouldRestart decides if the terminal should be restarted after it ended with err
+func (o *TerminalOptions) shouldRestart(err error) bool {
+	// restarting wouldn't help if we are not allowed to exec into the container or it is
+	// crash-looping, while a pod that is gone or terminating is replaced by a new one
+	permissionErr := &ExecPermissionError{}
+	crashLoopErr := &CrashLoopError{}
+	if kerrors.IsForbidden(err) || errors.As(err, &permissionErr) || errors.As(err, &crashLoopErr) {
+		return false
+	} else if o.ShouldRestart != nil {
+		return o.ShouldRestart(err, o.restarts)
+	} else if o.Restart == 0 {
+		return false
+	}
+
+	if exitError, ok := err.(kubectlExec.CodeExitError); ok {
+		// Expected exit codes are (https://shapeshed.com/unix-exit-codes/):
+		// 1 - Catchall for general errors
+		// 2 - Misuse of shell builtins (according to Bash documentation)
+		// 126 - Command invoked cannot execute
+		// 127 - “command not found”
+		// 128 - Invalid argument to exit
+		// 130 - Script terminated by Control-C
+		return IsUnexpectedExitCode(exitError.Code)
+	}
+
+	return true
+}
+
+// screenFallback notifies OnScreenFallback about the reason screen cannot be used, if it wasn't notified yet
+func (o *TerminalOptions) screenFallback(reason error) {
+	if o.OnScreenFallback == nil {
+		return
+	}
+
+	o.screenFallbackOnce.Do(func() {
+		o.OnScreenFallback(reason)
+	})
+}
+
+// closeGracePeriod returns the grace period of the exec stream, which is zero if disabled
+func (o *TerminalOptions) closeGracePeriod() time.Duration {
+	if o.CloseGracePeriod < 0 {
+		return 0
+	}
+
+	return o.CloseGracePeriod
+}
+
+// requestID returns the request id the exec requests of the terminal are traced with, which is the
+// request id of the context or the session id if none is set
+func (o *TerminalOptions) requestID(ctx devspacecontext.Context) string {
+	if id := devspacecontext.RequestIDFromContext(ctx); id != "" {
+		return id
+	}
+
+	return o.sessionID
+}
+
+// shouldFollow decides if the terminal should follow the replacement of its pod
+func (o *TerminalOptions) shouldFollow() bool {
+	if !o.FollowPodLifecycle {
+		return false
+	}
+
+	maxFollowRetries := o.MaxFollowRetries
+	if maxFollowRetries <= 0 {
+		maxFollowRetries = DefaultMaxFollowRetries
+	}
+
+	return o.followRetries < maxFollowRetries
+}
+
+// followed returns the options to use after the terminal followed its pod, which doesn't count as restart
+func (o *TerminalOptions) followed() *TerminalOptions {
+	next := o.restarted()
+	next.Restart = o.Restart
+	next.followRetries++
+	next.containerEnv = ""
+	return next
+}
+
+// restarted returns the options to use for the next restart
+func (o *TerminalOptions) restarted() *TerminalOptions {
+	next := *o
+	next.restarts++
+	if next.Restart > 0 {
+		next.Restart--
+	}
+
+	return &next
+}
+
+// trackPod remembers the pod a session connects to and tells the user if the pod was recreated with the
+// same name since the last session, e.g. by a statefulset, as its state like files or processes is gone
+func (o *TerminalOptions) trackPod(ctx devspacecontext.Context, pod *corev1.Pod) {
+	if o.podName == pod.Name && o.podUID != "" && o.podUID != pod.UID {
+		ctx.Log().Infof("Pod %s was recreated, connecting to new instance", pod.Name)
+	}
+
+	o.podName, o.podUID = pod.Name, pod.UID
+}
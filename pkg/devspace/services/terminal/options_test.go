@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+func TestNewTerminalOptions(t *testing.T) {
+	options := NewTerminalOptions()
+	assert.Equal(t, options.TTY, true, "Expected tty to be enabled by default")
+	assert.Equal(t, options.Stdout, os.Stdout)
+	assert.Equal(t, options.Restart, 0)
+
+	options = NewTerminalOptions(
+		WithRestart(2),
+		WithTeeFile("out.log"),
+		WithEnvVars(map[string]string{"FOO": "bar"}),
+		WithTTY(false),
+	)
+	assert.Equal(t, options.Restart, 2)
+	assert.Equal(t, options.TeeFile, "out.log")
+	assert.DeepEqual(t, options.EnvVars, map[string]string{"FOO": "bar"})
+	assert.Equal(t, options.TTY, false)
+}
+
+func TestDefaultTerminalOptions(t *testing.T) {
+	defaults := DefaultTerminalOptions()
+	zero := NewTerminalOptions()
+	assert.Equal(t, defaults.TTY, zero.TTY)
+	assert.Equal(t, defaults.SubResource, zero.SubResource)
+	assert.Equal(t, defaults.Restart, zero.Restart)
+	assert.Equal(t, defaults.ScreenSession, zero.ScreenSession)
+	assert.Equal(t, defaults.closeGracePeriod(), zero.closeGracePeriod())
+
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}},
+		Container: &corev1.Container{Name: "app"},
+	}
+	assert.Equal(t, banner(io.Discard, defaults.BannerColor, container), banner(io.Discard, zero.BannerColor, container))
+
+	exitError := kubectlExec.CodeExitError{Err: fmt.Errorf("exit 127"), Code: 127}
+	elapsed := DefaultCommandHintTimeout - time.Second
+	assert.Equal(t, commandHint(exitError, elapsed, defaults.CommandHintTimeout, "mytool", "app"), commandHint(exitError, elapsed, zero.CommandHintTimeout, "mytool", "app"))
+
+	offset := int64(10)
+	defaults.resumeOffset, zero.resumeOffset = &offset, &offset
+	assert.DeepEqual(t, defaults.resumeCommand([]string{"sh"}), zero.resumeCommand([]string{"sh"}))
+
+	defaults.FollowPodLifecycle, zero.FollowPodLifecycle = true, true
+	defaults.followRetries, zero.followRetries = DefaultMaxFollowRetries-1, DefaultMaxFollowRetries-1
+	assert.Equal(t, defaults.shouldFollow(), zero.shouldFollow())
+	defaults.followRetries, zero.followRetries = DefaultMaxFollowRetries, DefaultMaxFollowRetries
+	assert.Equal(t, defaults.shouldFollow(), zero.shouldFollow())
+}
+
+func TestShouldRestart(t *testing.T) {
+	lostConnection := fmt.Errorf("lost connection")
+	options := NewTerminalOptions(WithRestart(2))
+	assert.Equal(t, options.shouldRestart(lostConnection), true)
+	assert.Equal(t, options.shouldRestart(kubectlExec.CodeExitError{Code: 1}), false)
+	assert.Equal(t, options.shouldRestart(kubectlExec.CodeExitError{Code: 137}), true)
+
+	next := options.restarted().restarted()
+	assert.Equal(t, next.Restart, 0)
+	assert.Equal(t, next.shouldRestart(lostConnection), false)
+
+	options = NewTerminalOptions(WithRestart(RestartUnlimited))
+	assert.Equal(t, options.restarted().Restart, RestartUnlimited)
+
+	// the hook overrides the default decision and receives the restart count
+	options = NewTerminalOptions(WithShouldRestart(func(err error, restarts int) bool {
+		return restarts < 1
+	}))
+	assert.Equal(t, options.shouldRestart(kubectlExec.CodeExitError{Code: 1}), true)
+	assert.Equal(t, options.restarted().shouldRestart(lostConnection), false)
+}
+
+func TestWithEnvVars(t *testing.T) {
+	command := withEnvVars([]string{"sh"}, map[string]string{"B": "2", "A": "1"})
+	assert.DeepEqual(t, command, []string{"env", "A=1", "B=2", "sh"})
+	assert.DeepEqual(t, withEnvVars([]string{"sh"}, nil), []string{"sh"})
+}
+
+func TestCloseGracePeriod(t *testing.T) {
+	assert.Equal(t, NewTerminalOptions().closeGracePeriod(), DefaultCloseGracePeriod)
+	assert.Equal(t, NewTerminalOptions(WithCloseGracePeriod(time.Second*5)).closeGracePeriod(), time.Second*5)
+	assert.Equal(t, NewTerminalOptions(WithCloseGracePeriod(-1)).closeGracePeriod(), time.Duration(0))
+}
@@ -0,0 +1,382 @@
+package terminal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/randutil"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultPoolMaxIdle is the default amount of idle sessions a terminal pool keeps open
+const DefaultPoolMaxIdle = 4
+
+// DefaultPoolIdleTimeout is the default duration after which an idle session is closed
+const DefaultPoolIdleTimeout = time.Minute * 5
+
+// poolScreenSessionPrefix is the prefix of the screen sessions pooled shells run in
+const poolScreenSessionPrefix = "devspace-pool-"
+
+// poolShellScript starts the shell of a pooled session in its screen session. The shell doesn't echo its
+// input and, as its stderr is no terminal, is not interactive, so that it doesn't print prompts. Commands
+// redirect their stderr to stdout themselves.
+const poolShellScript = "stty -echo -icanon 2>/dev/null; exec sh 2>/dev/null"
+
+// poolTerminalCols and poolTerminalRows are the terminal size of pooled sessions in a screen
+// session, which is wide enough that screen doesn't wrap the output of commands
+const (
+	poolTerminalCols = 4096
+	poolTerminalRows = 100
+)
+
+// TerminalSession is a long running shell inside a container that executes
+// multiple commands over a single exec connection
+type TerminalSession struct {
+	Pod       *corev1.Pod
+	Container string
+
+	client kubectl.Client
+
+	// screenSession is the screen session the shell runs in, if any. The shell survives a lost
+	// connection in its screen session, so that the session can be reattached.
+	screenSession string
+	logPath       string
+
+	stdin  *io.PipeWriter
+	stdout *bufio.Reader
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	lastUsed time.Time
+	mutex    sync.Mutex
+
+	// bytesIn, bytesOut and lastPingLatency are updated atomically and returned by Stats
+	bytesIn         int64
+	bytesOut        int64
+	lastPingLatency int64
+}
+
+// TerminalPool reuses exec connections across multiple short-lived commands to the
+// same container to avoid the overhead of opening a new connection for each command
+type TerminalPool struct {
+	client kubectl.Client
+
+	// MaxIdle is the maximum number of idle sessions that are kept open
+	MaxIdle int
+
+	// IdleTimeout is the duration after which an idle session is closed
+	IdleTimeout time.Duration
+
+	// DisableScreen starts the shells in the container directly instead of in a screen session. Shells in a
+	// screen session keep their state, e.g. the working directory, if the connection is lost and are reattached
+	// by the next Acquire. Sessions fall back to a plain shell if screen can't be installed in the container.
+	DisableScreen bool
+
+	idle  map[string][]*TerminalSession
+	mutex sync.Mutex
+}
+
+// NewTerminalPool creates a new terminal pool with the default limits
+func NewTerminalPool(client kubectl.Client) *TerminalPool {
+	return &TerminalPool{
+		client:      client,
+		MaxIdle:     DefaultPoolMaxIdle,
+		IdleTimeout: DefaultPoolIdleTimeout,
+		idle:        map[string][]*TerminalSession{},
+	}
+}
+
+// Acquire returns an idle session to the given container or opens a new one
+func (p *TerminalPool) Acquire(ctx context.Context, pod *corev1.Pod, container string) (*TerminalSession, error) {
+	p.mutex.Lock()
+	p.evictExpired()
+	key := poolKey(pod, container)
+	screenSession := ""
+	for len(p.idle[key]) > 0 {
+		session := p.idle[key][len(p.idle[key])-1]
+		p.idle[key] = p.idle[key][:len(p.idle[key])-1]
+		if session.lost() {
+			// the shell is still running in its screen session, so the new connection reattaches to it
+			screenSession = session.screenSession
+			session.cancel()
+			break
+		} else if !session.closed() {
+			p.mutex.Unlock()
+			return session, nil
+		}
+	}
+	p.mutex.Unlock()
+
+	session := p.startSession(ctx, pod, container, screenSession)
+	_, err := session.Ping(ctx)
+	if err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "start session")
+	}
+
+	return session, nil
+}
+
+// Release returns the session to the pool, so that it can be reused by a later Acquire
+func (p *TerminalPool) Release(session *TerminalSession) {
+	if session.closed() {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.evictExpired()
+	idle := 0
+	for _, sessions := range p.idle {
+		idle += len(sessions)
+	}
+	if idle >= p.MaxIdle {
+		session.Close()
+		return
+	}
+
+	key := poolKey(session.Pod, session.Container)
+	session.lastUsed = time.Now()
+	p.idle[key] = append(p.idle[key], session)
+}
+
+// Close closes all idle sessions of the pool
+func (p *TerminalPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, sessions := range p.idle {
+		for _, session := range sessions {
+			session.Close()
+		}
+	}
+	p.idle = map[string][]*TerminalSession{}
+}
+
+func (p *TerminalPool) evictExpired() {
+	for key, sessions := range p.idle {
+		alive := []*TerminalSession{}
+		for _, session := range sessions {
+			if (session.closed() && !session.lost()) || (p.IdleTimeout > 0 && time.Since(session.lastUsed) > p.IdleTimeout) {
+				session.Close()
+				continue
+			}
+
+			alive = append(alive, session)
+		}
+
+		if len(alive) == 0 {
+			delete(p.idle, key)
+		} else {
+			p.idle[key] = alive
+		}
+	}
+}
+
+func poolKey(pod *corev1.Pod, container string) string {
+	return pod.Namespace + "/" + pod.Name + "/" + container
+}
+
+// startSession starts a shell in the container. Unless screen is disabled, the shell runs in the given screen
+// session, which is created if it doesn't exist yet or empty.
+func (p *TerminalPool) startSession(ctx context.Context, pod *corev1.Pod, container string, screenSession string) *TerminalSession {
+	command := []string{"sh"}
+	logPath := ""
+	if !p.DisableScreen {
+		if screenSession == "" {
+			screenSession = poolScreenSessionPrefix + strings.ToLower(randutil.GenerateRandomString(12))
+		}
+
+		selected := &selector.SelectedPodContainer{Pod: pod, Container: &corev1.Container{Name: container}}
+		options := NewTerminalOptions(WithScreenSession(screenSession))
+		err := installScreen(devspacecontext.NewContext(ctx, nil, log.Discard).WithKubeClient(p.client), selected, options)
+		if err != nil {
+			screenSession = ""
+		} else {
+			command, logPath = screenCommand(selected, options, []string{"sh", "-c", poolShellScript})
+		}
+	}
+
+	return startSession(p.client, pod, container, command, screenSession, logPath)
+}
+
+func startSession(client kubectl.Client, pod *corev1.Pod, container string, command []string, screenSession string, logPath string) *TerminalSession {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	session := &TerminalSession{
+		Pod:           pod,
+		Container:     container,
+		client:        client,
+		screenSession: screenSession,
+		logPath:       logPath,
+		stdin:         stdinWriter,
+		ctx:           cancelCtx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+
+	// stdout is counted before it is parsed, so the stats are up to date once Exec returns
+	session.stdout = bufio.NewReader(&kubectl.CountingReader{Reader: stdoutReader, Count: &session.bytesOut})
+
+	execOptions := &kubectl.ExecStreamOptions{
+		Pod:         pod,
+		Container:   container,
+		Command:     command,
+		Stdin:       stdinReader,
+		Stdout:      stdoutWriter,
+		SubResource: kubectl.SubResourceExec,
+	}
+	if screenSession != "" {
+		// screen needs a terminal, which isn't there locally
+		execOptions.TTY = true
+		execOptions.ForceTTY = true
+		execOptions.TerminalSizeQueue = newFixedSizeQueue(poolTerminalCols, poolTerminalRows)
+	}
+
+	go func() {
+		err := client.ExecStream(cancelCtx, execOptions)
+		if err == nil {
+			err = fmt.Errorf("session closed")
+		}
+
+		_ = stdoutWriter.CloseWithError(err)
+		_ = stdinReader.CloseWithError(err)
+		close(session.done)
+	}()
+
+	return session
+}
+
+// Exec executes the command in the session and returns its combined output and exit code.
+// If the context is cancelled while the command is running the session is closed.
+func (s *TerminalSession) Exec(ctx context.Context, command string) ([]byte, int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed() {
+		return nil, 0, fmt.Errorf("session closed")
+	}
+
+	// the marker tells us where the output of the command ends and contains its exit code
+	marker := "DEVSPACE_" + randutil.GenerateRandomString(16)
+	_, err := fmt.Fprintf(&kubectl.CountingWriter{Writer: s.stdin, Count: &s.bytesIn}, "{\n%s\n} </dev/null 2>&1; printf '\\n%s %%d\\n' $?\n", command, marker)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	type result struct {
+		output   []byte
+		exitCode int
+		err      error
+	}
+	resultChan := make(chan result, 1)
+	go func() {
+		output := &strings.Builder{}
+		for {
+			line, err := s.stdout.ReadString('\n')
+			if err != nil {
+				resultChan <- result{err: err}
+				return
+			} else if s.screenSession != "" {
+				// screen renders the output for a terminal
+				line = stripANSI(strings.ReplaceAll(line, "\r", ""))
+			}
+			if strings.HasPrefix(line, marker+" ") {
+				exitCode, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, marker+" ")))
+				resultChan <- result{output: []byte(strings.TrimSuffix(output.String(), "\n")), exitCode: exitCode, err: err}
+				return
+			}
+
+			output.WriteString(line)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		s.Close()
+		<-resultChan
+		return nil, 0, ctx.Err()
+	case r := <-resultChan:
+		if r.err != nil {
+			s.Close()
+		}
+
+		return r.output, r.exitCode, r.err
+	}
+}
+
+// Close closes the exec connection of the session and ends its screen session
+func (s *TerminalSession) Close() {
+	if s.screenSession != "" && s.ctx.Err() == nil {
+		select {
+		case <-s.done:
+			go s.quitScreen()
+		default:
+			// the shell ends the screen session once it exits
+			_, err := fmt.Fprintf(s.stdin, "%s\n", s.exitScript())
+			if err != nil {
+				go s.quitScreen()
+			}
+		}
+	}
+
+	s.cancel()
+	_ = s.stdin.Close()
+}
+
+// quitScreen ends the screen session of a session that lost its connection
+func (s *TerminalSession) quitScreen() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	_, _, _ = s.client.ExecBuffered(ctx, s.Pod, s.Container, []string{"sh", "-c", "screen -S " + shellquote.Join(s.screenSession) + " -X quit; " + s.exitScript()}, nil)
+}
+
+// exitScript removes the screen log of the session and exits the shell
+func (s *TerminalSession) exitScript() string {
+	if s.logPath == "" {
+		return "exit"
+	}
+
+	return "rm -f " + shellquote.Join(s.logPath) + "; exit"
+}
+
+// lost returns true if the shell of the session still runs in its screen session, but the connection was lost
+func (s *TerminalSession) lost() bool {
+	if s.screenSession == "" || s.ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *TerminalSession) closed() bool {
+	select {
+	case <-s.ctx.Done():
+		return true
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
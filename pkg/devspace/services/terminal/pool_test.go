@@ -0,0 +1,201 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// localExecClient executes the exec stream commands on the local machine
+type localExecClient struct {
+	kubectltesting.Client
+
+	execs int
+}
+
+func (c *localExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.execs++
+	cmd := exec.CommandContext(ctx, options.Command[0], options.Command[1:]...)
+	cmd.Stdin = options.Stdin
+	cmd.Stdout = options.Stdout
+	cmd.Stderr = options.Stderr
+	return cmd.Run()
+}
+
+func TestTerminalPool(t *testing.T) {
+	client := &localExecClient{}
+	pool := NewTerminalPool(client)
+	pool.DisableScreen = true
+	defer pool.Close()
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+	session, err := pool.Acquire(context.Background(), pod, "container")
+	assert.NilError(t, err)
+
+	output, exitCode, err := session.Exec(context.Background(), "echo hello; echo world")
+	assert.NilError(t, err)
+	assert.Equal(t, string(output), "hello\nworld\n")
+	assert.Equal(t, exitCode, 0)
+
+	output, exitCode, err = session.Exec(context.Background(), "printf partial; exit_code() { return 3; }; exit_code")
+	assert.NilError(t, err)
+	assert.Equal(t, string(output), "partial")
+	assert.Equal(t, exitCode, 3)
+
+	// a released session is reused
+	pool.Release(session)
+	reused, err := pool.Acquire(context.Background(), pod, "container")
+	assert.NilError(t, err)
+	assert.Equal(t, reused, session)
+	assert.Equal(t, client.execs, 1)
+
+	// a closed session is not reused
+	reused.Close()
+	pool.Release(reused)
+	session, err = pool.Acquire(context.Background(), pod, "container")
+	assert.NilError(t, err)
+	assert.Assert(t, session != reused)
+	assert.Equal(t, client.execs, 2)
+
+	// expired sessions are closed
+	pool.IdleTimeout = time.Millisecond
+	pool.Release(session)
+	time.Sleep(time.Millisecond * 10)
+	_, err = pool.Acquire(context.Background(), pod, "container")
+	assert.NilError(t, err)
+	assert.Equal(t, client.execs, 3)
+	assert.Equal(t, session.closed(), true)
+}
+
+// screenRenderer simulates the output of screen, which renders the output for a terminal
+type screenRenderer struct {
+	writer io.Writer
+	once   sync.Once
+}
+
+func (r *screenRenderer) Write(p []byte) (int, error) {
+	r.once.Do(func() {
+		_, _ = r.writer.Write([]byte("\x1b[H\x1b[J"))
+	})
+
+	_, err := r.writer.Write([]byte(strings.ReplaceAll(string(p), "\n", "\x1b[K\r\n")))
+	return len(p), err
+}
+
+// screenLocalExecClient executes the command wrapped in screen on the local machine
+type screenLocalExecClient struct {
+	kubectltesting.Client
+
+	mutex    sync.Mutex
+	commands [][]string
+	options  []*kubectl.ExecStreamOptions
+	cancel   context.CancelFunc
+	quit     chan []string
+}
+
+func (c *screenLocalExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.mutex.Lock()
+	c.commands = append(c.commands, options.Command)
+	c.options = append(c.options, options)
+	c.cancel = cancel
+	c.mutex.Unlock()
+
+	command := options.Command
+	for i, arg := range command {
+		if arg == "--" {
+			command = command[i+1:]
+			break
+		}
+	}
+
+	cmd := exec.CommandContext(cancelCtx, command[0], command[1:]...)
+	cmd.Stdout = &screenRenderer{writer: options.Stdout}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	// stdin is copied by us, so that the copying is abandoned once the connection is lost
+	go func() {
+		_, _ = io.Copy(stdin, options.Stdin)
+		_ = stdin.Close()
+	}()
+	return cmd.Run()
+}
+
+func (c *screenLocalExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	if strings.Contains(strings.Join(command, " "), "-X quit") {
+		c.quit <- command
+	}
+
+	return nil, nil, nil
+}
+
+// execStream returns the command and options of the i-th exec stream
+func (c *screenLocalExecClient) execStream(i int) ([]string, *kubectl.ExecStreamOptions) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.commands[i], c.options[i]
+}
+
+// loseConnection ends the current exec stream as if the connection was lost
+func (c *screenLocalExecClient) loseConnection() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.cancel()
+}
+
+func TestTerminalPoolScreen(t *testing.T) {
+	client := &screenLocalExecClient{quit: make(chan []string, 1)}
+	pool := NewTerminalPool(client)
+	defer pool.Close()
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "screen", Namespace: "default"}}
+	session, err := pool.Acquire(context.Background(), pod, "container")
+	assert.NilError(t, err)
+	assert.Assert(t, strings.HasPrefix(session.screenSession, poolScreenSessionPrefix), "Unexpected screen session %s", session.screenSession)
+	command, options := client.execStream(0)
+	assert.DeepEqual(t, command, []string{"screen", "-dRSqL", session.screenSession, "--", "sh", "-c", poolShellScript})
+	assert.Assert(t, options.TTY && options.ForceTTY, "Expected a tty for screen")
+
+	output, exitCode, err := session.Exec(context.Background(), "echo hello; echo world")
+	assert.NilError(t, err)
+	assert.Equal(t, string(output), "hello\nworld\n")
+	assert.Equal(t, exitCode, 0)
+
+	// an idle session that lost its connection is reattached to its screen session
+	pool.Release(session)
+	client.loseConnection()
+	<-session.done
+	reattached, err := pool.Acquire(context.Background(), pod, "container")
+	assert.NilError(t, err)
+	assert.Assert(t, reattached != session)
+	assert.Equal(t, reattached.screenSession, session.screenSession)
+	reattachCommand, _ := client.execStream(1)
+	assert.DeepEqual(t, reattachCommand, command)
+
+	// the screen session of a session that lost its connection is ended on close
+	client.loseConnection()
+	<-reattached.done
+	reattached.Close()
+	select {
+	case command := <-client.quit:
+		assert.DeepEqual(t, command, []string{"sh", "-c", "screen -S " + session.screenSession + " -X quit; exit"})
+	case <-time.After(time.Second * 5):
+		t.Fatal("Expected the screen session to be ended")
+	}
+}
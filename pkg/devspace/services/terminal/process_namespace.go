@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	"strings"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/mgutz/ansi"
+)
+
+// sharedProcessNamespacePromptCommand is the PROMPT_COMMAND that notes before every prompt that the processes
+// of all containers are visible. Unlike PS1 it isn't overwritten by the rc files of the shell, but only bash
+// runs it.
+const sharedProcessNamespacePromptCommand = "printf '(all processes) '"
+
+// checkSharedProcessNamespace checks if the pod of the container shares its process namespace
+// across containers and warns the user if that is not the case
+func checkSharedProcessNamespace(ctx devspacecontext.Context, container *selector.SelectedPodContainer) bool {
+	if container.Pod.Spec.ShareProcessNamespace == nil || !*container.Pod.Spec.ShareProcessNamespace {
+		ctx.Log().Warnf("Pod %s does not share its process namespace, so only processes of container %s will be visible. Set %s in the pod spec to see the processes of all containers", container.Pod.Name, container.Container.Name, ansi.Color("shareProcessNamespace: true", "white+b"))
+		return false
+	}
+
+	// with a shared process namespace the pause container becomes pid 1 and
+	// the processes of the other containers show up in /proc
+	stdout, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{
+		"sh",
+		"-c",
+		"cat /proc/1/cmdline",
+	}, nil)
+	if err != nil {
+		ctx.Log().Debugf("Error checking process namespace: %s %v", string(stderr), err)
+		ctx.Log().Warnf("Couldn't verify that the process namespace of pod %s is shared, processes of other containers might not be visible", container.Pod.Name)
+		return false
+	} else if !strings.Contains(string(stdout), "pause") {
+		ctx.Log().Warnf("Pod %s has shareProcessNamespace enabled, but the processes of other containers are not visible in container %s. Is the container runtime supporting process namespace sharing?", container.Pod.Name, container.Container.Name)
+		return false
+	}
+
+	ctx.Log().Debugf("Process namespace of pod %s is shared", container.Pod.Name)
+	return true
+}
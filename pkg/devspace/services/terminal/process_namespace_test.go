@@ -0,0 +1,129 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// processNamespaceExecClient answers the check of the process namespace with the command line of pid 1
+type processNamespaceExecClient struct {
+	recordingExecClient
+
+	cmdline string
+	err     error
+	checks  int
+}
+
+func (c *processNamespaceExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	if command[len(command)-1] != "cat /proc/1/cmdline" {
+		return nil, nil, nil
+	}
+
+	c.checks++
+	return []byte(c.cmdline), nil, c.err
+}
+
+type checkSharedProcessNamespaceTestCase struct {
+	name                  string
+	shareProcessNamespace *bool
+	cmdline               string
+	err                   error
+
+	expected       bool
+	expectedChecks int
+	expectedWarn   string
+}
+
+func TestCheckSharedProcessNamespace(t *testing.T) {
+	testCases := []checkSharedProcessNamespaceTestCase{
+		{
+			name:                  "Shared",
+			shareProcessNamespace: pointer.Bool(true),
+			cmdline:               "/pause\x00",
+			expected:              true,
+			expectedChecks:        1,
+		},
+		{
+			name:         "Not shared",
+			expectedWarn: "does not share its process namespace",
+		},
+		{
+			name:                  "Not supported by the runtime",
+			shareProcessNamespace: pointer.Bool(true),
+			cmdline:               "/usr/bin/node\x00server.js\x00",
+			expectedChecks:        1,
+			expectedWarn:          "Is the container runtime supporting process namespace sharing?",
+		},
+		{
+			name:                  "Check failed",
+			shareProcessNamespace: pointer.Bool(true),
+			err:                   fmt.Errorf("connection refused"),
+			expectedChecks:        1,
+			expectedWarn:          "Couldn't verify that the process namespace of pod pod is shared",
+		},
+	}
+
+	for _, testCase := range testCases {
+		client := &processNamespaceExecClient{cmdline: testCase.cmdline, err: testCase.err}
+		out := &bytes.Buffer{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+		container := &selector.SelectedPodContainer{
+			Pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+				Spec:       corev1.PodSpec{ShareProcessNamespace: testCase.shareProcessNamespace},
+			},
+			Container: &corev1.Container{Name: "app"},
+		}
+
+		shared := checkSharedProcessNamespace(ctx, container)
+		assert.Equal(t, shared, testCase.expected, "Unexpected result in test case %s", testCase.name)
+		assert.Equal(t, client.checks, testCase.expectedChecks, "Unexpected checks in test case %s", testCase.name)
+		if testCase.expectedWarn != "" {
+			assert.Assert(t, strings.Contains(out.String(), testCase.expectedWarn), "Expected warning in test case %s, got %s", testCase.name, out.String())
+		} else {
+			assert.Equal(t, out.String(), "", "Unexpected output in test case %s", testCase.name)
+		}
+	}
+}
+
+func TestStartTerminalShowAllProcesses(t *testing.T) {
+	client := &processNamespaceExecClient{cmdline: "/pause\x00"}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+			Spec:       corev1.PodSpec{ShareProcessNamespace: pointer.Bool(true)},
+		},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Command:            "bash",
+		Shell:              "sh",
+		ShowAllProcesses:   true,
+		DisableScreen:      true,
+		DisableSessionFile: true,
+	}}
+
+	// the note is printed by PROMPT_COMMAND, which rc files don't overwrite like PS1
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(strings.Join(client.options.Command, " "), "PROMPT_COMMAND="+sharedProcessNamespacePromptCommand), "Expected PROMPT_COMMAND, got %v", client.options.Command)
+	assert.Assert(t, !strings.Contains(strings.Join(client.options.Command, " "), "PS1="), "Unexpected PS1 in %v", client.options.Command)
+}
@@ -0,0 +1,132 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/randutil"
+)
+
+// DefaultPromptTimeout is the maximum time TerminalOptions.WaitForPrompt waits for the prompt of the shell,
+// after which the session is treated as connected anyway
+const DefaultPromptTimeout = time.Second * 10
+
+// promptTimeout is replaced in tests
+var promptTimeout = DefaultPromptTimeout
+
+// promptMarkerFormat is the marker that is printed by the shell before every prompt. It is an operating
+// system command escape sequence, which terminals ignore if they don't know it.
+const promptMarkerFormat = "\033]697;devspace-%s\007"
+
+// newPromptID returns a unique id for the prompt marker of a session
+func newPromptID() string {
+	return randutil.GenerateRandomString(12)
+}
+
+// promptMarker returns the marker that is printed before every prompt of the session with the id
+func promptMarker(id string) string {
+	return fmt.Sprintf(promptMarkerFormat, id)
+}
+
+// promptCommand returns the PROMPT_COMMAND that prints the marker, which is only run by bash. The escape
+// sequences are interpreted by printf, so that the command itself contains no control characters.
+func promptCommand(id string) string {
+	return fmt.Sprintf(`printf '\033]697;devspace-%s\007'`, id)
+}
+
+// promptWriter removes the prompt marker from the output of the session and closes ready the
+// first time it was found, which means that the shell is ready for input
+type promptWriter struct {
+	writer io.Writer
+	marker []byte
+
+	// pending is the end of the last write that could be the start of the marker
+	pending []byte
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+func newPromptWriter(writer io.Writer, marker string) *promptWriter {
+	return &promptWriter{
+		writer: writer,
+		marker: []byte(marker),
+		ready:  make(chan struct{}),
+	}
+}
+
+func (w *promptWriter) Write(p []byte) (int, error) {
+	data := append(w.pending, p...)
+	w.pending = nil
+	for {
+		index := bytes.Index(data, w.marker)
+		if index == -1 {
+			break
+		}
+
+		w.readyOnce.Do(func() { close(w.ready) })
+		if index > 0 {
+			_, err := w.writer.Write(data[:index])
+			if err != nil {
+				return 0, err
+			}
+		}
+		data = data[index+len(w.marker):]
+	}
+
+	// the marker might be split across writes, so a possible start of it is held back
+	keep := 0
+	for i := len(w.marker) - 1; i > 0; i-- {
+		if bytes.HasSuffix(data, w.marker[:i]) {
+			keep = i
+			break
+		}
+	}
+	if keep > 0 {
+		w.pending = append([]byte{}, data[len(data)-keep:]...)
+		data = data[:len(data)-keep]
+	}
+	if len(data) > 0 {
+		_, err := w.writer.Write(data)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// emitConnected emits the session_connected event. If prompt is set, the event is delayed until the marker was
+// found in the output or promptTimeout has passed. The returned function has to be called once the session has
+// ended, it skips a pending event so that it is never emitted after the session_ended event.
+func (o *TerminalOptions) emitConnected(ctx devspacecontext.Context, container *selector.SelectedPodContainer, prompt *promptWriter) func() {
+	if prompt == nil {
+		o.emitEvent(ctx, newEvent(EventSessionConnected, container.Pod.Name, container.Container.Name, nil))
+		return func() {}
+	}
+
+	ended := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		select {
+		case <-prompt.ready:
+		case <-time.After(promptTimeout):
+			ctx.Log().Debugf("The shell in container %s printed no prompt within %v, treating the session as connected", container.Container.Name, promptTimeout)
+		case <-ended:
+			return
+		}
+		o.emitEvent(ctx, newEvent(EventSessionConnected, container.Pod.Name, container.Container.Name, nil))
+	}()
+
+	return func() {
+		close(ended)
+		<-done
+	}
+}
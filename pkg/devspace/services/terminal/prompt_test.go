@@ -0,0 +1,169 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type promptWriterTestCase struct {
+	name   string
+	writes []string
+
+	expectedOutput string
+	expectedReady  bool
+}
+
+func TestPromptWriter(t *testing.T) {
+	marker := promptMarker("abc")
+	testCases := []promptWriterTestCase{
+		{
+			name:           "No marker",
+			writes:         []string{"hello ", "world"},
+			expectedOutput: "hello world",
+		},
+		{
+			name:           "Marker is removed",
+			writes:         []string{"motd\n" + marker + "$ ", "ls\n" + marker + "$ "},
+			expectedOutput: "motd\n$ ls\n$ ",
+			expectedReady:  true,
+		},
+		{
+			name:           "Marker split across writes",
+			writes:         []string{"motd\n" + marker[:4], marker[4:10], marker[10:] + "$ "},
+			expectedOutput: "motd\n$ ",
+			expectedReady:  true,
+		},
+		{
+			name:           "Other escape sequence",
+			writes:         []string{"\033]0;title\007", "$ "},
+			expectedOutput: "\033]0;title\007$ ",
+		},
+	}
+
+	for _, testCase := range testCases {
+		out := &bytes.Buffer{}
+		writer := newPromptWriter(out, marker)
+		for _, write := range testCase.writes {
+			n, err := writer.Write([]byte(write))
+			assert.NilError(t, err)
+			assert.Equal(t, n, len(write))
+		}
+
+		assert.Equal(t, out.String(), testCase.expectedOutput, "Unexpected output in test case %s", testCase.name)
+		ready := false
+		select {
+		case <-writer.ready:
+			ready = true
+		default:
+		}
+		assert.Equal(t, ready, testCase.expectedReady, "Unexpected ready in test case %s", testCase.name)
+	}
+}
+
+func TestPromptCommand(t *testing.T) {
+	assert.Equal(t, promptCommand("abc"), `printf '\033]697;devspace-abc\007'`)
+}
+
+// promptExecClient prints the prompt marker of the PROMPT_COMMAND after delay, like bash would
+type promptExecClient struct {
+	recordingExecClient
+
+	delay    time.Duration
+	noPrompt bool
+}
+
+var promptIDRegEx = regexp.MustCompile(`PROMPT_COMMAND=printf '\\033\]697;devspace-([a-zA-Z0-9]+)\\007'`)
+
+func (c *promptExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.options = options
+	match := promptIDRegEx.FindStringSubmatch(strings.Join(options.Command, " "))
+	if match == nil {
+		return nil
+	}
+
+	time.Sleep(c.delay)
+	if !c.noPrompt {
+		_, _ = options.Stdout.Write([]byte(promptMarker(match[1]) + "$ "))
+	}
+	time.Sleep(c.delay)
+	return nil
+}
+
+type waitForPromptTestCase struct {
+	name     string
+	delay    time.Duration
+	noPrompt bool
+	timeout  time.Duration
+
+	expectedEvents []EventType
+	expectedOutput string
+}
+
+func TestStartTerminalFromCMDWaitForPrompt(t *testing.T) {
+	testCases := []waitForPromptTestCase{
+		{
+			name:           "Prompt",
+			delay:          time.Millisecond * 50,
+			timeout:        time.Minute,
+			expectedEvents: []EventType{EventSessionStarted, EventSessionConnected, EventSessionEnded},
+			expectedOutput: "$ ",
+		},
+		{
+			name:           "No prompt within timeout",
+			delay:          time.Millisecond * 100,
+			noPrompt:       true,
+			timeout:        time.Millisecond * 10,
+			expectedEvents: []EventType{EventSessionStarted, EventSessionConnected, EventSessionEnded},
+		},
+		{
+			name:           "Session ends before prompt",
+			noPrompt:       true,
+			timeout:        time.Minute,
+			expectedEvents: []EventType{EventSessionStarted, EventSessionEnded},
+		},
+	}
+	defer func(timeout time.Duration) { promptTimeout = timeout }(promptTimeout)
+
+	for _, testCase := range testCases {
+		promptTimeout = testCase.timeout
+		client := &promptExecClient{delay: testCase.delay, noPrompt: testCase.noPrompt}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+			Container: &corev1.Container{Name: "app"},
+		}}
+
+		events := &bytes.Buffer{}
+		stdout := &bytes.Buffer{}
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"bash"}),
+			WithWaitForPrompt(true),
+			WithEvents(EventFormatJSON, events),
+			WithStreams(stdout, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err, "Unexpected error in test case %s", testCase.name)
+
+		types := []EventType{}
+		for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+			event := Event{}
+			assert.NilError(t, json.Unmarshal([]byte(line), &event))
+			types = append(types, event.Type)
+		}
+		assert.DeepEqual(t, types, testCase.expectedEvents)
+		assert.Equal(t, stdout.String(), testCase.expectedOutput, "Unexpected output in test case %s", testCase.name)
+	}
+}
@@ -0,0 +1,166 @@
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/pkg/errors"
+)
+
+// proxyTokenTimeout is the time a connection to the proxy server has to send the token
+var proxyTokenTimeout = time.Second * 10
+
+// StartTerminalProxyServer listens on listenAddr and bridges the first authenticated TCP connection to a
+// terminal like StartTerminalWithOptions, e.g. so that a CI orchestrator can forward the session over a
+// stable local port. A connection authenticates by sending the ProxyToken followed by a newline, other
+// connections are closed. Afterwards the connection carries the raw input and output of the terminal
+// without any framing. Only loopback addresses are allowed unless ProxyAllowRemote is set. The listener
+// is closed once a connection was accepted or the context is done and the terminal is closed once the
+// connection is closed.
+func StartTerminalProxyServer(
+	ctx devspacecontext.Context,
+	devContainer *latest.DevContainer,
+	selector targetselector.TargetSelector,
+	listenAddr string,
+	parent *tomb.Tomb,
+	options ...OptionFunc,
+) error {
+	terminalOptions := NewTerminalOptions(options...)
+	if !terminalOptions.ProxyAllowRemote {
+		err := checkLoopbackAddr(listenAddr)
+		if err != nil {
+			return err
+		}
+	}
+
+	token := terminalOptions.ProxyToken
+	if token == "" {
+		var err error
+		token, err = generateProxyToken()
+		if err != nil {
+			return err
+		}
+
+		ctx.Log().Infof("Terminal proxy token: %s", token)
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return errors.Wrapf(err, "listen on %s", listenAddr)
+	}
+	defer listener.Close()
+
+	// closing the listener unblocks Accept if the context is done before a connection was accepted
+	accepted := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Context().Done():
+			_ = listener.Close()
+		case <-accepted:
+		}
+	}()
+
+	ctx.Log().Infof("Waiting for a terminal connection on %s", listener.Addr())
+	var (
+		conn   net.Conn
+		reader io.Reader
+	)
+	for {
+		conn, err = listener.Accept()
+		if err != nil {
+			close(accepted)
+			if ctx.IsDone() {
+				return nil
+			}
+
+			return errors.Wrap(err, "accept terminal connection")
+		}
+
+		reader, err = authenticateProxyConn(conn, token)
+		if err == nil {
+			break
+		}
+
+		ctx.Log().Warnf("Rejected terminal connection from %s: %v", conn.RemoteAddr(), err)
+		_ = conn.Close()
+	}
+	close(accepted)
+	defer conn.Close()
+
+	// only a single connection is bridged
+	_ = listener.Close()
+	ctx.Log().Infof("Terminal connection from %s", conn.RemoteAddr())
+
+	cancelCtx, cancel := context.WithCancel(ctx.Context())
+	defer cancel()
+	terminalOptions.Stdout = conn
+	terminalOptions.Stderr = conn
+	terminalOptions.Stdin = &connReader{reader: reader, closed: cancel}
+	return StartTerminalWithOptions(ctx.WithContext(cancelCtx), devContainer, selector, parent, terminalOptions)
+}
+
+// checkLoopbackAddr returns an error if listenAddr is not a loopback address, e.g. if it listens on all interfaces
+func checkLoopbackAddr(listenAddr string) error {
+	addr, err := net.ResolveTCPAddr("tcp", listenAddr)
+	if err != nil {
+		return errors.Wrapf(err, "resolve %s", listenAddr)
+	} else if addr.IP == nil || !addr.IP.IsLoopback() {
+		return errors.Errorf("refusing to expose the terminal on %s, because it is not a loopback address", listenAddr)
+	}
+
+	return nil
+}
+
+// generateProxyToken returns a random token for the proxy server
+func generateProxyToken() (string, error) {
+	token := make([]byte, 16)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", errors.Wrap(err, "generate proxy token")
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// authenticateProxyConn reads the token line of the connection and returns the reader of the remaining input
+func authenticateProxyConn(conn net.Conn, token string) (io.Reader, error) {
+	_ = conn.SetReadDeadline(time.Now().Add(proxyTokenTimeout))
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadSlice('\n')
+	if err != nil {
+		return nil, errors.Wrap(err, "read token")
+	} else if subtle.ConstantTimeCompare(bytes.TrimRight(line, "\r\n"), []byte(token)) != 1 {
+		return nil, errors.New("invalid token")
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+	return reader, nil
+}
+
+// connReader calls closed once reading from the connection failed, e.g. because it was closed
+type connReader struct {
+	reader io.Reader
+	closed func()
+	once   sync.Once
+}
+
+func (c *connReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	if err != nil {
+		c.once.Do(c.closed)
+	}
+
+	return n, err
+}
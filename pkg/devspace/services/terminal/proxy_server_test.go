@@ -0,0 +1,131 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// freeAddr returns a local address that is not in use
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
+
+func dialWithRetry(t *testing.T, addr string) *net.TCPConn {
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			return conn.(*net.TCPConn)
+		} else if i == 50 {
+			t.Fatalf("dial %s: %v", addr, err)
+		}
+
+		time.Sleep(time.Millisecond * 20)
+	}
+}
+
+func TestStartTerminalProxyServer(t *testing.T) {
+	client := &echoExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		DisableScreen:      true,
+		DisableSessionFile: true,
+	}}
+
+	addr := freeAddr(t)
+	done := make(chan error)
+	go func() {
+		done <- StartTerminalProxyServer(ctx, devContainer, podSelector, addr, &tomb.Tomb{}, WithProxyToken("secret"))
+	}()
+
+	// connections with a wrong token are closed and the next connection is accepted
+	rejected := dialWithRetry(t, addr)
+	defer rejected.Close()
+	_, err := rejected.Write([]byte("wrong\n"))
+	assert.NilError(t, err)
+	_, err = rejected.Read(make([]byte, 1))
+	assert.Equal(t, err, io.EOF)
+
+	conn := dialWithRetry(t, addr)
+	defer conn.Close()
+	_, err = conn.Write([]byte("secret\nhello"))
+	assert.NilError(t, err)
+	out := make([]byte, 5)
+	_, err = io.ReadFull(conn, out)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "hello")
+
+	// closing the connection ends the session and the server
+	assert.NilError(t, conn.CloseWrite())
+	_, err = conn.Read(out)
+	assert.Equal(t, err, io.EOF)
+	assert.NilError(t, <-done)
+
+	// only the first connection is accepted
+	_, err = net.Dial("tcp", addr)
+	assert.Assert(t, err != nil, "Expected the listener to be closed")
+}
+
+func TestStartTerminalProxyServerCancel(t *testing.T) {
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&echoExecClient{})
+
+	done := make(chan error)
+	go func() {
+		done <- StartTerminalProxyServer(ctx, &latest.DevContainer{Terminal: &latest.Terminal{}}, &fixedSelector{}, freeAddr(t), &tomb.Tomb{})
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NilError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("Expected the server to stop once the context is done")
+	}
+}
+
+type checkLoopbackAddrTestCase struct {
+	addr        string
+	expectedErr bool
+}
+
+func TestCheckLoopbackAddr(t *testing.T) {
+	testCases := []checkLoopbackAddrTestCase{
+		{addr: "127.0.0.1:8080"},
+		{addr: "[::1]:8080"},
+		{addr: "localhost:8080"},
+		{addr: ":8080", expectedErr: true},
+		{addr: "0.0.0.0:8080", expectedErr: true},
+		{addr: "10.0.0.1:8080", expectedErr: true},
+	}
+
+	for _, testCase := range testCases {
+		err := checkLoopbackAddr(testCase.addr)
+		assert.Equal(t, err != nil, testCase.expectedErr, "Unexpected error for address %s: %v", testCase.addr, err)
+	}
+}
+
+func TestStartTerminalProxyServerRemote(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&echoExecClient{})
+	err := StartTerminalProxyServer(ctx, &latest.DevContainer{Terminal: &latest.Terminal{}}, &fixedSelector{}, "0.0.0.0:0", &tomb.Tomb{})
+	assert.ErrorContains(t, err, "refusing to expose the terminal on 0.0.0.0:0")
+}
@@ -0,0 +1,78 @@
+package terminal
+
+import (
+	"fmt"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+)
+
+// execVerb is the verb that is required on the exec and attach sub resources of a pod
+const execVerb = "create"
+
+// ExecPermissionError is returned if the user is not allowed to exec into the pod
+type ExecPermissionError struct {
+	User      string
+	Verb      string
+	Resource  string
+	Namespace string
+	Pod       string
+	Reason    string
+}
+
+func (e *ExecPermissionError) Error() string {
+	message := fmt.Sprintf("user %s does not have exec permission on pod %s in namespace %s, please ask your cluster admin to allow the verb %s on %s in namespace %s", e.User, e.Pod, e.Namespace, e.Verb, e.Resource, e.Namespace)
+	if e.Reason != "" {
+		message += ": " + e.Reason
+	}
+
+	return message
+}
+
+// checkExecPermission checks if the user is allowed to connect to the pod of the container with the sub resource
+func checkExecPermission(ctx devspacecontext.Context, container *selector.SelectedPodContainer, subResource kubectl.SubResource) error {
+	if subResource == "" {
+		subResource = kubectl.SubResourceExec
+	}
+
+	resource := "pods/" + string(subResource)
+	allowed, reason, err := kubectl.CanI(ctx.Context(), ctx.KubeClient().KubeClient(), execVerb, resource, container.Pod.Namespace, container.Pod.Name)
+	if err != nil {
+		return errors.Wrapf(err, "check %s permission", resource)
+	} else if allowed {
+		return nil
+	}
+
+	return &ExecPermissionError{
+		User:      currentUser(ctx),
+		Verb:      execVerb,
+		Resource:  resource,
+		Namespace: container.Pod.Namespace,
+		Pod:       container.Pod.Name,
+		Reason:    reason,
+	}
+}
+
+// currentUser returns the name of the user from the cluster or, for clusters that don't support
+// the SelfSubjectReview api, the user of the kube context
+func currentUser(ctx devspacecontext.Context) string {
+	user, err := kubectl.CurrentUser(ctx.Context(), ctx.KubeClient().KubeClient())
+	if err != nil {
+		ctx.Log().Debugf("Error retrieving current user: %v", err)
+	} else if user != "" {
+		return user
+	}
+
+	if clientConfig := ctx.KubeClient().ClientConfig(); clientConfig != nil {
+		rawConfig, err := clientConfig.RawConfig()
+		if err == nil {
+			if kubeContext, ok := rawConfig.Contexts[ctx.KubeClient().CurrentContext()]; ok && kubeContext.AuthInfo != "" {
+				return kubeContext.AuthInfo
+			}
+		}
+	}
+
+	return "<unknown>"
+}
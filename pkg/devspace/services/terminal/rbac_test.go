@@ -0,0 +1,89 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type checkRBACTestCase struct {
+	name    string
+	allowed bool
+
+	expectedErr     string
+	expectedStreams int
+}
+
+func TestStartTerminalCheckRBAC(t *testing.T) {
+	testCases := []checkRBACTestCase{
+		{
+			name:            "Allowed",
+			allowed:         true,
+			expectedStreams: 1,
+		},
+		{
+			name:        "Denied",
+			expectedErr: "user jane does not have exec permission on pod pod in namespace dev, please ask your cluster admin to allow the verb create on pods/exec in namespace dev: no binding",
+		},
+	}
+
+	for _, testCase := range testCases {
+		var review *authorizationv1.SelfSubjectAccessReview
+		kubeClient := fake.NewSimpleClientset()
+		kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review = action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			result := review.DeepCopy()
+			result.Status.Allowed = testCase.allowed
+			if !testCase.allowed {
+				result.Status.Reason = "no binding"
+			}
+			return true, result, nil
+		})
+		kubeClient.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &authenticationv1.SelfSubjectReview{
+				Status: authenticationv1.SelfSubjectReviewStatus{UserInfo: authenticationv1.UserInfo{Username: "jane"}},
+			}, nil
+		})
+
+		client := &ephemeralExecClient{Client: kubectltesting.Client{Client: kubeClient}}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "dev"}},
+			Container: &corev1.Container{Name: "container"},
+		}}
+
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithRestart(RestartUnlimited),
+			WithCheckRBAC(true),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		if testCase.expectedErr == "" {
+			assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		} else {
+			assert.Error(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+		}
+		assert.Equal(t, len(client.streams), testCase.expectedStreams, "Unexpected exec streams in "+testCase.name)
+		assert.DeepEqual(t, review.Spec.ResourceAttributes, &authorizationv1.ResourceAttributes{
+			Namespace:   "dev",
+			Verb:        "create",
+			Resource:    "pods",
+			Subresource: "exec",
+			Name:        "pod",
+		})
+	}
+}
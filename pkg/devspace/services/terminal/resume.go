@@ -0,0 +1,34 @@
+package terminal
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ResumeFunc returns the command that continues the output of command after offset bytes of
+// stdout were already received. It is used to reconnect non-tty sessions without duplicating output.
+type ResumeFunc func(command []string, offset int64) []string
+
+// resumeScript pipes the stdout of the command given as arguments through tail, which skips the bytes given by
+// the format argument. The exit status of the command is passed through fd 3, as a pipeline would exit with the
+// status of tail and pipefail is not supported by every sh.
+const resumeScript = `{ status=$( { { "$@"; echo $? >&3; } | tail -c +%d >&4; } 3>&1 ); exit $status; } 4>&1`
+
+// DefaultResumeCommand runs the command again and skips the first offset bytes of its stdout.
+// This requires the command to produce the same output again, e.g. cat or tail of a file.
+// The resumed command exits with the exit code of the command.
+func DefaultResumeCommand(command []string, offset int64) []string {
+	return append([]string{"sh", "-c", fmt.Sprintf(resumeScript, offset+1), "devspace-resume"}, command...)
+}
+
+// resumeCommand returns the command to resume the session with
+func (o *TerminalOptions) resumeCommand(command []string) []string {
+	offset := atomic.LoadInt64(o.resumeOffset)
+	if offset == 0 {
+		return command
+	} else if o.ResumeCommand != nil {
+		return o.ResumeCommand(command, offset)
+	}
+
+	return DefaultResumeCommand(command, offset)
+}
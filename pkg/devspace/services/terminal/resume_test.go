@@ -0,0 +1,81 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// interruptedExecClient writes one chunk of output per session and loses the connection until all chunks are written
+type interruptedExecClient struct {
+	kubectltesting.Client
+
+	chunks   []string
+	commands [][]string
+}
+
+func (c *interruptedExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.commands = append(c.commands, options.Command)
+	_, _ = options.Stdout.Write([]byte(c.chunks[0]))
+	c.chunks = c.chunks[1:]
+	if len(c.chunks) > 0 {
+		return fmt.Errorf("connection reset by peer")
+	}
+
+	return nil
+}
+
+func TestStartTerminalFromCMDResume(t *testing.T) {
+	client := &interruptedExecClient{chunks: []string{"first ", "second ", "third"}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+
+	stdout := &bytes.Buffer{}
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"cat", "/var/log/app.log"}),
+		WithTTY(false),
+		WithRestart(RestartUnlimited),
+		WithResume(nil),
+		WithDisableSessionFile(true),
+		WithStreams(stdout, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, stdout.String(), "first second third")
+	assert.DeepEqual(t, client.commands, [][]string{
+		{"cat", "/var/log/app.log"},
+		DefaultResumeCommand([]string{"cat", "/var/log/app.log"}, 6),
+		DefaultResumeCommand([]string{"cat", "/var/log/app.log"}, 13),
+	})
+}
+
+func TestDefaultResumeCommand(t *testing.T) {
+	if _, err := exec.LookPath("tail"); err != nil {
+		t.Skip("tail is not available")
+	}
+
+	command := DefaultResumeCommand([]string{"sh", "-c", "printf 'first second'; exit 3"}, 6)
+	out, err := exec.Command(command[0], command[1:]...).Output()
+	assert.Equal(t, string(out), "second")
+
+	// the exit code of the command is passed on instead of the one of tail
+	exitErr := &exec.ExitError{}
+	assert.Assert(t, errors.As(err, &exitErr), "Expected an exit error, got %v", err)
+	assert.Equal(t, exitErr.ExitCode(), 3)
+}
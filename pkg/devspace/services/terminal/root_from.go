@@ -0,0 +1,57 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/mgutz/ansi"
+)
+
+// rootFrom wraps the command so that it is executed with the root filesystem of the container
+// rootFrom of the same pod, which only works if the pod shares its process namespace
+func rootFrom(container *selector.SelectedPodContainer, command []string, rootFrom string) ([]string, error) {
+	if rootFrom == container.Container.Name {
+		return nil, fmt.Errorf("terminal.rootFrom cannot be the container %s the terminal is opened to", rootFrom)
+	} else if container.Pod.Spec.ShareProcessNamespace == nil || !*container.Pod.Spec.ShareProcessNamespace {
+		return nil, fmt.Errorf("terminal.rootFrom requires pod %s to share its process namespace, please set %s in the pod spec", container.Pod.Name, ansi.Color("shareProcessNamespace: true", "white+b"))
+	}
+
+	found := false
+	for _, podContainer := range container.Pod.Spec.Containers {
+		if podContainer.Name == rootFrom {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("terminal.rootFrom: container %s not found in pod %s", rootFrom, container.Pod.Name)
+	}
+
+	containerID := ""
+	for _, status := range container.Pod.Status.ContainerStatuses {
+		if status.Name == rootFrom && status.State.Running != nil {
+			containerID = status.ContainerID
+		}
+	}
+	if index := strings.Index(containerID, "://"); index != -1 {
+		containerID = containerID[index+3:]
+	}
+	if containerID == "" {
+		return nil, fmt.Errorf("terminal.rootFrom: container %s in pod %s is not running", rootFrom, container.Pod.Name)
+	}
+
+	return rootFromCommand(command, rootFrom, containerID), nil
+}
+
+// rootFromCommand wraps the command with nsenter to change its root and working directory to the ones of a
+// process of the given container. The process is found by the container id in its cgroup, which is visible
+// through the shared process namespace. The command has to exist in the filesystem of that container.
+func rootFromCommand(command []string, name string, containerID string) []string {
+	script := fmt.Sprintf(`pid=$(grep -l %s /proc/[0-9]*/cgroup 2>/dev/null | head -n 1 | cut -d/ -f3)
+[ -n "$pid" ] || { echo 'no process of container %s found, is the process namespace shared?' >&2; exit 1; }
+command -v nsenter >/dev/null 2>&1 || { echo 'nsenter not found in container' >&2; exit 127; }
+exec nsenter --target "$pid" --root --wd -- %s`, shellquote.Join(containerID), name, shellquote.Join(command...))
+	return []string{"sh", "-c", script}
+}
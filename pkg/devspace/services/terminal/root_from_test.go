@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+type rootFromTestCase struct {
+	name                  string
+	rootFrom              string
+	shareProcessNamespace *bool
+	statuses              []corev1.ContainerStatus
+
+	expectedCommand []string
+	expectedErr     string
+}
+
+func TestRootFrom(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	testCases := []rootFromTestCase{
+		{
+			name:                  "Distroless container",
+			rootFrom:              "distroless",
+			shareProcessNamespace: pointer.Bool(true),
+			statuses: []corev1.ContainerStatus{
+				{Name: "debug", ContainerID: "containerd://abc", State: running},
+				{Name: "distroless", ContainerID: "containerd://def", State: running},
+			},
+			expectedCommand: []string{"sh", "-c", `pid=$(grep -l def /proc/[0-9]*/cgroup 2>/dev/null | head -n 1 | cut -d/ -f3)
+[ -n "$pid" ] || { echo 'no process of container distroless found, is the process namespace shared?' >&2; exit 1; }
+command -v nsenter >/dev/null 2>&1 || { echo 'nsenter not found in container' >&2; exit 127; }
+exec nsenter --target "$pid" --root --wd -- sh -c 'exec /busybox/sh'`},
+		},
+		{
+			name:        "Process namespace not shared",
+			rootFrom:    "distroless",
+			expectedErr: "terminal.rootFrom requires pod pod to share its process namespace",
+		},
+		{
+			name:                  "Same container",
+			rootFrom:              "debug",
+			shareProcessNamespace: pointer.Bool(true),
+			expectedErr:           "terminal.rootFrom cannot be the container debug the terminal is opened to",
+		},
+		{
+			name:                  "Unknown container",
+			rootFrom:              "other",
+			shareProcessNamespace: pointer.Bool(true),
+			expectedErr:           "terminal.rootFrom: container other not found in pod pod",
+		},
+		{
+			name:                  "Container not running",
+			rootFrom:              "distroless",
+			shareProcessNamespace: pointer.Bool(true),
+			statuses: []corev1.ContainerStatus{
+				{Name: "distroless", ContainerID: "containerd://def", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}},
+			},
+			expectedErr: "terminal.rootFrom: container distroless in pod pod is not running",
+		},
+	}
+
+	for _, testCase := range testCases {
+		container := &selector.SelectedPodContainer{
+			Pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+				Spec: corev1.PodSpec{
+					ShareProcessNamespace: testCase.shareProcessNamespace,
+					Containers:            []corev1.Container{{Name: "debug"}, {Name: "distroless"}},
+				},
+				Status: corev1.PodStatus{ContainerStatuses: testCase.statuses},
+			},
+			Container: &corev1.Container{Name: "debug"},
+		}
+
+		command, err := rootFrom(container, []string{"sh", "-c", "exec /busybox/sh"}, testCase.rootFrom)
+		if testCase.expectedErr != "" {
+			assert.ErrorContains(t, err, testCase.expectedErr, "Unexpected error in test case %s", testCase.name)
+			continue
+		}
+		assert.NilError(t, err, "Unexpected error in test case %s", testCase.name)
+		assert.DeepEqual(t, command, testCase.expectedCommand)
+	}
+}
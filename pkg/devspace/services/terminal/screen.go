@@ -0,0 +1,343 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// DefaultScreenSessionName is the name of the screen session of a dev container terminal
+const DefaultScreenSessionName = "dev"
+
+// DefaultScreenInstallTimeout is the default maximum time to wait for screen to be installed
+const DefaultScreenInstallTimeout = time.Second * 60
+
+// screenLogExportTimeout is the maximum time to wait for the screen log to be retrieved
+const screenLogExportTimeout = time.Second * 30
+
+// DefaultScreenInstallScript installs screen in the container if necessary
+const DefaultScreenInstallScript = `if ! command -v screen; then
+  touch /usr/.devspace-write-test 2>&1 | grep -i "read-only file system" && exit 1
+  rm -f /usr/.devspace-write-test
+  if command -v apk; then
+    apk add --no-cache screen
+  elif command -v apt-get; then
+    apt-get -qq update && apt-get install -y screen && rm -rf /var/lib/apt/lists/*
+  elif command -v dnf; then
+    dnf install -y screen && dnf clean all
+  elif command -v zypper; then
+    zypper --non-interactive install -y screen && zypper clean
+  else
+    echo "Couldn't install screen using neither apt-get, apk, dnf nor zypper."
+    exit 1
+  fi
+fi
+if command -v screen; then
+  echo "Screen installed successfully."
+else
+  echo "Couldn't find screen, need to fallback."
+  exit 1
+fi`
+
+// screenProbeScript only checks if screen is present in the container, without installing it
+const screenProbeScript = `command -v screen`
+
+// screenrcLogPath is the logfile of the default .screenrc for screen versions without -Logfile
+const screenrcLogPath = "/tmp/terminal-log.0"
+
+// screenrcScript creates a default .screenrc if there is none yet. The logfile is only set if screen
+// doesn't support -Logfile, because the logfile command of the .screenrc would take precedence over it.
+func screenrcScript(supportsLogfile bool) string {
+	script := `if [ ! -f ~/.screenrc ]; then
+  echo "termcapinfo xterm* ti@:te@" > ~/.screenrc
+`
+	if !supportsLogfile {
+		script += `  echo "logfile ` + screenrcLogPath + `" >> ~/.screenrc
+`
+	}
+
+	return script + `  echo "escape ^tt" >> ~/.screenrc
+fi`
+}
+
+// screenVersionScript prints the version of screen, some versions exit with 1 after printing it
+const screenVersionScript = `screen --version 2>&1 || true`
+
+// screenVersionRegEx matches the major and minor version in the output of screen --version
+var screenVersionRegEx = regexp.MustCompile(`Screen version (\d+)\.(\d+)`)
+
+// screenLogNameRegEx matches the characters of a session name that are replaced in the log file name
+var screenLogNameRegEx = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// screenLogPath returns the path of the screen log in the container, which defaults to a
+// file per screen session under /tmp, so that a reattached session keeps writing to it
+func screenLogPath(options *TerminalOptions) string {
+	if options.ScreenLogPath != "" {
+		return options.ScreenLogPath
+	}
+
+	return "/tmp/devspace-screenlog-" + screenLogNameRegEx.ReplaceAllString(options.ScreenSession, "-") + ".log"
+}
+
+// screenCommand wraps the command in the screen session of the options, which is created or reattached.
+// If screen supports -Logfile, the output is logged to the returned path, otherwise the path is empty.
+func screenCommand(container *selector.SelectedPodContainer, options *TerminalOptions, command []string) ([]string, string) {
+	logPath := ""
+	newCommand := []string{"screen", "-dRSqL", options.ScreenSession}
+	if screenProbes.supportsLogfile(container) {
+		logPath = screenLogPath(options)
+		newCommand = append(newCommand, "-Logfile", logPath)
+	}
+	newCommand = append(newCommand, "--")
+	return append(newCommand, command...), logPath
+}
+
+// supportsLogfile checks if the screen version printed by screen --version supports
+// the -Logfile option, which was added in screen 4.06
+func supportsLogfile(versionOutput string) bool {
+	matches := screenVersionRegEx.FindStringSubmatch(versionOutput)
+	if matches == nil {
+		return false
+	}
+
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2])
+	return major > 4 || (major == 4 && minor >= 6)
+}
+
+// screenProbes remembers the containers screen was already confirmed in, so that
+// multiple terminals to the same container only run the install script once
+var screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+
+type screenProbeCache struct {
+	confirmed map[string]bool
+	logfile   map[string]bool
+	readOnly  map[string]bool
+	mutex     sync.Mutex
+
+	// probes serializes the probes per container, so that terminals to other containers don't wait
+	probes map[string]*sync.Mutex
+}
+
+// lock waits until no other probe for the container is running and returns the function to unlock it again
+func (c *screenProbeCache) lock(key string) func() {
+	c.mutex.Lock()
+	if c.probes == nil {
+		c.probes = map[string]*sync.Mutex{}
+	}
+	probe, ok := c.probes[key]
+	if !ok {
+		probe = &sync.Mutex{}
+		c.probes[key] = probe
+	}
+	c.mutex.Unlock()
+
+	probe.Lock()
+	return probe.Unlock
+}
+
+// state returns if screen was already confirmed in the container or its filesystem is read-only
+func (c *screenProbeCache) state(key string) (bool, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.confirmed[key], c.readOnly[key]
+}
+
+func (c *screenProbeCache) setReadOnly(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.readOnly == nil {
+		c.readOnly = map[string]bool{}
+	}
+	c.readOnly[key] = true
+}
+
+func (c *screenProbeCache) confirm(key string, supportsLogfile bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.logfile == nil {
+		c.logfile = map[string]bool{}
+	}
+	c.logfile[key] = supportsLogfile
+	c.confirmed[key] = true
+}
+
+// supportsLogfile returns if screen in the container supports -Logfile, which is
+// detected when screen is confirmed in the container by installScreen
+func (c *screenProbeCache) supportsLogfile(container *selector.SelectedPodContainer) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.logfile[screenProbeKey(container)]
+}
+
+// screenProbeKey identifies the container, the pod uid changes if the pod is recreated
+func screenProbeKey(container *selector.SelectedPodContainer) string {
+	return string(container.Pod.UID) + "/" + container.Pod.Namespace + "/" + container.Pod.Name + "/" + container.Container.Name
+}
+
+// ScreenFallbackError is the reason screen could not be used in the container. It holds
+// the output of the install script to help figuring out why the installation failed.
+type ScreenFallbackError struct {
+	Stdout []byte
+	Stderr []byte
+	Err    error
+
+	// ReadOnlyFilesystem is true if screen cannot be installed, because the filesystem of the container is read-only
+	ReadOnlyFilesystem bool
+}
+
+func (e *ScreenFallbackError) Error() string {
+	message := "couldn't install screen: " + e.Err.Error()
+	if e.ReadOnlyFilesystem {
+		message = "couldn't install screen, because the filesystem is read-only: " + e.Err.Error()
+	}
+	if output := strings.TrimSpace(string(e.Stdout) + "\n" + string(e.Stderr)); output != "" {
+		message += "\n" + output
+	}
+
+	return message
+}
+
+func (e *ScreenFallbackError) Unwrap() error {
+	return e.Err
+}
+
+// installScreen tries to install screen in the container and returns a *ScreenFallbackError if screen cannot be used
+func installScreen(ctx devspacecontext.Context, container *selector.SelectedPodContainer, options *TerminalOptions) error {
+	script := options.ScreenInstallScript
+	if options.NoPackageInstall {
+		script = screenProbeScript
+	} else if script == "" {
+		script = DefaultScreenInstallScript
+	}
+	timeout := options.ScreenInstallTimeout
+	if timeout <= 0 {
+		timeout = DefaultScreenInstallTimeout
+	}
+
+	// concurrent terminals to the same container wait for the first probe instead of running their own
+	key := screenProbeKey(container)
+	unlock := screenProbes.lock(key)
+	defer unlock()
+	confirmed, readOnly := screenProbes.state(key)
+	if confirmed {
+		ctx.Log().Debugf("Screen already confirmed in container, skipping installation")
+		return nil
+	}
+	if readOnly {
+		ctx.Log().Debugf("Filesystem of container is read-only, skipping screen installation")
+		return &ScreenFallbackError{Err: fmt.Errorf("read-only file system"), ReadOnlyFilesystem: true}
+	}
+
+	// package managers might hang on broken mirrors, so we limit the time we wait for
+	// the installation. Cancelling the context closes the exec stream to the container.
+	installCtx, cancel := context.WithTimeout(ctx.Context(), timeout)
+	defer cancel()
+
+	ctx.Log().Debugf("Installing screen in container...")
+	stdout, stderr, err := ctx.KubeClient().ExecBuffered(installCtx, container.Pod, container.Container.Name, []string{
+		"sh",
+		"-c",
+		script,
+	}, nil)
+	if installCtx.Err() == context.DeadlineExceeded {
+		ctx.Log().Debugf("Timed out installing screen after %s, falling back to plain shell", timeout)
+		return &ScreenFallbackError{Stdout: stdout, Stderr: stderr, Err: fmt.Errorf("timed out after %s", timeout)}
+	} else if err != nil {
+		ctx.Log().Debugf("Error installing screen: %s %s %v", string(stdout), string(stderr), err)
+		if !options.NoPackageInstall && isReadOnlyFilesystem(stdout, stderr) {
+			// the install would fail the same way for every terminal to the container, so we only warn once
+			screenProbes.setReadOnly(key)
+			ctx.Log().Warnf("Screen can't be installed in container %s, because its filesystem is read-only. Falling back to a plain shell, which won't survive reconnects. Set terminal.disableScreen to true to skip the installation", container.Container.Name)
+			return &ScreenFallbackError{Stdout: stdout, Stderr: stderr, Err: err, ReadOnlyFilesystem: true}
+		}
+
+		return &ScreenFallbackError{Stdout: stdout, Stderr: stderr, Err: err}
+	}
+
+	// older screen versions don't support -Logfile and write to the logfile of the .screenrc instead
+	stdout, stderr, err = ctx.KubeClient().ExecBuffered(installCtx, container.Pod, container.Container.Name, []string{"sh", "-c", screenVersionScript}, nil)
+	if err != nil {
+		ctx.Log().Debugf("Error retrieving screen version: %s %v", string(stderr), err)
+	}
+	logfile := supportsLogfile(string(stdout))
+
+	// dotfiles might be mounted shortly after the container started, so users can opt out of the
+	// .screenrc creation to not clobber them. Screen then just uses whatever config exists.
+	if options.SkipScreenrc {
+		ctx.Log().Debugf("Not creating a .screenrc in container, because it is not managed by DevSpace")
+	} else {
+		ctx.Log().Debugf("Creating a default .screenrc in container if it doesn't exist")
+		_, stderr, err = ctx.KubeClient().ExecBuffered(installCtx, container.Pod, container.Container.Name, []string{"sh", "-c", screenrcScript(logfile)}, nil)
+		if err != nil && isReadOnlyFilesystem(nil, stderr) {
+			ctx.Log().Debugf("Couldn't create .screenrc, because the filesystem is read-only, screen uses its default configuration")
+		} else if err != nil {
+			ctx.Log().Debugf("Error creating .screenrc: %s %v", string(stderr), err)
+		}
+	}
+
+	screenProbes.confirm(key, logfile)
+	return nil
+}
+
+// isReadOnlyFilesystem checks if the output of a command contains the message of EROFS, which
+// package managers and the write test of DefaultScreenInstallScript print on a read-only filesystem
+func isReadOnlyFilesystem(stdout []byte, stderr []byte) bool {
+	return strings.Contains(strings.ToLower(string(stdout)+"\n"+string(stderr)), "read-only file system")
+}
+
+// exportScreenLog copies the screen log of the session at logPath out of the container to localPath
+func exportScreenLog(ctx devspacecontext.Context, container *selector.SelectedPodContainer, logPath string, localPath string, sessionErr error) {
+	// the session context might already be cancelled at this point, so we use a separate one
+	exportCtx, cancel := context.WithTimeout(context.Background(), screenLogExportTimeout)
+	defer cancel()
+
+	// screen versions without -Logfile write to the logfile of the default .screenrc,
+	// or to screenlog.0 in the working directory if the .screenrc is not managed by DevSpace
+	script := "cat " + screenrcLogPath + " 2>/dev/null || cat screenlog.0"
+	if logPath != "" {
+		script = "cat " + shellquote.Join(logPath)
+	}
+	stdout, stderr, err := ctx.KubeClient().ExecBuffered(exportCtx, container.Pod, container.Container.Name, []string{
+		"sh",
+		"-c",
+		script,
+	}, nil)
+	if err != nil {
+		ctx.Log().Warnf("Error retrieving screen log: %s %v", string(stderr), err)
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(localPath), 0755)
+	if err == nil {
+		err = os.WriteFile(localPath, stdout, 0644)
+	}
+	if err != nil {
+		ctx.Log().Warnf("Error writing screen log to %s: %v", localPath, err)
+		return
+	}
+
+	// screen only flushes its log periodically, so if the session was interrupted
+	// the last output might be missing
+	if _, ok := sessionErr.(kubectlExec.CodeExitError); sessionErr != nil && !ok {
+		ctx.Log().Warnf("Session ended abnormally, screen log exported to %s might be incomplete", localPath)
+		return
+	}
+
+	ctx.Log().Infof("Exported screen log to %s", localPath)
+}
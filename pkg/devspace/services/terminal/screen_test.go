@@ -0,0 +1,487 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// hangingExecClient blocks every buffered exec until the context is cancelled
+type hangingExecClient struct {
+	kubectltesting.Client
+}
+
+func (c *hangingExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	<-ctx.Done()
+	return nil, nil, nil
+}
+
+func TestInstallScreenTimeout(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&hangingExecClient{})
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{},
+		Container: &corev1.Container{Name: "test"},
+	}
+
+	start := time.Now()
+	err := installScreen(ctx, container, &TerminalOptions{ScreenInstallTimeout: time.Millisecond * 50})
+	assert.ErrorContains(t, err, "timed out after 50ms", "Expected fallback to plain shell")
+	assert.Assert(t, time.Since(start) < time.Second*5, "Expected install to be cancelled")
+}
+
+// perContainerExecClient blocks the execs to the container slow until release is closed
+type perContainerExecClient struct {
+	kubectltesting.Client
+
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *perContainerExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	if container == "slow" {
+		select {
+		case c.started <- struct{}{}:
+		default:
+		}
+		<-c.release
+	}
+
+	return []byte("Screen version 4.09.00 (GNU) 30-Jan-22"), nil, nil
+}
+
+func TestInstallScreenPerContainer(t *testing.T) {
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+	client := &perContainerExecClient{started: make(chan struct{}, 1), release: make(chan struct{})}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	slow := &selector.SelectedPodContainer{Pod: &corev1.Pod{}, Container: &corev1.Container{Name: "slow"}}
+	fast := &selector.SelectedPodContainer{Pod: &corev1.Pod{}, Container: &corev1.Container{Name: "fast"}}
+
+	done := make(chan error)
+	go func() {
+		done <- installScreen(ctx, slow, &TerminalOptions{})
+	}()
+	<-client.started
+
+	// a probe of another container doesn't wait for the running one
+	assert.NilError(t, installScreen(ctx, fast, &TerminalOptions{}))
+	close(client.release)
+	assert.NilError(t, <-done)
+}
+
+type installScreenScriptTestCase struct {
+	name             string
+	script           string
+	skipScreenrc     bool
+	noPackageInstall bool
+
+	expected []string
+}
+
+func TestInstallScreenScript(t *testing.T) {
+	testCases := []installScreenScriptTestCase{
+		{
+			name:     "Default script",
+			expected: []string{DefaultScreenInstallScript, screenVersionScript, screenrcScript(false)},
+		},
+		{
+			name:     "Custom script",
+			script:   "microdnf install -y screen",
+			expected: []string{"microdnf install -y screen", screenVersionScript, screenrcScript(false)},
+		},
+		{
+			name:             "No package install",
+			script:           "microdnf install -y screen",
+			noPackageInstall: true,
+			expected:         []string{screenProbeScript, screenVersionScript, screenrcScript(false)},
+		},
+		{
+			name:         "Unmanaged screenrc",
+			skipScreenrc: true,
+			expected:     []string{DefaultScreenInstallScript, screenVersionScript},
+		},
+	}
+
+	for _, testCase := range testCases {
+		screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+		client := &screenRecordingClient{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		container := &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{},
+			Container: &corev1.Container{Name: "test"},
+		}
+
+		assert.NilError(t, installScreen(ctx, container, &TerminalOptions{ScreenInstallScript: testCase.script, SkipScreenrc: testCase.skipScreenrc, NoPackageInstall: testCase.noPackageInstall}), "Unexpected result in "+testCase.name)
+		assert.DeepEqual(t, client.scripts, testCase.expected)
+	}
+}
+
+func TestScreenrcScript(t *testing.T) {
+	assert.Assert(t, !strings.Contains(screenrcScript(true), "logfile"), "Unexpected logfile with -Logfile support")
+	assert.Assert(t, strings.Contains(screenrcScript(false), `echo "logfile `+screenrcLogPath+`" >> ~/.screenrc`), "Expected a logfile without -Logfile support")
+}
+
+// noScreenClient fails to install screen and interrupts the first session
+type noScreenClient struct {
+	kubectltesting.Client
+
+	installs int
+	streams  int
+	scripts  []string
+}
+
+func (c *noScreenClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	c.installs++
+	c.scripts = append(c.scripts, command[len(command)-1])
+	return []byte("Couldn't install screen using neither apt-get, apk, dnf nor zypper."), []byte("permission denied"), kubectlExec.CodeExitError{Err: fmt.Errorf("command terminated with exit code 1"), Code: 1}
+}
+
+func (c *noScreenClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.streams++
+	if c.streams == 1 {
+		return fmt.Errorf("connection reset by peer")
+	}
+
+	return nil
+}
+
+func TestStartTerminalScreenFallback(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(interface{}) bool { return true }
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+
+	client := &noScreenClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+
+	reasons := []error{}
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithScreen(true, "dev"),
+		WithRestart(1),
+		WithOnScreenFallback(func(reason error) { reasons = append(reasons, reason) }),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, client.installs, 2, "Expected the install to be retried on restart")
+	assert.Equal(t, len(reasons), 1, "Expected the fallback to be reported once")
+
+	fallbackErr := &ScreenFallbackError{}
+	assert.Assert(t, errors.As(reasons[0], &fallbackErr))
+	assert.Equal(t, string(fallbackErr.Stderr), "permission denied")
+	assert.ErrorContains(t, reasons[0], "Couldn't install screen using neither apt-get, apk, dnf nor zypper.")
+}
+
+// readOnlyClient fails to install screen like apk does on a read-only root filesystem
+type readOnlyClient struct {
+	kubectltesting.Client
+
+	installs int
+}
+
+func (c *readOnlyClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	c.installs++
+	return nil, []byte("ERROR: Unable to lock database: Read-only file system\nERROR: Failed to open apk database: Read-only file system"), kubectlExec.CodeExitError{Err: fmt.Errorf("command terminated with exit code 99"), Code: 99}
+}
+
+func TestInstallScreenReadOnlyFilesystem(t *testing.T) {
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+	client := &readOnlyClient{}
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{},
+		Container: &corev1.Container{Name: "test"},
+	}
+
+	for i := 0; i < 2; i++ {
+		err := installScreen(ctx, container, &TerminalOptions{})
+		fallbackErr := &ScreenFallbackError{}
+		assert.Assert(t, errors.As(err, &fallbackErr))
+		assert.Assert(t, fallbackErr.ReadOnlyFilesystem, "Expected a read-only filesystem")
+		assert.ErrorContains(t, err, "because the filesystem is read-only")
+	}
+
+	assert.Equal(t, client.installs, 1, "Expected the install to be skipped once the filesystem is known to be read-only")
+	assert.Equal(t, strings.Count(out.String(), "because its filesystem is read-only"), 1, "Expected a single warning, got %s", out.String())
+	assert.Assert(t, strings.Contains(out.String(), "Set terminal.disableScreen to true"), "Expected guidance, got %s", out.String())
+}
+
+func TestIsReadOnlyFilesystem(t *testing.T) {
+	assert.Assert(t, isReadOnlyFilesystem([]byte("touch: cannot touch '/usr/.devspace-write-test': Read-only file system"), nil))
+	assert.Assert(t, isReadOnlyFilesystem(nil, []byte("E: List directory /var/lib/apt/lists/partial is missing. - Acquire (30: Read-only file system)")))
+	assert.Assert(t, !isReadOnlyFilesystem([]byte("Couldn't install screen using neither apt-get, apk, dnf nor zypper."), []byte("permission denied")))
+}
+
+func TestStartTerminalNoPackageInstall(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(interface{}) bool { return true }
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+
+	client := &noScreenClient{streams: 1}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{Shell: ShellSh, NoPackageInstall: true, ScreenInstallScript: "apk add screen", DisableSessionFile: true}}
+
+	reasons := []error{}
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithOnScreenFallback(func(reason error) { reasons = append(reasons, reason) }),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, client.scripts, []string{screenProbeScript})
+	assert.Equal(t, client.streams, 2, "Expected a plain shell to be opened")
+	assert.Equal(t, len(reasons), 0, "Expected a silent fallback")
+}
+
+func benchmarkInstallScreen(b *testing.B, cached bool) {
+	defer func(original *screenProbeCache) { screenProbes = original }(screenProbes)
+
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&screenRecordingClient{})
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{},
+		Container: &corev1.Container{Name: "test"},
+	}
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !cached {
+			screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+		}
+		if installScreen(ctx, container, &TerminalOptions{ScreenInstallTimeout: time.Second}) != nil {
+			b.Fatal("expected screen to be installed")
+		}
+	}
+}
+
+func BenchmarkScreenInstallCached(b *testing.B) {
+	benchmarkInstallScreen(b, true)
+}
+
+func BenchmarkScreenInstallFresh(b *testing.B) {
+	benchmarkInstallScreen(b, false)
+}
+
+type supportsLogfileTestCase struct {
+	name          string
+	versionOutput string
+
+	expected bool
+}
+
+func TestSupportsLogfile(t *testing.T) {
+	testCases := []supportsLogfileTestCase{
+		{
+			name:          "Screen 4.08",
+			versionOutput: "Screen version 4.08.00 (GNU) 05-Feb-20",
+			expected:      true,
+		},
+		{
+			name:          "Screen 5",
+			versionOutput: "Screen version 5.0.0 (build on 2024-08-29 17:37:08)",
+			expected:      true,
+		},
+		{
+			name:          "Screen 4.05",
+			versionOutput: "Screen version 4.05.00 (GNU) 25-Dec-16",
+		},
+		{
+			name:          "Unknown output",
+			versionOutput: "sh: screen: not found",
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, supportsLogfile(testCase.versionOutput), testCase.expected, "Unexpected result in "+testCase.name)
+	}
+}
+
+// screenLogClient reports a screen version with -Logfile support and returns the log of the session
+type screenLogClient struct {
+	kubectltesting.Client
+
+	version string
+	command []string
+	export  string
+}
+
+func (c *screenLogClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	script := command[len(command)-1]
+	if script == screenVersionScript {
+		return []byte(c.version), nil, nil
+	} else if strings.HasPrefix(script, "cat ") {
+		c.export = script
+		return []byte("session output"), nil, nil
+	}
+
+	return nil, nil, nil
+}
+
+func (c *screenLogClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.command = options.Command
+	return nil
+}
+
+type screenLogPathTestCase struct {
+	name          string
+	version       string
+	screenLogPath string
+
+	expectedCommand []string
+	expectedExport  string
+}
+
+func TestStartTerminalScreenLogPath(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(interface{}) bool { return true }
+
+	testCases := []screenLogPathTestCase{
+		{
+			name:            "Default path",
+			version:         "Screen version 4.08.00 (GNU) 05-Feb-20",
+			expectedCommand: []string{"screen", "-dRSqL", "my session", "-Logfile", "/tmp/devspace-screenlog-my-session.log", "--"},
+			expectedExport:  "cat /tmp/devspace-screenlog-my-session.log",
+		},
+		{
+			name:            "Custom path",
+			version:         "Screen version 4.08.00 (GNU) 05-Feb-20",
+			screenLogPath:   "/var/log/my screen.log",
+			expectedCommand: []string{"screen", "-dRSqL", "my session", "-Logfile", "/var/log/my screen.log", "--"},
+			expectedExport:  "cat '/var/log/my screen.log'",
+		},
+		{
+			name:            "Screen without -Logfile",
+			version:         "Screen version 4.05.00 (GNU) 25-Dec-16",
+			screenLogPath:   "/var/log/screen.log",
+			expectedCommand: []string{"screen", "-dRSqL", "my session", "--"},
+			expectedExport:  "cat /tmp/terminal-log.0 2>/dev/null || cat screenlog.0",
+		},
+	}
+
+	for _, testCase := range testCases {
+		screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+		client := &screenLogClient{version: testCase.version}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+			Container: &corev1.Container{Name: "test"},
+		}}
+		devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+			Shell:              ShellSh,
+			ScreenLogPath:      testCase.screenLogPath,
+			ScreenLogExport:    filepath.Join(t.TempDir(), "screen.log"),
+			DisableSessionFile: true,
+		}}
+
+		err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+			WithScreenSession("my session"),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.DeepEqual(t, client.command[:len(testCase.expectedCommand)], testCase.expectedCommand)
+		assert.Equal(t, client.export, testCase.expectedExport, "Unexpected export in "+testCase.name)
+
+		exported, err := os.ReadFile(devContainer.Terminal.ScreenLogExport)
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, string(exported), "session output", "Unexpected export in "+testCase.name)
+	}
+}
+
+func TestStartTerminalFromCMDWithResult(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(interface{}) bool { return true }
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+
+	// screen is available
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+	client := &recordingExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	result, err := StartTerminalFromCMDWithResult(ctx, podSelector, NewTerminalOptions(
+		WithScreen(true, "dev"),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, client.options.Command[0], "screen")
+	assert.DeepEqual(t, result, &TerminalResult{UsedMultiplexer: true, Multiplexer: MultiplexerScreen})
+
+	// screen cannot be installed, so the session falls back to a plain shell
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+	ctx = ctx.WithKubeClient(&noScreenClient{})
+	result, err = StartTerminalFromCMDWithResult(ctx, podSelector, NewTerminalOptions(
+		WithScreen(true, "dev"),
+		WithRestart(1),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, &TerminalResult{})
+}
+
+func TestStartTerminalWithResult(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(interface{}) bool { return true }
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{Shell: ShellSh, DisableSessionFile: true}}
+
+	// screen is available
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+	client := &recordingExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	result, err := StartTerminalWithResult(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, client.options.Command[0], "screen")
+	assert.DeepEqual(t, result, &TerminalResult{UsedMultiplexer: true, Multiplexer: MultiplexerScreen})
+
+	// screen cannot be installed, so the session falls back to a plain shell
+	// keep the parent alive across the restart like the dev pipeline does
+	parent := &tomb.Tomb{}
+	parent.Go(func() error {
+		<-parent.Dying()
+		return nil
+	})
+	defer parent.Kill(nil)
+
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+	ctx = ctx.WithKubeClient(&noScreenClient{})
+	result, err = StartTerminalWithResult(ctx, devContainer, podSelector, parent, NewTerminalOptions(
+		WithRestart(1),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, &TerminalResult{})
+}
@@ -0,0 +1,71 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/randutil"
+	"github.com/pkg/errors"
+)
+
+// SessionFilePathPrefix is the prefix of the file inside the container that tells other tooling
+// that it is running inside a DevSpace session. Every session writes its own file named after its
+// session id, which exists while the session is connected.
+const SessionFilePathPrefix = "/tmp/.devspace-session-"
+
+// SessionFileEnvVar is the environment variable that holds the path of the session file in the session
+const SessionFileEnvVar = "DEVSPACE_SESSION_FILE"
+
+// sessionFileCleanupScript exports the session file given as first argument and removes it once the command
+// has ended. The command is not exec'd, so that the shell survives it, and signals are turned into a regular exit.
+const sessionFileCleanupScript = SessionFileEnvVar + `="$1"; export ` + SessionFileEnvVar + `; shift; trap 'rm -f "$` + SessionFileEnvVar + `"' EXIT; trap 'exit 129' HUP; trap 'exit 130' INT; trap 'exit 143' TERM; "$@"`
+
+// SessionMetadata is the content of the session file
+type SessionMetadata struct {
+	SessionID string    `json:"sessionID"`
+	StartedAt time.Time `json:"startedAt"`
+	User      string    `json:"user"`
+}
+
+// writeSessionFile writes the metadata of a new session to its session file in the container and returns the path of the file
+func writeSessionFile(ctx devspacecontext.Context, container *selector.SelectedPodContainer) (string, error) {
+	sessionMetadata := &SessionMetadata{
+		SessionID: strings.ToLower(randutil.GenerateRandomString(12)),
+		StartedAt: time.Now().UTC(),
+		User:      localUser(),
+	}
+	metadata, err := json.Marshal(sessionMetadata)
+	if err != nil {
+		return "", err
+	}
+
+	sessionFilePath := SessionFilePathPrefix + sessionMetadata.SessionID
+	_, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{"sh", "-c", "cat > " + sessionFilePath}, bytes.NewReader(metadata))
+	if err != nil {
+		return "", errors.Wrapf(err, "write session file: %s", string(stderr))
+	}
+
+	return sessionFilePath, nil
+}
+
+// withSessionFileCleanup wraps the command into a shell that exports the path of the session file
+// as SessionFileEnvVar and removes the file once the command has ended
+func withSessionFileCleanup(command []string, sessionFilePath string) []string {
+	return append([]string{"sh", "-c", sessionFileCleanupScript, "devspace-session", sessionFilePath}, command...)
+}
+
+// localUser returns the name of the local user that opened the session
+func localUser() string {
+	currentUser, err := user.Current()
+	if err == nil && currentUser.Username != "" {
+		return currentUser.Username
+	}
+
+	return os.Getenv("USER")
+}
@@ -0,0 +1,120 @@
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// sessionFileClient records the session file written to the container and the command of the session
+type sessionFileClient struct {
+	kubectltesting.Client
+
+	sessionFile     []byte
+	sessionFilePath string
+	command         []string
+}
+
+func (c *sessionFileClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	if path, ok := strings.CutPrefix(command[len(command)-1], "cat > "); ok {
+		c.sessionFile, _ = io.ReadAll(input)
+		c.sessionFilePath = path
+	}
+
+	return nil, nil, nil
+}
+
+func (c *sessionFileClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.command = options.Command
+	return nil
+}
+
+type sessionFileTestCase struct {
+	name               string
+	disableSessionFile bool
+
+	expectedFile    bool
+	expectedCommand func(sessionFilePath string) []string
+}
+
+func TestStartTerminalSessionFile(t *testing.T) {
+	testCases := []sessionFileTestCase{
+		{
+			name:         "Session file",
+			expectedFile: true,
+			expectedCommand: func(sessionFilePath string) []string {
+				return []string{"sh", "-c", sessionFileCleanupScript, "devspace-session", sessionFilePath, "echo", "test"}
+			},
+		},
+		{
+			name:               "Disabled",
+			disableSessionFile: true,
+			expectedCommand: func(sessionFilePath string) []string {
+				return []string{"echo", "test"}
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		client := &sessionFileClient{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+			Container: &corev1.Container{Name: "container"},
+		}}
+
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"echo", "test"}),
+			WithDisableSessionFile(testCase.disableSessionFile),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.DeepEqual(t, client.command, testCase.expectedCommand(client.sessionFilePath))
+		if !testCase.expectedFile {
+			assert.Assert(t, client.sessionFile == nil, "Unexpected session file in "+testCase.name)
+			continue
+		}
+
+		metadata := &SessionMetadata{}
+		assert.NilError(t, json.Unmarshal(client.sessionFile, metadata), "Unexpected session file in "+testCase.name)
+		assert.Assert(t, metadata.SessionID != "", "Expected a session id in "+testCase.name)
+		assert.Assert(t, !metadata.StartedAt.IsZero(), "Expected a start time in "+testCase.name)
+		assert.Equal(t, client.sessionFilePath, SessionFilePathPrefix+metadata.SessionID, "Unexpected session file path in "+testCase.name)
+	}
+}
+
+func TestSessionFileCleanup(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first"), filepath.Join(dir, "second")
+	assert.NilError(t, os.WriteFile(first, []byte("{}"), 0600))
+	assert.NilError(t, os.WriteFile(second, []byte("{}"), 0600))
+
+	command := withSessionFileCleanup([]string{"sh", "-c", "echo $" + SessionFileEnvVar}, first)
+	out, err := exec.Command(command[0], command[1:]...).Output()
+	assert.NilError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), first)
+
+	// ending one session must not remove the session file of another one
+	_, err = os.Stat(first)
+	assert.Assert(t, os.IsNotExist(err), "Expected the session file to be removed")
+	_, err = os.Stat(second)
+	assert.NilError(t, err)
+}
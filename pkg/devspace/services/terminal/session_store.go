@@ -0,0 +1,470 @@
+package terminal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofrs/flock"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/randutil"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+// SessionRecord is the metadata of a terminal session that is opened by a DevSpace process on this machine
+type SessionRecord struct {
+	ID          string    `json:"id"`
+	PID         int       `json:"pid"`
+	KubeContext string    `json:"kubeContext,omitempty"`
+	Namespace   string    `json:"namespace"`
+	Pod         string    `json:"pod"`
+	Container   string    `json:"container"`
+	StartedAt   time.Time `json:"startedAt"`
+
+	// BytesIn and BytesOut are the bytes sent to and received from the container by all sessions
+	// of the terminal, which are updated every sessionRecordInterval while a session is connected
+	BytesIn  uint64 `json:"bytesIn,omitempty"`
+	BytesOut uint64 `json:"bytesOut,omitempty"`
+}
+
+// sessionRecordInterval is the interval in which the bytes transferred by a session are written to the session store
+var sessionRecordInterval = time.Second * 5
+
+// SessionStore persists the active terminal sessions to a json file, so that sessions of
+// crashed DevSpace processes can be found afterwards. The file is locked while it is read
+// or written, which allows multiple DevSpace processes to share the store.
+type SessionStore struct {
+	path string
+
+	// sessionLocks are the locks of the sessions this store added, which are held until the session is removed
+	sessionLocks map[string]*flock.Flock
+	m            sync.Mutex
+}
+
+// NewSessionStore creates a session store backed by the json file at path
+func NewSessionStore(path string) *SessionStore {
+	return &SessionStore{
+		path:         path,
+		sessionLocks: map[string]*flock.Flock{},
+	}
+}
+
+// NewDefaultSessionStore creates a session store backed by devspace/sessions.json in $XDG_STATE_HOME,
+// which defaults to ~/.local/state
+func NewDefaultSessionStore() (*SessionStore, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		homeDir, err := homedir.Dir()
+		if err != nil {
+			return nil, err
+		}
+
+		stateHome = filepath.Join(homeDir, ".local", "state")
+	}
+
+	return NewSessionStore(filepath.Join(stateHome, "devspace", "sessions.json")), nil
+}
+
+// Path returns the path of the json file
+func (s *SessionStore) Path() string {
+	return s.path
+}
+
+// List returns all sessions ordered by their start
+func (s *SessionStore) List() ([]SessionRecord, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedRecords(records), nil
+}
+
+// Get returns the session with the given id and false if it doesn't exist
+func (s *SessionStore) Get(id string) (SessionRecord, bool, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return SessionRecord{}, false, err
+	}
+	defer unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return SessionRecord{}, false, err
+	}
+
+	record, ok := records[id]
+	return record, ok, nil
+}
+
+// Add adds a new session of this process and returns an error if a session with the same id exists
+// already. The session counts as running until it is removed or the process exits.
+func (s *SessionStore) Add(record SessionRecord) error {
+	err := s.lockSession(record.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.update(func(records map[string]SessionRecord) error {
+		if _, ok := records[record.ID]; ok {
+			return errors.Errorf("session %s exists already", record.ID)
+		}
+
+		records[record.ID] = record
+		return nil
+	})
+	if err != nil {
+		s.unlockSession(record.ID)
+		return err
+	}
+
+	return nil
+}
+
+// Update replaces an existing session and returns an error if it doesn't exist
+func (s *SessionStore) Update(record SessionRecord) error {
+	return s.update(func(records map[string]SessionRecord) error {
+		if _, ok := records[record.ID]; !ok {
+			return errors.Errorf("session %s doesn't exist", record.ID)
+		}
+
+		records[record.ID] = record
+		return nil
+	})
+}
+
+// Remove removes the sessions with the given ids, unknown ids are ignored
+func (s *SessionStore) Remove(ids ...string) error {
+	err := s.update(func(records map[string]SessionRecord) error {
+		for _, id := range ids {
+			delete(records, id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		s.unlockSession(id)
+	}
+	return nil
+}
+
+// Stale returns the sessions of DevSpace processes that are not running anymore. A session is stale if
+// nobody holds its lock, which the operating system releases when the process exits, even if it crashed.
+func (s *SessionStore) Stale() ([]SessionRecord, error) {
+	records, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	stale := []SessionRecord{}
+	for _, record := range records {
+		if s.stale(record.ID) {
+			stale = append(stale, record)
+		}
+	}
+
+	return stale, nil
+}
+
+func (s *SessionStore) stale(id string) bool {
+	s.m.Lock()
+	_, ok := s.sessionLocks[id]
+	s.m.Unlock()
+	if ok {
+		return false
+	}
+
+	// sessions without a lock file were not added by a running process
+	lockPath := s.sessionLockPath(id)
+	_, err := os.Stat(lockPath)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+
+	sessionLock := flock.New(lockPath)
+	locked, err := sessionLock.TryLock()
+	if err != nil || !locked {
+		return false
+	}
+
+	_ = sessionLock.Unlock()
+	return true
+}
+
+// lock locks the store file across processes and returns the function that unlocks it again
+func (s *SessionStore) lock(shared bool) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(s.path), 0755)
+	if err != nil {
+		return nil, errors.Wrap(err, "create session store directory")
+	}
+
+	storeLock := flock.New(s.path + ".lock")
+	if shared {
+		err = storeLock.RLock()
+	} else {
+		err = storeLock.Lock()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "lock session store")
+	}
+
+	return func() {
+		_ = storeLock.Unlock()
+	}, nil
+}
+
+func (s *SessionStore) sessionLockPath(id string) string {
+	return filepath.Join(filepath.Dir(s.path), "sessions", id+".lock")
+}
+
+// lockSession takes the lock that marks the session as running
+func (s *SessionStore) lockSession(id string) error {
+	lockPath := s.sessionLockPath(id)
+	err := os.MkdirAll(filepath.Dir(lockPath), 0755)
+	if err != nil {
+		return errors.Wrap(err, "create session lock directory")
+	}
+
+	sessionLock := flock.New(lockPath)
+	locked, err := sessionLock.TryLock()
+	if err != nil {
+		return errors.Wrapf(err, "lock session %s", id)
+	} else if !locked {
+		return errors.Errorf("session %s exists already", id)
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.sessionLocks[id] = sessionLock
+	return nil
+}
+
+// unlockSession releases the lock of the session if this store holds it and removes the
+// lock file. Stale sessions of other processes are unlocked, so their lock files are removed too.
+func (s *SessionStore) unlockSession(id string) {
+	s.m.Lock()
+	sessionLock, ok := s.sessionLocks[id]
+	delete(s.sessionLocks, id)
+	s.m.Unlock()
+
+	lockPath := s.sessionLockPath(id)
+	if !ok {
+		sessionLock = flock.New(lockPath)
+		locked, err := sessionLock.TryLock()
+		if err != nil || !locked {
+			return
+		}
+	}
+
+	_ = sessionLock.Unlock()
+	_ = os.Remove(lockPath)
+}
+
+func (s *SessionStore) load() (map[string]SessionRecord, error) {
+	records := map[string]SessionRecord{}
+	out, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, nil
+		}
+
+		return nil, errors.Wrap(err, "read session store")
+	} else if len(strings.TrimSpace(string(out))) == 0 {
+		return records, nil
+	}
+
+	list := []SessionRecord{}
+	err = json.Unmarshal(out, &list)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse session store %s", s.path)
+	}
+	for _, record := range list {
+		records[record.ID] = record
+	}
+
+	return records, nil
+}
+
+// update loads the sessions while the store is locked, changes them with fn and writes them
+// to a temporary file first, so that a crash while writing doesn't corrupt the store
+func (s *SessionStore) update(fn func(records map[string]SessionRecord) error) error {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return err
+	}
+	err = fn(records)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(sortedRecords(records), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "write session store")
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = tempFile.Write(out)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "write session store")
+	}
+
+	return os.Rename(tempFile.Name(), s.path)
+}
+
+func sortedRecords(records map[string]SessionRecord) []SessionRecord {
+	list := make([]SessionRecord, 0, len(records))
+	for _, record := range records {
+		list = append(list, record)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].StartedAt.Equal(list[j].StartedAt) {
+			return list[i].ID < list[j].ID
+		}
+
+		return list[i].StartedAt.Before(list[j].StartedAt)
+	})
+
+	return list
+}
+
+// recordSession adds the session to the session store of the options or updates it
+// after a restart, errors are only logged as the store is informational
+func (o *TerminalOptions) recordSession(ctx devspacecontext.Context, container *selector.SelectedPodContainer) {
+	if o.SessionStore == nil || o.sessionRecord == nil {
+		return
+	}
+
+	record := SessionRecord{
+		ID:          o.sessionRecord.ID,
+		PID:         os.Getpid(),
+		KubeContext: ctx.KubeClient().CurrentContext(),
+		Namespace:   container.Pod.Namespace,
+		Pod:         container.Pod.Name,
+		Container:   container.Container.Name,
+		StartedAt:   o.sessionRecord.StartedAt,
+		BytesIn:     uint64(atomic.LoadInt64(o.sessionBytesIn)),
+		BytesOut:    uint64(atomic.LoadInt64(o.sessionBytesOut)),
+	}
+
+	var err error
+	if o.sessionRecord.Pod == "" {
+		err = o.SessionStore.Add(record)
+	} else {
+		err = o.SessionStore.Update(record)
+	}
+	if err != nil {
+		ctx.Log().Debugf("Error recording terminal session in %s: %v", o.SessionStore.Path(), err)
+		return
+	}
+
+	*o.sessionRecord = record
+}
+
+// startSessionRecord prepares the session record that is shared across restarts and returns
+// a function that removes it from the session store once the terminal has ended
+func (o *TerminalOptions) startSessionRecord(ctx devspacecontext.Context) func() {
+	if o.SessionStore == nil {
+		return func() {}
+	}
+
+	id := o.sessionID
+	if id == "" {
+		id = strings.ToLower(randutil.GenerateRandomString(12))
+	}
+	o.sessionRecord = &SessionRecord{
+		ID:        id,
+		StartedAt: time.Now().UTC(),
+	}
+	o.sessionBytesIn, o.sessionBytesOut = new(int64), new(int64)
+	return func() {
+		if o.sessionRecord.Pod == "" {
+			return
+		}
+
+		err := o.SessionStore.Remove(o.sessionRecord.ID)
+		if err != nil {
+			ctx.Log().Debugf("Error removing terminal session from %s: %v", o.SessionStore.Path(), err)
+		}
+	}
+}
+
+// recordSessionBytes writes the bytes transferred by the terminal to the session store in an interval while
+// the session is connected, so that devspace terminal stats can show them, and returns the function that
+// stops it after a final update
+func (o *TerminalOptions) recordSessionBytes(ctx devspacecontext.Context) func() {
+	if o.SessionStore == nil || o.sessionRecord == nil || o.sessionRecord.Pod == "" {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(sessionRecordInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				o.updateSessionBytes(ctx)
+				return
+			case <-ticker.C:
+				o.updateSessionBytes(ctx)
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+func (o *TerminalOptions) updateSessionBytes(ctx devspacecontext.Context) {
+	record := *o.sessionRecord
+	record.BytesIn = uint64(atomic.LoadInt64(o.sessionBytesIn))
+	record.BytesOut = uint64(atomic.LoadInt64(o.sessionBytesOut))
+	if record == *o.sessionRecord {
+		return
+	}
+
+	err := o.SessionStore.Update(record)
+	if err != nil {
+		ctx.Log().Debugf("Error recording terminal session in %s: %v", o.SessionStore.Path(), err)
+		return
+	}
+
+	*o.sessionRecord = record
+}
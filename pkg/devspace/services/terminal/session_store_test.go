@@ -0,0 +1,207 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSessionStore(t *testing.T) {
+	store := NewSessionStore(filepath.Join(t.TempDir(), "devspace", "sessions.json"))
+
+	records, err := store.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 0)
+
+	startedAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := SessionRecord{ID: "b", PID: os.Getpid(), Pod: "pod", Namespace: "default", Container: "app", StartedAt: startedAt.Add(time.Minute)}
+	second := SessionRecord{ID: "a", PID: os.Getpid(), Pod: "other", Namespace: "default", Container: "app", StartedAt: startedAt}
+	assert.NilError(t, store.Add(first))
+	assert.NilError(t, store.Add(second))
+	assert.ErrorContains(t, store.Add(first), "exists already")
+
+	records, err = store.List()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, records, []SessionRecord{second, first})
+
+	first.Pod = "replaced"
+	assert.NilError(t, store.Update(first))
+	record, ok, err := store.Get("b")
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	assert.Equal(t, record.Pod, "replaced")
+	assert.ErrorContains(t, store.Update(SessionRecord{ID: "missing"}), "doesn't exist")
+
+	assert.NilError(t, store.Remove("a", "missing"))
+	_, ok, err = store.Get("a")
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+
+	// a new store reads the same file
+	records, err = NewSessionStore(store.Path()).List()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, records, []SessionRecord{first})
+}
+
+func TestSessionStoreStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	store := NewSessionStore(path)
+	assert.NilError(t, store.Add(SessionRecord{ID: "running", PID: os.Getpid()}))
+
+	// the pid of a crashed process might have been reused, which must not matter
+	crashed := NewSessionStore(path)
+	assert.NilError(t, crashed.Add(SessionRecord{ID: "crashed", PID: os.Getpid()}))
+	assert.NilError(t, crashed.sessionLocks["crashed"].Unlock())
+
+	// sessions of other processes are running as long as the process holds their lock
+	other := NewSessionStore(path)
+	assert.NilError(t, other.Add(SessionRecord{ID: "other", PID: -1}))
+
+	stale, err := store.Stale()
+	assert.NilError(t, err)
+	assert.Equal(t, len(stale), 1)
+	assert.Equal(t, stale[0].ID, "crashed")
+
+	assert.NilError(t, store.Remove("crashed"))
+	_, err = os.Stat(store.sessionLockPath("crashed"))
+	assert.Assert(t, os.IsNotExist(err), "Expected the lock of the stale session to be removed")
+}
+
+func TestSessionStoreConcurrentProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	// every store has its own file handles like a separate DevSpace process
+	waitGroup := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			assert.Check(t, NewSessionStore(path).Add(SessionRecord{ID: strconv.Itoa(i)}))
+		}(i)
+	}
+	waitGroup.Wait()
+
+	records, err := NewSessionStore(path).List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 20)
+	matches, err := filepath.Glob(path + ".*.tmp")
+	assert.NilError(t, err)
+	assert.Equal(t, len(matches), 0, "Unexpected temporary files")
+}
+
+func TestNewDefaultSessionStore(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+
+	store, err := NewDefaultSessionStore()
+	assert.NilError(t, err)
+	assert.Equal(t, store.Path(), filepath.Join("/state", "devspace", "sessions.json"))
+}
+
+type sessionStoreClient struct {
+	recordingExecClient
+
+	store   *SessionStore
+	records []SessionRecord
+}
+
+func (c *sessionStoreClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	records, err := c.store.List()
+	if err != nil {
+		return err
+	}
+
+	c.records = records
+	return c.recordingExecClient.ExecStream(ctx, options)
+}
+
+func TestStartTerminalRecordsSession(t *testing.T) {
+	store := NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	client := &sessionStoreClient{store: store}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithDisableSessionFile(true),
+		WithSessionStore(store),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+
+	// the session is recorded while it is open and removed once it has ended
+	assert.Equal(t, len(client.records), 1)
+	assert.Equal(t, client.records[0].Pod, "pod")
+	assert.Equal(t, client.records[0].Namespace, "default")
+	assert.Equal(t, client.records[0].Container, "app")
+	assert.Equal(t, client.records[0].PID, os.Getpid())
+	records, err := store.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 0)
+}
+
+// sessionBytesClient transfers bytes like a connected session and waits until they are recorded in the store
+type sessionBytesClient struct {
+	recordingExecClient
+
+	store    *SessionStore
+	recorded SessionRecord
+}
+
+func (c *sessionBytesClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	atomic.AddInt64(options.BytesIn, 4)
+	atomic.AddInt64(options.BytesOut, 42)
+	for i := 0; i < 100; i++ {
+		records, err := c.store.List()
+		if err != nil {
+			return err
+		} else if len(records) == 1 && records[0].BytesOut > 0 {
+			c.recorded = records[0]
+			break
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	return c.recordingExecClient.ExecStream(ctx, options)
+}
+
+func TestStartTerminalRecordsSessionBytes(t *testing.T) {
+	defer func(original time.Duration) { sessionRecordInterval = original }(sessionRecordInterval)
+	sessionRecordInterval = time.Millisecond * 10
+
+	store := NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	client := &sessionBytesClient{store: store}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithDisableSessionFile(true),
+		WithSessionStore(store),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, client.recorded.BytesIn, uint64(4))
+	assert.Equal(t, client.recorded.BytesOut, uint64(42))
+}
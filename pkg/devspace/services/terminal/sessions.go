@@ -0,0 +1,163 @@
+package terminal
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+)
+
+// SessionInfo describes a screen or tmux session running inside a container
+type SessionInfo struct {
+	Namespace string
+	Pod       string
+	Container string
+
+	// Multiplexer is either MultiplexerScreen or MultiplexerTmux
+	Multiplexer string
+	Name        string
+
+	// Created is the creation time of the session, zero if unknown
+	Created  time.Time
+	Attached bool
+}
+
+// listSessionsScript prints the screen and tmux sessions of the container, each
+// prefixed by a marker line so that we know which multiplexer the output belongs to
+const listSessionsScript = `if command -v screen >/dev/null 2>&1; then
+  echo "# screen"
+  screen -list
+fi
+if command -v tmux >/dev/null 2>&1; then
+  echo "# tmux"
+  tmux list-sessions -F '#{session_name} #{session_created} #{session_attached}' 2>/dev/null
+fi
+true`
+
+// screenTimeLayouts are the time formats different screen versions use in screen -list
+var screenTimeLayouts = []string{
+	"01/02/2006 03:04:05 PM",
+	"01/02/06 15:04:05",
+	"01/02/2006 15:04:05",
+}
+
+// ListTerminalSessions returns the screen and tmux sessions of all containers in the namespace matched by podSelector
+func ListTerminalSessions(ctx devspacecontext.Context, namespace string, podSelector selector.Selector) ([]SessionInfo, error) {
+	ctx = withExecLimit(ctx)
+	if namespace != "" {
+		podSelector.Namespace = namespace
+	}
+	if podSelector.FilterContainer == nil {
+		podSelector.FilterContainer = selector.FilterNonRunningContainers
+	}
+
+	containers, err := selector.NewFilter(ctx.KubeClient()).SelectContainers(ctx.Context(), podSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "select containers")
+	}
+
+	sessions := []SessionInfo{}
+	for _, container := range containers {
+		stdout, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{"sh", "-c", listSessionsScript}, nil)
+		if err != nil {
+			ctx.Log().Debugf("Error listing sessions in %s:%s: %s %v", container.Pod.Name, container.Container.Name, string(stderr), err)
+			continue
+		}
+
+		for _, session := range parseSessions(container.Pod.Name, container.Container.Name, string(stdout)) {
+			session.Namespace = container.Pod.Namespace
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
+
+func parseSessions(pod string, container string, output string) []SessionInfo {
+	sessions := []SessionInfo{}
+	multiplexer := ""
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# ") {
+			multiplexer = strings.TrimPrefix(line, "# ")
+			continue
+		}
+
+		var session *SessionInfo
+		switch multiplexer {
+		case MultiplexerScreen:
+			session = parseScreenSession(line)
+		case MultiplexerTmux:
+			session = parseTmuxSession(line)
+		}
+		if session == nil {
+			continue
+		}
+
+		session.Pod = pod
+		session.Container = container
+		session.Multiplexer = multiplexer
+		sessions = append(sessions, *session)
+	}
+
+	return sessions
+}
+
+// parseScreenSession parses a session line of screen -list, e.g.
+// "	1234.dev	(10/16/2026 07:36:20 AM)	(Detached)"
+func parseScreenSession(line string) *SessionInfo {
+	if !strings.HasPrefix(line, "\t") {
+		return nil
+	}
+
+	fields := strings.Split(strings.TrimSpace(line), "\t")
+	id := strings.SplitN(fields[0], ".", 2)
+	if len(id) != 2 {
+		return nil
+	}
+
+	session := &SessionInfo{Name: id[1]}
+	for _, field := range fields[1:] {
+		field = strings.Trim(field, "()")
+		if strings.Contains(strings.ToLower(field), "attached") {
+			session.Attached = true
+			continue
+		}
+
+		for _, layout := range screenTimeLayouts {
+			created, err := time.ParseInLocation(layout, field, time.Local)
+			if err == nil {
+				session.Created = created
+				break
+			}
+		}
+	}
+
+	return session
+}
+
+// parseTmuxSession parses a session line of tmux list-sessions in the
+// format "#{session_name} #{session_created} #{session_attached}"
+func parseTmuxSession(line string) *SessionInfo {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return nil
+	}
+
+	session := &SessionInfo{Name: fields[0]}
+	created, err := strconv.ParseInt(fields[1], 10, 64)
+	if err == nil {
+		session.Created = time.Unix(created, 0)
+	}
+	attached, err := strconv.Atoi(fields[2])
+	if err == nil {
+		session.Attached = attached > 0
+	}
+
+	return session
+}
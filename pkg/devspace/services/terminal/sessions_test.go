@@ -0,0 +1,38 @@
+package terminal
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestParseSessions(t *testing.T) {
+	output := `# screen
+There are screens on:
+	1234.dev	(10/16/2026 07:36:20 AM)	(Detached)
+	5678.enter	(Attached)
+2 Sockets in /run/screen/S-root.
+# tmux
+main 1792136287 1
+`
+
+	sessions := parseSessions("pod", "container", output)
+	assert.Equal(t, len(sessions), 3)
+
+	assert.Equal(t, sessions[0].Multiplexer, "screen")
+	assert.Equal(t, sessions[0].Name, "dev")
+	assert.Equal(t, sessions[0].Pod, "pod")
+	assert.Equal(t, sessions[0].Container, "container")
+	assert.Equal(t, sessions[0].Attached, false)
+	assert.Equal(t, sessions[0].Created.Equal(time.Date(2026, 10, 16, 7, 36, 20, 0, time.Local)), true)
+
+	assert.Equal(t, sessions[1].Name, "enter")
+	assert.Equal(t, sessions[1].Attached, true)
+	assert.Equal(t, sessions[1].Created.IsZero(), true)
+
+	assert.Equal(t, sessions[2].Multiplexer, "tmux")
+	assert.Equal(t, sessions[2].Name, "main")
+	assert.Equal(t, sessions[2].Attached, true)
+	assert.Equal(t, sessions[2].Created.Unix(), int64(1792136287))
+}
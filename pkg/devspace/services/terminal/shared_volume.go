@@ -0,0 +1,193 @@
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/survey"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// SharedVolumeName is the name of the emptyDir volume shared between the ephemeral and the target container
+const SharedVolumeName = "devspace-shared"
+
+// DefaultSharedVolumePath is the path the shared volume is mounted at in both containers
+const DefaultSharedVolumePath = "/devspace-shared"
+
+// hasSharedVolume checks if the shared volume is mounted into the container of the pod
+func hasSharedVolume(pod *corev1.Pod, containerName string) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Name != containerName {
+			continue
+		}
+
+		for _, volumeMount := range container.VolumeMounts {
+			if volumeMount.Name == SharedVolumeName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sharedVolumePatch returns a strategic merge patch for a pod template that adds the shared
+// volume and mounts it into the given container
+func sharedVolumePatch(containerName string, mountPath string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{
+							Name:         SharedVolumeName,
+							VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name: containerName,
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      SharedVolumeName,
+									MountPath: mountPath,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// sharedVolumeRevertPatch returns a strategic merge patch for a pod template that removes the
+// shared volume and its mount from the given container again
+func sharedVolumeRevertPatch(containerName string, mountPath string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"volumes": []map[string]interface{}{
+						{"name": SharedVolumeName, "$patch": "delete"},
+					},
+					"containers": []map[string]interface{}{
+						{
+							"name":         containerName,
+							"volumeMounts": []map[string]interface{}{{"mountPath": mountPath, "$patch": "delete"}},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// mountSharedVolume adds the shared volume to the workload owning the pod of the target container after the user
+// confirmed it. Volumes of a running pod cannot be changed, so the workload replaces the pod and the target container
+// is restarted. The returned function reverts the patch again, which replaces the pod once more.
+func mountSharedVolume(ctx devspacecontext.Context, target *selector.SelectedPodContainer, mountPath string) (func(), error) {
+	patch, err := sharedVolumePatch(target.Container.Name, mountPath)
+	if err != nil {
+		return nil, err
+	}
+	revertPatch, err := sharedVolumeRevertPatch(target.Container.Name, mountPath)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := metav1.GetControllerOf(target.Pod)
+	if owner != nil && owner.Kind == "ReplicaSet" {
+		replicaSet, err := ctx.KubeClient().KubeClient().AppsV1().ReplicaSets(target.Pod.Namespace).Get(ctx.Context(), owner.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "get replica set")
+		}
+
+		owner = metav1.GetControllerOf(replicaSet)
+	}
+	if owner == nil {
+		return nil, fmt.Errorf("cannot mount a shared volume into pod %s, because it is not owned by a deployment or statefulset", target.Pod.Name)
+	} else if owner.Kind != "Deployment" && owner.Kind != "StatefulSet" {
+		return nil, fmt.Errorf("cannot mount a shared volume into pod %s, because it is owned by %s %s", target.Pod.Name, owner.Kind, owner.Name)
+	}
+
+	answer, err := ctx.Log().Question(&survey.QuestionOptions{
+		Question:     fmt.Sprintf("Mounting the shared volume %s patches %s %s, which replaces pod %s and restarts container %s. The patch is reverted when the terminal ends. Do you want to continue?", SharedVolumeName, owner.Kind, owner.Name, target.Pod.Name, target.Container.Name),
+		DefaultValue: "No",
+		Options:      []string{"Yes", "No"},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "confirm patching %s %s", owner.Kind, owner.Name)
+	} else if answer != "Yes" {
+		return nil, fmt.Errorf("mounting the shared volume into container %s was declined", target.Container.Name)
+	}
+
+	err = patchOwner(ctx.Context(), ctx, target.Pod.Namespace, owner, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		ctx.Log().Infof("Removing the shared volume %s from %s %s", SharedVolumeName, owner.Kind, owner.Name)
+
+		// the session context might already be cancelled at this point, so we use a separate one
+		err := patchOwner(context.Background(), ctx, target.Pod.Namespace, owner, revertPatch)
+		if err != nil {
+			ctx.Log().Warnf("Error removing the shared volume %s from %s %s, please remove it manually: %v", SharedVolumeName, owner.Kind, owner.Name, err)
+		}
+	}, nil
+}
+
+// patchOwner applies the strategic merge patch to the deployment or statefulset
+func patchOwner(patchCtx context.Context, ctx devspacecontext.Context, namespace string, owner *metav1.OwnerReference, patch []byte) error {
+	var err error
+	switch owner.Kind {
+	case "Deployment":
+		_, err = ctx.KubeClient().KubeClient().AppsV1().Deployments(namespace).Patch(patchCtx, owner.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case "StatefulSet":
+		_, err = ctx.KubeClient().KubeClient().AppsV1().StatefulSets(namespace).Patch(patchCtx, owner.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "patch %s %s", owner.Kind, owner.Name)
+	}
+
+	return nil
+}
+
+// waitForSharedVolume waits until a pod that replaces the pod of the target container has the shared volume mounted
+func waitForSharedVolume(ctx devspacecontext.Context, target *selector.SelectedPodContainer) (*selector.SelectedPodContainer, error) {
+	labelSelector, err := followLabelSelector(target.Pod)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Log().Infof("Waiting for a pod with the shared volume %s to replace pod %s...", SharedVolumeName, target.Pod.Name)
+	var replacement *selector.SelectedPodContainer
+	err = wait.PollUntilContextTimeout(ctx.Context(), ephemeralContainerInterval, ephemeralContainerTimeout, true, func(waitCtx context.Context) (bool, error) {
+		pods, err := ctx.KubeClient().KubeClient().CoreV1().Pods(target.Pod.Namespace).List(waitCtx, metav1.ListOptions{LabelSelector: labelSelector.String()})
+		if err != nil {
+			return false, err
+		}
+
+		for i := range pods.Items {
+			replacement = replacementContainer(target, &pods.Items[i])
+			if replacement != nil && hasSharedVolume(replacement.Pod, target.Container.Name) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "wait for pod %s to be replaced", target.Pod.Name)
+	}
+
+	return replacement, nil
+}
@@ -0,0 +1,134 @@
+package terminal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	logtesting "github.com/loft-sh/devspace/pkg/util/log/testing"
+	"github.com/loft-sh/devspace/pkg/util/ptr"
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMountSharedVolume(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "testNamespace"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "app"}, {Name: "sidecar", Image: "sidecar"}},
+				},
+			},
+		},
+	}
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "app-1234",
+			Namespace:       "testNamespace",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "app", Controller: ptr.Bool(true)}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "app-1234-abcd",
+			Namespace:       "testNamespace",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "app-1234", Controller: ptr.Bool(true)}},
+		},
+		Spec: deployment.Spec.Template.Spec,
+	}
+	kubeClient := fake.NewSimpleClientset(deployment, replicaSet, pod)
+	logger := logtesting.NewFakeLogger()
+	ctx := devspacecontext.NewContext(context.Background(), nil, logger).WithKubeClient(&kubectltesting.Client{Client: kubeClient})
+	assert.Equal(t, hasSharedVolume(pod, "app"), false)
+
+	// nothing is patched without confirmation
+	logger.Survey.SetNextAnswer("No")
+	_, err := mountSharedVolume(ctx, &selector.SelectedPodContainer{Pod: pod, Container: &pod.Spec.Containers[0]}, DefaultSharedVolumePath)
+	assert.ErrorContains(t, err, "was declined")
+	unchanged, err := kubeClient.AppsV1().Deployments("testNamespace").Get(context.Background(), "app", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(unchanged.Spec.Template.Spec.Volumes), 0)
+
+	logger.Survey.SetNextAnswer("Yes")
+	revert, err := mountSharedVolume(ctx, &selector.SelectedPodContainer{Pod: pod, Container: &pod.Spec.Containers[0]}, DefaultSharedVolumePath)
+	assert.NilError(t, err)
+
+	patched, err := kubeClient.AppsV1().Deployments("testNamespace").Get(context.Background(), "app", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, patched.Spec.Template.Spec.Volumes, []corev1.Volume{{Name: SharedVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}})
+	assert.Equal(t, len(patched.Spec.Template.Spec.Containers), 2)
+	assert.Equal(t, patched.Spec.Template.Spec.Containers[0].Image, "app")
+	assert.DeepEqual(t, patched.Spec.Template.Spec.Containers[0].VolumeMounts, []corev1.VolumeMount{{Name: SharedVolumeName, MountPath: DefaultSharedVolumePath}})
+	assert.Equal(t, len(patched.Spec.Template.Spec.Containers[1].VolumeMounts), 0)
+
+	pod.Spec = patched.Spec.Template.Spec
+	assert.Equal(t, hasSharedVolume(pod, "app"), true)
+	ephemeralContainer := newEphemeralContainer(&selector.SelectedPodContainer{Pod: pod, Container: &pod.Spec.Containers[0]}, EphemeralContainerOptions{MountSharedVolume: true})
+	assert.DeepEqual(t, ephemeralContainer.VolumeMounts, patched.Spec.Template.Spec.Containers[0].VolumeMounts)
+
+	// the patch is reverted once the terminal ended
+	revert()
+	reverted, err := kubeClient.AppsV1().Deployments("testNamespace").Get(context.Background(), "app", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(reverted.Spec.Template.Spec.Volumes), 0)
+	assert.Equal(t, len(reverted.Spec.Template.Spec.Containers), 2)
+	assert.Equal(t, reverted.Spec.Template.Spec.Containers[0].Image, "app")
+	assert.Equal(t, len(reverted.Spec.Template.Spec.Containers[0].VolumeMounts), 0)
+}
+
+func TestStartEphemeralContainerSharedVolume(t *testing.T) {
+	defer func(original time.Duration) { ephemeralContainerInterval = original }(ephemeralContainerInterval)
+	ephemeralContainerInterval = time.Millisecond * 10
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "testNamespace"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app"}}},
+			},
+		},
+	}
+	owner := []metav1.OwnerReference{{Kind: "Deployment", Name: "app", Controller: ptr.Bool(true)}}
+	newSharedVolumePod := func(name string, uid types.UID, volume bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "testNamespace", UID: uid, Labels: map[string]string{"app": "app"}, OwnerReferences: owner},
+			Spec:       *deployment.Spec.Template.Spec.DeepCopy(),
+			Status: corev1.PodStatus{
+				ContainerStatuses:          []corev1.ContainerStatus{{Name: "app", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}},
+				EphemeralContainerStatuses: []corev1.ContainerStatus{{Name: DefaultEphemeralContainerName, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}},
+			},
+		}
+		if volume {
+			pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: SharedVolumeName, MountPath: DefaultSharedVolumePath}}
+		}
+		return pod
+	}
+	original := newSharedVolumePod("app-old", "old", false)
+
+	// the replacement that is created by the rollout of the patched deployment
+	kubeClient := fake.NewSimpleClientset(deployment, original, newSharedVolumePod("app-new", "new", true))
+	logger := logtesting.NewFakeLogger()
+	logger.Survey.SetNextAnswer("Yes")
+	ctx := devspacecontext.NewContext(context.Background(), nil, logger).WithKubeClient(&kubectltesting.Client{Client: kubeClient})
+
+	container, revert, err := startEphemeralContainer(ctx, &selector.SelectedPodContainer{Pod: original, Container: &original.Spec.Containers[0]}, EphemeralContainerOptions{MountSharedVolume: true})
+	assert.NilError(t, err)
+	assert.Equal(t, container.Pod.Name, "app-new")
+	assert.Equal(t, container.Container.Name, DefaultEphemeralContainerName)
+	patched, err := kubeClient.AppsV1().Deployments("testNamespace").Get(context.Background(), "app", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(patched.Spec.Template.Spec.Volumes), 1)
+
+	revert()
+	reverted, err := kubeClient.AppsV1().Deployments("testNamespace").Get(context.Background(), "app", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(reverted.Spec.Template.Spec.Volumes), 0)
+}
@@ -0,0 +1,176 @@
+package terminal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ShellSh runs the terminal command with sh -c, which is used by linux containers
+	ShellSh = "sh"
+
+	// ShellCmd runs the terminal command with cmd.exe /S /C, which is used by windows containers
+	ShellCmd = "cmd"
+
+	// ShellPowershell runs the terminal command with powershell -NoExit, which is used by windows containers
+	ShellPowershell = "powershell"
+)
+
+// osLabel is the well-known label of the operating system of a node
+const osLabel = "kubernetes.io/os"
+
+// isWindowsShell checks if the shell is used for windows containers
+func isWindowsShell(shell string) bool {
+	return shell == ShellCmd || shell == ShellPowershell
+}
+
+// detectedShells remembers the operating system detected per container, so that restarts and
+// other terminals to the same container don't probe it again
+var detectedShells = &shellCache{windows: map[string]bool{}}
+
+type shellCache struct {
+	windows map[string]bool
+	mutex   sync.Mutex
+}
+
+func (c *shellCache) get(key string) (bool, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	windows, ok := c.windows[key]
+	return windows, ok
+}
+
+func (c *shellCache) set(key string, windows bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.windows[key] = windows
+}
+
+// detectShell detects the operating system of the container and returns sh for linux containers and
+// windowsShell, which defaults to cmd, for windows containers. If the operating system is not known from the
+// pod spec or node, the container is probed for sh first and for cmd afterwards. The result is cached per
+// container. If both probes fail, e.g. because of a transient connection error, sh is used.
+func detectShell(ctx devspacecontext.Context, container *selector.SelectedPodContainer, windowsShell string) string {
+	if windowsShell == "" {
+		windowsShell = ShellCmd
+	}
+
+	key := screenProbeKey(container)
+	windows, ok := detectedShells.get(key)
+	if !ok {
+		windows, ok = detectWindows(ctx, container)
+		if !ok {
+			ctx.Log().Debugf("Cannot detect the operating system of container %s in pod %s, using sh", container.Container.Name, container.Pod.Name)
+			return ShellSh
+		}
+
+		detectedShells.set(key, windows)
+	}
+	if windows {
+		return windowsShell
+	}
+
+	return ShellSh
+}
+
+// detectWindows returns if the container is a windows container and false as second value if the
+// operating system could not be detected
+func detectWindows(ctx devspacecontext.Context, container *selector.SelectedPodContainer) (bool, bool) {
+	switch containerOS(ctx, container.Pod) {
+	case string(corev1.Linux):
+		return false, true
+	case string(corev1.Windows):
+		return true, true
+	}
+
+	_, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{"sh", "-c", "true"}, nil)
+	if err == nil {
+		return false, true
+	}
+	ctx.Log().Debugf("Error probing for sh in container %s: %s %v", container.Container.Name, string(stderr), err)
+
+	_, stderr, err = ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{"cmd", "/c", "ver"}, nil)
+	if err != nil {
+		ctx.Log().Debugf("Error probing for cmd in container %s: %s %v", container.Container.Name, string(stderr), err)
+		return false, false
+	}
+
+	ctx.Log().Debugf("Container %s has no sh but cmd, assuming a windows container", container.Container.Name)
+	return true, true
+}
+
+// containerOS returns the operating system of the pod from its spec or node, or an empty string if it is unknown
+func containerOS(ctx devspacecontext.Context, pod *corev1.Pod) string {
+	if pod.Spec.OS != nil && pod.Spec.OS.Name != "" {
+		return string(pod.Spec.OS.Name)
+	} else if pod.Spec.NodeSelector[osLabel] != "" {
+		return pod.Spec.NodeSelector[osLabel]
+	} else if pod.Spec.NodeName == "" {
+		return ""
+	}
+
+	node, err := ctx.KubeClient().KubeClient().CoreV1().Nodes().Get(ctx.Context(), pod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		ctx.Log().Debugf("Error retrieving node %s of pod %s: %v", pod.Spec.NodeName, pod.Name, err)
+		return ""
+	}
+
+	return node.Labels[osLabel]
+}
+
+// getWindowsCommand returns the command for windows containers. Environment variables are set within
+// the command, as env is not available, and the work dir might be on a different drive.
+func getWindowsCommand(shell string, workDir string, command string, envVars map[string]string) []string {
+	keys := make([]string, 0, len(envVars))
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if shell == ShellPowershell {
+		parts := []string{}
+		for _, key := range keys {
+			parts = append(parts, fmt.Sprintf("$env:%s=%s", key, powershellQuote(envVars[key])))
+		}
+		if workDir != "" {
+			parts = append(parts, "Set-Location "+powershellQuote(workDir))
+		}
+		if command != "" {
+			parts = append(parts, command)
+		}
+		if len(parts) == 0 {
+			return []string{"powershell", "-NoLogo", "-NoExit"}
+		}
+
+		return []string{"powershell", "-NoLogo", "-NoExit", "-Command", strings.Join(parts, "; ")}
+	}
+
+	if command == "" {
+		command = "cmd.exe"
+	}
+
+	parts := []string{}
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf(`set "%s=%s"`, key, envVars[key]))
+	}
+	if workDir != "" {
+		parts = append(parts, fmt.Sprintf(`cd /d "%s"`, workDir))
+	}
+	parts = append(parts, command)
+
+	return []string{"cmd.exe", "/S", "/C", strings.Join(parts, " && ")}
+}
+
+// powershellQuote quotes the value as powershell string literal
+func powershellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
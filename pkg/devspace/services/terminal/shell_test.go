@@ -0,0 +1,174 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// shellExecClient only succeeds for commands of the given shells
+type shellExecClient struct {
+	kubectltesting.Client
+
+	shells map[string]bool
+}
+
+func (c *shellExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	if !c.shells[command[0]] {
+		return nil, []byte("executable file not found"), fmt.Errorf("command terminated with exit code 127")
+	}
+
+	return nil, nil, nil
+}
+
+type detectShellTestCase struct {
+	name         string
+	podSpec      corev1.PodSpec
+	nodeOS       string
+	shells       map[string]bool
+	windowsShell string
+
+	expected string
+}
+
+func TestDetectShell(t *testing.T) {
+	testCases := []detectShellTestCase{
+		{
+			name:         "Windows pod os",
+			podSpec:      corev1.PodSpec{OS: &corev1.PodOS{Name: corev1.Windows}},
+			windowsShell: ShellPowershell,
+			expected:     ShellPowershell,
+		},
+		{
+			name:     "Linux node selector",
+			podSpec:  corev1.PodSpec{NodeSelector: map[string]string{osLabel: "linux"}},
+			expected: ShellSh,
+		},
+		{
+			name:     "Windows node",
+			podSpec:  corev1.PodSpec{NodeName: "node"},
+			nodeOS:   "windows",
+			expected: ShellCmd,
+		},
+		{
+			name:     "Linux container probed",
+			shells:   map[string]bool{"sh": true},
+			expected: ShellSh,
+		},
+		{
+			name:     "Windows container probed",
+			shells:   map[string]bool{"cmd": true},
+			expected: ShellCmd,
+		},
+		{
+			name:     "Unknown operating system",
+			expected: ShellSh,
+		},
+	}
+
+	defer func(original *shellCache) { detectedShells = original }(detectedShells)
+	for _, testCase := range testCases {
+		detectedShells = &shellCache{windows: map[string]bool{}}
+		kubeClient := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: map[string]string{osLabel: testCase.nodeOS}}})
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&shellExecClient{Client: kubectltesting.Client{Client: kubeClient}, shells: testCase.shells})
+		container := &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}, Spec: testCase.podSpec},
+			Container: &corev1.Container{Name: "test"},
+		}
+
+		shell := detectShell(ctx, container, testCase.windowsShell)
+		assert.Equal(t, shell, testCase.expected, "Unexpected shell in "+testCase.name)
+	}
+}
+
+// countingShellExecClient counts the probes and only succeeds for cmd
+type countingShellExecClient struct {
+	kubectltesting.Client
+
+	probes int
+}
+
+func (c *countingShellExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	c.probes++
+	if command[0] != "cmd" {
+		return nil, nil, fmt.Errorf("command terminated with exit code 127")
+	}
+
+	return nil, nil, nil
+}
+
+func TestDetectShellCached(t *testing.T) {
+	defer func(original *shellCache) { detectedShells = original }(detectedShells)
+	detectedShells = &shellCache{windows: map[string]bool{}}
+
+	client := &countingShellExecClient{Client: kubectltesting.Client{Client: fake.NewSimpleClientset()}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "uid"}},
+		Container: &corev1.Container{Name: "test"},
+	}
+
+	assert.Equal(t, detectShell(ctx, container, ""), ShellCmd)
+	assert.Equal(t, detectShell(ctx, container, ShellPowershell), ShellPowershell)
+	assert.Equal(t, client.probes, 2)
+
+	// a recreated pod is probed again
+	container.Pod.UID = "other"
+	assert.Equal(t, detectShell(ctx, container, ""), ShellCmd)
+	assert.Equal(t, client.probes, 4)
+}
+
+type getWindowsCommandTestCase struct {
+	name    string
+	shell   string
+	workDir string
+	command string
+	envVars map[string]string
+
+	expected []string
+}
+
+func TestGetWindowsCommand(t *testing.T) {
+	testCases := []getWindowsCommandTestCase{
+		{
+			name:     "Default cmd command",
+			shell:    ShellCmd,
+			expected: []string{"cmd.exe", "/S", "/C", "cmd.exe"},
+		},
+		{
+			name:     "Cmd with work dir and env vars",
+			shell:    ShellCmd,
+			workDir:  `D:\app`,
+			command:  "dir",
+			envVars:  map[string]string{"DEVSPACE_POD": "test", "A": "b c"},
+			expected: []string{"cmd.exe", "/S", "/C", `set "A=b c" && set "DEVSPACE_POD=test" && cd /d "D:\app" && dir`},
+		},
+		{
+			name:     "Default powershell command",
+			shell:    ShellPowershell,
+			expected: []string{"powershell", "-NoLogo", "-NoExit"},
+		},
+		{
+			name:     "Powershell with work dir and env vars",
+			shell:    ShellPowershell,
+			workDir:  `D:\app`,
+			command:  "Get-ChildItem",
+			envVars:  map[string]string{"DEVSPACE_POD": "test", "A": "it's"},
+			expected: []string{"powershell", "-NoLogo", "-NoExit", "-Command", `$env:A='it''s'; $env:DEVSPACE_POD='test'; Set-Location 'D:\app'; Get-ChildItem`},
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.DeepEqual(t, getWindowsCommand(testCase.shell, testCase.workDir, testCase.command, testCase.envVars), testCase.expected)
+	}
+}
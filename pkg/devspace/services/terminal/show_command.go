@@ -0,0 +1,57 @@
+package terminal
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// redactedValue replaces the values of secrets in the shown command
+const redactedValue = "<redacted>"
+
+// secretNameParts are parts of environment variable names whose values are considered secrets
+var secretNameParts = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL", "AUTH", "PRIVATE"}
+
+// isSecretName returns true if the name of the environment variable looks like it holds a secret
+func isSecretName(name string) bool {
+	name = strings.ToUpper(name)
+	for _, part := range secretNameParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactCommand returns the command as shell words with the values of environment variables
+// whose names look like secrets replaced by redactedValue
+func redactCommand(command []string, envs ...map[string]string) string {
+	secrets := []string{}
+	for _, env := range envs {
+		for name, value := range env {
+			if value != "" && isSecretName(name) {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+
+	// longer secrets are replaced first, so that a secret containing another one is redacted completely
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+
+	words := make([]string, 0, len(command))
+	for _, arg := range command {
+		for _, secret := range secrets {
+			// values in nested commands are quoted, see getCommand
+			arg = strings.ReplaceAll(arg, shellquote.Join(secret), redactedValue)
+			arg = strings.ReplaceAll(arg, secret, redactedValue)
+		}
+
+		words = append(words, arg)
+	}
+
+	return shellquote.Join(words...)
+}
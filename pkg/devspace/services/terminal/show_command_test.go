@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type redactCommandTestCase struct {
+	name    string
+	command []string
+	env     map[string]string
+
+	expected string
+}
+
+func TestRedactCommand(t *testing.T) {
+	testCases := []redactCommandTestCase{
+		{
+			name:     "No env",
+			command:  []string{"sh", "-c", "exec bash"},
+			expected: `sh -c 'exec bash'`,
+		},
+		{
+			name:     "Secret env",
+			command:  getCommand(&latest.DevContainer{Terminal: &latest.Terminal{Env: map[string]string{"API_TOKEN": "abc123", "EDITOR": "vim"}}}, "bash", ""),
+			env:      map[string]string{"API_TOKEN": "abc123", "EDITOR": "vim"},
+			expected: `sh -c 'exec env API_TOKEN=<redacted> EDITOR=vim sh -c bash'`,
+		},
+		{
+			name:     "Quoted secret",
+			command:  getCommand(&latest.DevContainer{Terminal: &latest.Terminal{Env: map[string]string{"DB_PASSWORD": "it's"}}}, "bash", ""),
+			env:      map[string]string{"DB_PASSWORD": "it's"},
+			expected: `sh -c 'exec env DB_PASSWORD=<redacted> sh -c bash'`,
+		},
+		{
+			name:     "Secret contained in another secret",
+			command:  []string{"login", "--token=secret-value"},
+			env:      map[string]string{"TOKEN": "secret-value", "SECRET": "secret"},
+			expected: `login --token=\<redacted\>`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, redactCommand(testCase.command, testCase.env), testCase.expected, "Unexpected command in test case %s", testCase.name)
+	}
+}
+
+func TestStartTerminalShowCommand(t *testing.T) {
+	out := &bytes.Buffer{}
+	client := &recordingExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Command:            "bash",
+		Shell:              "sh",
+		Env:                map[string]string{"GITHUB_TOKEN": "ghp_123"},
+		DisableScreen:      true,
+		DisableSessionFile: true,
+		ShowCommand:        true,
+	}}
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(out.String(), "Executing command in container app: env DEVSPACE_CONTAINER=app"), "Expected command, got %s", out.String())
+	assert.Assert(t, strings.Contains(out.String(), "exec env GITHUB_TOKEN=<redacted>"), "Expected redacted token, got %s", out.String())
+	assert.Assert(t, !strings.Contains(out.String(), "ghp_123"), "Expected no token, got %s", out.String())
+}
@@ -0,0 +1,33 @@
+package terminal
+
+import (
+	"sync"
+
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// fixedSizeQueue reports a fixed terminal size once, which is used if there
+// is no local terminal to negotiate the size with
+type fixedSizeQueue struct {
+	size remotecommand.TerminalSize
+	once sync.Once
+}
+
+func newFixedSizeQueue(cols, rows uint16) *fixedSizeQueue {
+	return &fixedSizeQueue{
+		size: remotecommand.TerminalSize{
+			Width:  cols,
+			Height: rows,
+		},
+	}
+}
+
+// Next returns the fixed size on the first call and nil afterwards, which stops the size updates
+func (q *fixedSizeQueue) Next() *remotecommand.TerminalSize {
+	var size *remotecommand.TerminalSize
+	q.once.Do(func() {
+		size = &q.size
+	})
+
+	return size
+}
@@ -0,0 +1,209 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/pkg/errors"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// SocketForward forwards a local UNIX socket, e.g. of a docker daemon or ssh agent, into the
+// container. The forwarding requires socat to be present in the container.
+type SocketForward struct {
+	// LocalPath is the path of the local socket
+	LocalPath string
+
+	// RemotePath is the path the socket is made available at in the container
+	RemotePath string
+}
+
+// socketForwardRetryDelay is the time to wait before retrying a failed socket forwarding
+var socketForwardRetryDelay = time.Second
+
+// maxSocketForwardRetries is the number of times in a row a socket forwarding that failed before
+// a connection was accepted is retried
+const maxSocketForwardRetries = 5
+
+// socatAcceptedMessage is logged by socat -d -d once it accepted a connection on the remote socket
+var socatAcceptedMessage = []byte("accepting connection from")
+
+// forwardSocket forwards connections to the remote socket to the local socket until ctx is done.
+// Every connection is served by its own socat process, which listens on the remote socket until it
+// accepted a connection. Then the local socket is dialed and the next socat process is started, so
+// that multiple connections are forwarded at the same time.
+func forwardSocket(ctx context.Context, client kubectl.Client, container *selector.SelectedPodContainer, forward SocketForward, log log.Logger) {
+	defer removeRemoteSocket(client, container, forward, log)
+
+	failures := 0
+	for ctx.Err() == nil {
+		accepted := make(chan struct{})
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- forwardSocketConnection(ctx, client, container, forward, accepted)
+		}()
+
+		var err error
+		select {
+		case <-accepted:
+			failures = 0
+			go func() {
+				err := <-errChan
+				if err != nil && ctx.Err() == nil {
+					log.Debugf("Error forwarding connection of socket %s to %s: %v", forward.LocalPath, forward.RemotePath, err)
+				}
+			}()
+			continue
+		case err = <-errChan:
+		}
+
+		select {
+		case <-accepted:
+			failures = 0
+			continue
+		default:
+		}
+		if ctx.Err() != nil {
+			return
+		} else if exitError, ok := errors.Cause(err).(kubectlExec.CodeExitError); ok && exitError.Code == 127 {
+			log.Warnf("Cannot forward socket %s to %s, because socat is not installed in the container", forward.LocalPath, forward.RemotePath)
+			return
+		}
+
+		failures++
+		if failures > maxSocketForwardRetries {
+			log.Warnf("Stopped forwarding socket %s to %s after %d failed attempts: %v", forward.LocalPath, forward.RemotePath, failures, err)
+			return
+		}
+
+		log.Debugf("Error forwarding socket %s to %s: %v", forward.LocalPath, forward.RemotePath, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(socketForwardRetryDelay):
+		}
+	}
+}
+
+// forwardSocketConnection starts socat in the container, which serves a single connection to the remote socket.
+// The local socket is dialed once socat accepted the connection, which closes accepted.
+func forwardSocketConnection(ctx context.Context, client kubectl.Client, container *selector.SelectedPodContainer, forward SocketForward, accepted chan struct{}) error {
+	conn := &lazySocketConn{
+		ctx:    ctx,
+		path:   forward.LocalPath,
+		dialed: make(chan struct{}),
+	}
+	defer conn.Close()
+
+	stderr := &socatStderr{
+		accepted: func() {
+			close(accepted)
+			conn.dial()
+		},
+	}
+	err := client.ExecStream(ctx, &kubectl.ExecStreamOptions{
+		Pod:       container.Pod,
+		Container: container.Container.Name,
+		Command:   []string{"socat", "-d", "-d", "UNIX-LISTEN:" + forward.RemotePath + ",unlink-early", "STDIO"},
+		Stdin:     conn,
+		Stdout:    conn,
+		Stderr:    stderr,
+	})
+	if err != nil && stderr.buffer.Len() > 0 {
+		return errors.Wrap(err, stderr.buffer.String())
+	}
+
+	return err
+}
+
+// removeRemoteSocket removes the remote socket once the forwarding has ended. The socket cannot be
+// removed by socat itself, as the next socat process already listens on it when a connection is closed.
+func removeRemoteSocket(client kubectl.Client, container *selector.SelectedPodContainer, forward SocketForward, log log.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	_, stderr, err := client.ExecBuffered(ctx, container.Pod, container.Container.Name, []string{"rm", "-f", forward.RemotePath}, nil)
+	if err != nil {
+		log.Debugf("Error removing forwarded socket %s: %s %v", forward.RemotePath, string(stderr), err)
+	}
+}
+
+// socatStderr collects the output of socat and calls accepted once socat accepted a connection
+type socatStderr struct {
+	buffer   bytes.Buffer
+	accepted func()
+	once     sync.Once
+}
+
+func (s *socatStderr) Write(p []byte) (int, error) {
+	s.buffer.Write(p)
+	if bytes.Contains(s.buffer.Bytes(), socatAcceptedMessage) {
+		s.once.Do(s.accepted)
+	}
+
+	return len(p), nil
+}
+
+// lazySocketConn dials the local socket once the remote connection was accepted or data was received from it.
+// Reads block until the socket was dialed, so no input is sent to socat before it accepted a connection.
+type lazySocketConn struct {
+	ctx  context.Context
+	path string
+
+	conn   net.Conn
+	err    error
+	dialed chan struct{}
+	once   sync.Once
+}
+
+func (c *lazySocketConn) dial() {
+	c.once.Do(func() {
+		c.conn, c.err = net.Dial("unix", c.path)
+		if c.err != nil {
+			c.err = errors.Wrap(c.err, "dial local socket")
+		}
+
+		close(c.dialed)
+	})
+}
+
+func (c *lazySocketConn) Read(p []byte) (int, error) {
+	select {
+	case <-c.ctx.Done():
+		return 0, io.EOF
+	case <-c.dialed:
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.conn.Read(p)
+}
+
+func (c *lazySocketConn) Write(p []byte) (int, error) {
+	c.dial()
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.conn.Write(p)
+}
+
+func (c *lazySocketConn) Close() error {
+	c.once.Do(func() {
+		c.err = io.EOF
+		close(c.dialed)
+	})
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+
+	return nil
+}
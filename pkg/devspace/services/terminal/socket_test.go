@@ -0,0 +1,145 @@
+package terminal
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// socatExecClient acts as a remote socat that accepts a connection to the forwarded socket once the
+// test sends to accept, reads a line and keeps the connection open until the context is done
+type socatExecClient struct {
+	kubectltesting.Client
+
+	commands chan []string
+	accept   chan struct{}
+	lines    chan string
+	removed  chan []string
+}
+
+func (c *socatExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.commands <- options.Command
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-c.accept:
+	}
+
+	_, _ = options.Stderr.Write([]byte("2024/01/01 00:00:00 socat[1] N accepting connection from AF=1 \"<anon>\"\n"))
+	line, err := bufio.NewReader(options.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	c.lines <- line
+	<-ctx.Done()
+	return nil
+}
+
+func (c *socatExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	c.removed <- command
+	return nil, nil, nil
+}
+
+// failingSocatExecClient fails every socket forwarding before a connection was accepted
+type failingSocatExecClient struct {
+	kubectltesting.Client
+
+	attempts int32
+}
+
+func (c *failingSocatExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	atomic.AddInt32(&c.attempts, 1)
+	return errors.New("connection refused")
+}
+
+func TestForwardSocket(t *testing.T) {
+	localPath := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", localPath)
+	assert.NilError(t, err)
+	defer listener.Close()
+	dialed := int32(0)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			// connections are kept open, so that the forwarded connections overlap
+			number := atomic.AddInt32(&dialed, 1)
+			_, _ = conn.Write([]byte("hello " + strconv.Itoa(int(number)) + "\n"))
+		}
+	}()
+
+	client := &socatExecClient{commands: make(chan []string, 10), accept: make(chan struct{}), lines: make(chan string, 10), removed: make(chan []string, 1)}
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{},
+		Container: &corev1.Container{Name: "test"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		forwardSocket(ctx, client, container, SocketForward{LocalPath: localPath, RemotePath: "/var/run/agent.sock"}, log.Discard)
+		close(done)
+	}()
+
+	// the local socket is only dialed once the remote connection was accepted
+	assert.DeepEqual(t, <-client.commands, []string{"socat", "-d", "-d", "UNIX-LISTEN:/var/run/agent.sock,unlink-early", "STDIO"})
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, atomic.LoadInt32(&dialed), int32(0))
+	client.accept <- struct{}{}
+	assert.Equal(t, <-client.lines, "hello 1\n")
+
+	// the next connection is served by a new exec while the first one is still open
+	<-client.commands
+	client.accept <- struct{}{}
+	assert.Equal(t, <-client.lines, "hello 2\n")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Expected socket forwarding to stop")
+	}
+	assert.DeepEqual(t, <-client.removed, []string{"rm", "-f", "/var/run/agent.sock"})
+}
+
+func TestForwardSocketMaxRetries(t *testing.T) {
+	defer func(original time.Duration) { socketForwardRetryDelay = original }(socketForwardRetryDelay)
+	socketForwardRetryDelay = time.Millisecond
+
+	client := &failingSocatExecClient{}
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{},
+		Container: &corev1.Container{Name: "test"},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		forwardSocket(context.Background(), client, container, SocketForward{LocalPath: "/tmp/missing.sock", RemotePath: "/var/run/agent.sock"}, log.Discard)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Expected socket forwarding to give up")
+	}
+	assert.Equal(t, atomic.LoadInt32(&client.attempts), int32(maxSocketForwardRetries+1))
+}
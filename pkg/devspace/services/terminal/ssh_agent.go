@@ -0,0 +1,34 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+)
+
+// sshAgentSocketPathFormat is the path of the forwarded ssh agent socket in the container, which is unique per
+// terminal, so that concurrent terminals of the same run don't replace each other's socket
+const sshAgentSocketPathFormat = "/tmp/.devspace-ssh-agent-%s.sock"
+
+// sshAgentForward returns the forwarding of the local ssh agent into the container. The socket is relayed by
+// socat over a separate exec stream, see forwardSocket, so socat has to be present in the container.
+func sshAgentForward(ctx devspacecontext.Context, container *selector.SelectedPodContainer, sessionID string) (*SocketForward, error) {
+	localPath := os.Getenv("SSH_AUTH_SOCK")
+	if localPath == "" {
+		return nil, errors.New("no local ssh agent found, SSH_AUTH_SOCK is not set")
+	}
+
+	stdout, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{"sh", "-c", "command -v socat"}, nil)
+	if err != nil {
+		ctx.Log().Debugf("Error probing for socat: %s %s %v", string(stdout), string(stderr), err)
+		return nil, errors.New("socat is not installed in the container")
+	}
+
+	return &SocketForward{
+		LocalPath:  localPath,
+		RemotePath: fmt.Sprintf(sshAgentSocketPathFormat, sessionID),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// sshAgentExecClient answers the probe for socat
+type sshAgentExecClient struct {
+	recordingExecClient
+
+	socat bool
+}
+
+func (c *sshAgentExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	if command[len(command)-1] == "command -v socat" && !c.socat {
+		return nil, nil, kubectlExec.CodeExitError{Err: fmt.Errorf("exit 1"), Code: 1}
+	}
+
+	return nil, nil, nil
+}
+
+type forwardSSHAgentTestCase struct {
+	name         string
+	authSock     string
+	socat        bool
+	expectedWarn string
+}
+
+func TestStartTerminalForwardSSHAgent(t *testing.T) {
+	testCases := []forwardSSHAgentTestCase{
+		{
+			name:     "Forwarded",
+			authSock: "/tmp/agent.sock",
+			socat:    true,
+		},
+		{
+			name:         "No local agent",
+			socat:        true,
+			expectedWarn: "SSH_AUTH_SOCK is not set",
+		},
+		{
+			name:         "No socat",
+			authSock:     "/tmp/agent.sock",
+			expectedWarn: "socat is not installed in the container",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Setenv("SSH_AUTH_SOCK", testCase.authSock)
+		client := &sshAgentExecClient{socat: testCase.socat}
+		out := &bytes.Buffer{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+			Container: &corev1.Container{Name: "app"},
+		}}
+		devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+			Command:            "bash",
+			Shell:              "sh",
+			ForwardSSHAgent:    true,
+			DisableScreen:      true,
+			DisableSessionFile: true,
+		}}
+
+		options := NewTerminalOptions(WithStreams(io.Discard, io.Discard, strings.NewReader("")))
+		err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, options)
+		assert.NilError(t, err, "Unexpected error in test case %s", testCase.name)
+
+		command := strings.Join(client.options.Command, " ")
+		if testCase.expectedWarn != "" {
+			assert.Assert(t, strings.Contains(out.String(), testCase.expectedWarn), "Expected warning in test case %s, got %s", testCase.name, out.String())
+			assert.Assert(t, !strings.Contains(command, "SSH_AUTH_SOCK"), "Unexpected SSH_AUTH_SOCK in test case %s: %s", testCase.name, command)
+			continue
+		}
+		assert.Assert(t, options.sessionID != "", "Expected a session id in test case %s", testCase.name)
+		assert.Assert(t, strings.Contains(command, "SSH_AUTH_SOCK="+fmt.Sprintf(sshAgentSocketPathFormat, options.sessionID)), "Expected SSH_AUTH_SOCK in test case %s: %s", testCase.name, command)
+	}
+}
@@ -0,0 +1,77 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/localcache"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/dockerfile"
+	"github.com/mgutz/ansi"
+)
+
+// runningImage returns the image the container is running, as reported by the pod status
+func runningImage(container *selector.SelectedPodContainer) string {
+	for _, status := range container.Pod.Status.ContainerStatuses {
+		if status.Name == container.Container.Name && status.Image != "" {
+			return status.Image
+		}
+	}
+
+	return container.Container.Image
+}
+
+// staleBuild compares the image the container is running with the images in the build cache and returns
+// the last built image if it has the same name, but a different tag. The build cache records the tag and
+// not the digest of an image, which is unique per build unless the tag is fixed in the image config.
+func staleBuild(cache localcache.Cache, container *selector.SelectedPodContainer) (string, string) {
+	running := runningImage(container)
+	runningName, runningTag, err := dockerfile.GetStrippedDockerImageName(running)
+	if err != nil || runningTag == "" {
+		return "", ""
+	}
+
+	// sort the image configs, so that the result is stable if multiple configs build the same image
+	images := cache.ListImageCache()
+	imageConfigNames := make([]string, 0, len(images))
+	for imageConfigName := range images {
+		imageConfigNames = append(imageConfigNames, imageConfigName)
+	}
+	sort.Strings(imageConfigNames)
+
+	for _, imageConfigName := range imageConfigNames {
+		imageCache := images[imageConfigName]
+		if imageCache.Tag == "" {
+			continue
+		}
+
+		builtName, _, err := dockerfile.GetStrippedDockerImageName(imageCache.ResolveImage())
+		if err != nil || builtName != runningName || imageCache.Tag == runningTag {
+			continue
+		}
+
+		return running, imageCache.ResolveImage() + ":" + imageCache.Tag
+	}
+
+	return "", ""
+}
+
+// warnOnStaleBuild writes a warning to out, usually stderr, if the container doesn't run the image that was built last
+func warnOnStaleBuild(ctx devspacecontext.Context, out io.Writer, container *selector.SelectedPodContainer) {
+	if out == nil || ctx.Config() == nil || ctx.Config().LocalCache() == nil {
+		return
+	}
+
+	running, built := staleBuild(ctx.Config().LocalCache(), container)
+	if running == "" {
+		return
+	}
+
+	message := fmt.Sprintf("Warning: container %s runs image %s, but the last local build is %s. You might be debugging stale code, please redeploy to use the new image", container.Container.Name, running, built)
+	_, err := fmt.Fprintln(out, forOutput(out, ansi.Color(message, "yellow")))
+	if err != nil {
+		ctx.Log().Debugf("Error printing stale build warning: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config"
+	"github.com/loft-sh/devspace/pkg/devspace/config/localcache"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type staleBuildTestCase struct {
+	name        string
+	images      map[string]localcache.ImageCache
+	specImage   string
+	statusImage string
+
+	expectedRunning string
+	expectedBuilt   string
+}
+
+func TestStaleBuild(t *testing.T) {
+	testCases := []staleBuildTestCase{
+		{
+			name:            "Stale tag",
+			images:          map[string]localcache.ImageCache{"app": {ImageName: "registry.dev/app", Tag: "new"}},
+			specImage:       "registry.dev/app:old",
+			expectedRunning: "registry.dev/app:old",
+			expectedBuilt:   "registry.dev/app:new",
+		},
+		{
+			name:      "Up to date",
+			images:    map[string]localcache.ImageCache{"app": {ImageName: "registry.dev/app", Tag: "new"}},
+			specImage: "registry.dev/app:new",
+		},
+		{
+			name:            "Status image is preferred",
+			images:          map[string]localcache.ImageCache{"app": {ImageName: "nginx", Tag: "new"}},
+			specImage:       "nginx:new",
+			statusImage:     "docker.io/library/nginx:old",
+			expectedRunning: "docker.io/library/nginx:old",
+			expectedBuilt:   "nginx:new",
+		},
+		{
+			name:      "Other image",
+			images:    map[string]localcache.ImageCache{"app": {ImageName: "registry.dev/app", Tag: "new"}},
+			specImage: "registry.dev/other:old",
+		},
+		{
+			name:      "Never built",
+			images:    map[string]localcache.ImageCache{"app": {ImageName: "registry.dev/app"}},
+			specImage: "registry.dev/app:old",
+		},
+	}
+
+	for _, testCase := range testCases {
+		cache := localcache.New("")
+		for imageConfigName, imageCache := range testCase.images {
+			cache.SetImageCache(imageConfigName, imageCache)
+		}
+		container := &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{},
+			Container: &corev1.Container{Name: "app", Image: testCase.specImage},
+		}
+		if testCase.statusImage != "" {
+			container.Pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app", Image: testCase.statusImage}}
+		}
+
+		running, built := staleBuild(cache, container)
+		assert.Equal(t, running, testCase.expectedRunning, "Unexpected running image in "+testCase.name)
+		assert.Equal(t, built, testCase.expectedBuilt, "Unexpected built image in "+testCase.name)
+	}
+}
+
+func TestStartTerminalWarnOnStaleBuild(t *testing.T) {
+	cache := localcache.New("")
+	cache.SetImageCache("app", localcache.ImageCache{ImageName: "registry.dev/app", Tag: "new"})
+	conf := config.NewConfig(nil, nil, latest.NewRaw(), cache, nil, nil, "")
+
+	stderr := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithConfig(conf).WithKubeClient(&recordingExecClient{})
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app", Image: "registry.dev/app:old"},
+	}}
+
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithWarnOnStaleBuild(true),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, stderr, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, stderr.String(), "Warning: container app runs image registry.dev/app:old, but the last local build is registry.dev/app:new. You might be debugging stale code, please redeploy to use the new image\n")
+}
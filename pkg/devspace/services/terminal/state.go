@@ -0,0 +1,52 @@
+package terminal
+
+// TerminalState is the connection state of a terminal
+type TerminalState string
+
+const (
+	// StateConnecting is sent when the terminal starts connecting to a container
+	StateConnecting TerminalState = "connecting"
+
+	// StateConnected is sent when the exec stream to the container is started
+	StateConnected TerminalState = "connected"
+
+	// StateReconnecting is sent when the terminal is restarted after it was interrupted
+	StateReconnecting TerminalState = "reconnecting"
+
+	// StateDisconnected is sent when the exec stream to the container has ended
+	StateDisconnected TerminalState = "disconnected"
+
+	// StateEnded is sent when the terminal has ended and won't be restarted anymore,
+	// the state channel is closed afterwards
+	StateEnded TerminalState = "ended"
+)
+
+// eventStates are the states the terminal is in after the lifecycle events
+var eventStates = map[EventType]TerminalState{
+	EventSessionStarted:   StateConnecting,
+	EventSessionConnected: StateConnected,
+	EventSessionRestarted: StateReconnecting,
+	EventSessionEnded:     StateDisconnected,
+}
+
+// sendState sends the state to the state channel without blocking, so that a slow consumer can't stall the session
+func (o *TerminalOptions) sendState(state TerminalState) {
+	if o.StateChan == nil {
+		return
+	}
+
+	select {
+	case o.StateChan <- state:
+	default:
+	}
+}
+
+// endStates sends StateEnded and closes the state channel
+func (o *TerminalOptions) endStates() {
+	if o.StateChan == nil {
+		return
+	}
+
+	o.sendState(StateEnded)
+	close(o.StateChan)
+}
@@ -0,0 +1,68 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type stateChanTestCase struct {
+	name   string
+	buffer int
+
+	expected []TerminalState
+}
+
+func TestStartTerminalStateChan(t *testing.T) {
+	testCases := []stateChanTestCase{
+		{
+			name:   "All transitions",
+			buffer: 10,
+			expected: []TerminalState{
+				StateConnecting,
+				StateConnected,
+				StateDisconnected,
+				StateReconnecting,
+				StateConnecting,
+				StateConnected,
+				StateDisconnected,
+				StateEnded,
+			},
+		},
+		{
+			name:     "Slow consumer",
+			buffer:   1,
+			expected: []TerminalState{StateConnecting},
+		},
+	}
+
+	for _, testCase := range testCases {
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&noScreenClient{})
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+			Container: &corev1.Container{Name: "test"},
+		}}
+
+		stateChan := make(chan TerminalState, testCase.buffer)
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithRestart(1),
+			WithStateChan(stateChan),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+
+		states := []TerminalState{}
+		for state := range stateChan {
+			states = append(states, state)
+		}
+		assert.DeepEqual(t, states, testCase.expected)
+	}
+}
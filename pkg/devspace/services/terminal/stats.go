@@ -0,0 +1,42 @@
+package terminal
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// TerminalStats are the statistics of a terminal session, which help to diagnose slow sessions
+type TerminalStats struct {
+	// BytesIn is the amount of bytes sent to stdin of the shell in the container
+	BytesIn uint64
+
+	// BytesOut is the amount of bytes received from stdout of the shell in the container
+	BytesOut uint64
+
+	// LastPingLatency is the round trip time of the last Ping, zero if the session wasn't pinged yet
+	LastPingLatency time.Duration
+}
+
+// Stats returns the current statistics of the session
+func (s *TerminalSession) Stats() TerminalStats {
+	return TerminalStats{
+		BytesIn:         uint64(atomic.LoadInt64(&s.bytesIn)),
+		BytesOut:        uint64(atomic.LoadInt64(&s.bytesOut)),
+		LastPingLatency: time.Duration(atomic.LoadInt64(&s.lastPingLatency)),
+	}
+}
+
+// Ping executes a no-op command in the session and returns the time until its exit code was received,
+// which is the latency of the exec connection to the container
+func (s *TerminalSession) Ping(ctx context.Context) (time.Duration, error) {
+	started := time.Now()
+	_, _, err := s.Exec(ctx, "true")
+	if err != nil {
+		return 0, err
+	}
+
+	latency := time.Since(started)
+	atomic.StoreInt64(&s.lastPingLatency, int64(latency))
+	return latency, nil
+}
@@ -0,0 +1,31 @@
+package terminal
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTerminalSessionStats(t *testing.T) {
+	pool := NewTerminalPool(&localExecClient{})
+	pool.DisableScreen = true
+	defer pool.Close()
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+	session, err := pool.Acquire(context.Background(), pod, "container")
+	assert.NilError(t, err)
+
+	// acquiring a session pings it
+	stats := session.Stats()
+	assert.Assert(t, stats.LastPingLatency > 0, "Expected the ping latency to be recorded")
+	assert.Assert(t, stats.BytesIn > 0 && stats.BytesOut > 0, "Expected the ping to be counted")
+
+	_, _, err = session.Exec(context.Background(), "echo hello")
+	assert.NilError(t, err)
+	next := session.Stats()
+	assert.Assert(t, next.BytesIn > stats.BytesIn, "Expected the command to be counted")
+	assert.Assert(t, next.BytesOut >= stats.BytesOut+uint64(len("hello\n")), "Expected the output to be counted")
+}
@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"fmt"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/sirupsen/logrus"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// sessionSummary collects the information printed after a terminal has ended, shared across restarts
+type sessionSummary struct {
+	startedAt time.Time
+
+	pod       string
+	container string
+	restarts  int
+
+	// exitCode is the exit code of the last session or -1 if the session ended without one
+	exitCode int
+
+	// multiplexer is the terminal multiplexer the last session ran in or empty if it ran in a plain shell
+	multiplexer string
+}
+
+func newSessionSummary() *sessionSummary {
+	return &sessionSummary{startedAt: time.Now()}
+}
+
+// connected records the container a session connected to and the number of restarts so far
+func (s *sessionSummary) connected(container *selector.SelectedPodContainer, restarts int) {
+	if s == nil {
+		return
+	}
+
+	s.pod, s.container, s.restarts = container.Pod.Name, container.Container.Name, restarts
+}
+
+// ranIn records the terminal multiplexer the last session ran in, after a possible fallback to a plain shell
+func (s *sessionSummary) ranIn(multiplexer string) {
+	if s == nil {
+		return
+	}
+
+	s.multiplexer = multiplexer
+}
+
+// ended records the exit code of a session from the error it ended with
+func (s *sessionSummary) ended(err error) {
+	if s == nil {
+		return
+	}
+
+	s.exitCode = 0
+	if exitError, ok := err.(kubectlExec.CodeExitError); ok {
+		s.exitCode = exitError.Code
+	} else if err != nil {
+		s.exitCode = -1
+	}
+}
+
+// message returns the one line summary of the terminal
+func (s *sessionSummary) message(now time.Time) string {
+	exitCode := "without exit code"
+	if s.exitCode >= 0 {
+		exitCode = fmt.Sprintf("with exit code %d", s.exitCode)
+	}
+
+	return fmt.Sprintf("Terminal to pod:container %s:%s ended after %s %s (%d restarts)", s.pod, s.container, now.Sub(s.startedAt).Round(time.Second), exitCode, s.restarts)
+}
+
+// logSummary prints the summary of the terminal unless it is quiet or never connected. A terminal
+// that was cancelled in a script, i.e. without a terminal as stdin, is only summarized in debug mode.
+func (o *TerminalOptions) logSummary(ctx devspacecontext.Context) {
+	if o.Quiet || o.summary == nil || o.summary.pod == "" {
+		return
+	}
+	if ctx.IsDone() && !isTerminal(o.Stdin) && ctx.Log().GetLevel() < logrus.DebugLevel {
+		return
+	}
+
+	ctx.Log().Info(o.summary.message(time.Now()))
+}
@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type summaryTestCase struct {
+	name     string
+	exitCode int
+	restart  int
+	quiet    bool
+
+	expectedSummary string
+}
+
+func TestStartTerminalSummary(t *testing.T) {
+	testCases := []summaryTestCase{
+		{
+			name:            "Expected exit code",
+			exitCode:        1,
+			restart:         2,
+			expectedSummary: "Terminal to pod:container pod:container ended after 0s with exit code 1 (0 restarts)",
+		},
+		{
+			name:            "Restarted",
+			exitCode:        137,
+			restart:         2,
+			expectedSummary: "Terminal to pod:container pod:container ended after 0s with exit code 137 (2 restarts)",
+		},
+		{
+			name:     "Quiet",
+			exitCode: 1,
+			quiet:    true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		out := &bytes.Buffer{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(&exitCodeExecClient{exitCode: testCase.exitCode})
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+			Container: &corev1.Container{Name: "container"},
+		}}
+
+		exitCode, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithRestart(testCase.restart),
+			WithQuiet(testCase.quiet),
+			WithDisableSessionFile(true),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, exitCode, testCase.exitCode, "Unexpected exit code in "+testCase.name)
+		if testCase.expectedSummary == "" {
+			assert.Assert(t, !strings.Contains(out.String(), "ended after"), "Unexpected summary in "+testCase.name)
+		} else {
+			assert.Assert(t, strings.Contains(out.String(), testCase.expectedSummary), "Expected summary in %s, got %s", testCase.name, out.String())
+		}
+	}
+}
+
+func TestSessionSummaryCancelled(t *testing.T) {
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, level := range []logrus.Level{logrus.InfoLevel, logrus.DebugLevel} {
+		out := &bytes.Buffer{}
+		ctx := devspacecontext.NewContext(cancelCtx, nil, log.NewStreamLogger(out, out, level))
+		options := NewTerminalOptions(WithStreams(io.Discard, io.Discard, strings.NewReader("")))
+		options.summary = &sessionSummary{startedAt: time.Now(), pod: "pod", container: "container", exitCode: -1}
+
+		options.logSummary(ctx)
+		assert.Equal(t, strings.Contains(out.String(), "ended after 0s without exit code (0 restarts)"), level == logrus.DebugLevel, "Unexpected summary with level "+level.String())
+	}
+}
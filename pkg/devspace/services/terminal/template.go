@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+)
+
+// commandTemplateData is the data the terminal command is evaluated against. All values are
+// shell escaped, as they are substituted into a command that is executed within a shell.
+type commandTemplateData struct {
+	Pod       string
+	Container string
+	Namespace string
+	Node      string
+}
+
+// renderCommand evaluates the command as go template against the metadata of the container,
+// e.g. PS1='[{{.Pod}}] '. Commands without valid template actions are returned unchanged.
+func renderCommand(command string, container *selector.SelectedPodContainer) (string, error) {
+	if !strings.Contains(command, "{{") {
+		return command, nil
+	}
+
+	// commands that are no valid template are left untouched, as they might
+	// contain braces that were never meant as template actions
+	t, err := template.New("command").Option("missingkey=error").Parse(command)
+	if err != nil || !hasActions(t.Tree) {
+		return command, nil
+	}
+
+	out := &strings.Builder{}
+	err = t.Execute(out, commandTemplateData{
+		Pod:       shellquote.Join(container.Pod.Name),
+		Container: shellquote.Join(container.Container.Name),
+		Namespace: shellquote.Join(container.Pod.Namespace),
+		Node:      shellquote.Join(container.Pod.Spec.NodeName),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "execute terminal command template")
+	}
+
+	return out.String(), nil
+}
+
+// hasActions checks if the template consists of more than plain text
+func hasActions(tree *parse.Tree) bool {
+	if tree == nil || tree.Root == nil {
+		return false
+	}
+
+	for _, node := range tree.Root.Nodes {
+		if node.Type() != parse.NodeText {
+			return true
+		}
+	}
+
+	return false
+}
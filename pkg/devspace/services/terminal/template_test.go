@@ -0,0 +1,66 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type renderCommandTestCase struct {
+	name    string
+	command string
+
+	expected    string
+	expectedErr string
+}
+
+func TestRenderCommand(t *testing.T) {
+	testCases := []renderCommandTestCase{
+		{
+			name:     "Plain command",
+			command:  "exec bash",
+			expected: "exec bash",
+		},
+		{
+			name:     "Plain command with braces",
+			command:  "echo {{ not a template",
+			expected: "echo {{ not a template",
+		},
+		{
+			name:     "Pod",
+			command:  "PS1='[{{.Pod}}] ' exec bash",
+			expected: "PS1='[my-pod] ' exec bash",
+		},
+		{
+			name:     "Namespace and container",
+			command:  "echo {{.Namespace}}/{{.Container}}",
+			expected: "echo 'my namespace'/app",
+		},
+		{
+			name:        "Unknown field",
+			command:     "echo {{.Unknown}}",
+			expectedErr: "execute terminal command template",
+		},
+	}
+
+	container := &selector.SelectedPodContainer{
+		Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-pod", Namespace: "my namespace"},
+			Spec:       corev1.PodSpec{NodeName: "node"},
+		},
+		Container: &corev1.Container{Name: "app"},
+	}
+	for _, testCase := range testCases {
+		command, err := renderCommand(testCase.command, container)
+		if testCase.expectedErr != "" {
+			assert.ErrorContains(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+			continue
+		}
+
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, command, testCase.expected, "Unexpected command in "+testCase.name)
+	}
+}
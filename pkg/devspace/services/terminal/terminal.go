@@ -1,10 +1,19 @@
 package terminal
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/acarl005/stripansi"
+	"github.com/kballard/go-shellquote"
 	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
 
 	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
@@ -13,14 +22,37 @@ import (
 	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
 	interruptpkg "github.com/loft-sh/devspace/pkg/util/interrupt"
 	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/proctitle"
+	"github.com/loft-sh/devspace/pkg/util/randutil"
 	"github.com/loft-sh/devspace/pkg/util/tomb"
 	"github.com/mgutz/ansi"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/remotecommand"
 	kubectlExec "k8s.io/client-go/util/exec"
 	"k8s.io/kubectl/pkg/util/term"
 )
 
+var (
+	// ErrClosedByUser is returned by StartTerminalFromCMDWithOptions if the terminal was closed, because
+	// its context was cancelled without a cause or with ErrClosedByUser as cause. It wraps context.Canceled.
+	ErrClosedByUser = fmt.Errorf("terminal closed by user: %w", context.Canceled)
+
+	// ErrClosedByTimeout is returned by StartTerminalFromCMDWithOptions if the terminal was closed, because
+	// the deadline of its context was exceeded. It wraps context.DeadlineExceeded.
+	ErrClosedByTimeout = fmt.Errorf("terminal closed due to timeout: %w", context.DeadlineExceeded)
+)
+
+// processTitlePrefix is the prefix of the process title while a session is connected
+const processTitlePrefix = "ds:"
+
+// isTerminal is replaced in tests to simulate an interactive terminal
+var isTerminal = term.IsTerminal
+
 // StartTerminalFromCMD opens a new terminal
+//
+// Deprecated: use StartTerminalFromCMDWithOptions instead
 func StartTerminalFromCMD(
 	ctx devspacecontext.Context,
 	selector targetselector.TargetSelector,
@@ -34,43 +66,160 @@ func StartTerminalFromCMD(
 	stderr io.Writer,
 	stdin io.Reader,
 ) (int, error) {
-	container, err := selector.SelectSingleContainer(ctx.Context(), ctx.KubeClient(), ctx.Log())
+	restarts := 0
+	if restart {
+		restarts = RestartUnlimited
+	}
+
+	return StartTerminalFromCMDWithOptions(ctx, selector, NewTerminalOptions(
+		WithCommand(command),
+		WithRestart(restarts),
+		WithTTY(tty),
+		WithScreen(screen, screenSession),
+		WithStreams(stdout, stderr, stdin),
+	))
+}
+
+// StartTerminalFromCMDWithOptions opens a new terminal with the given options. If ContainerEnvVar is set
+// in the format pod/container, that container is used and the selector is skipped.
+func StartTerminalFromCMDWithOptions(
+	ctx devspacecontext.Context,
+	selector targetselector.TargetSelector,
+	options *TerminalOptions,
+) (int, error) {
+	ctx = withExecLimit(ctx)
+	if options.restarts == 0 {
+		defer options.endStates()
+		options.summary = newSessionSummary()
+		options.sessionID = strings.ToLower(randutil.GenerateRandomString(12))
+		options.stdinPump = kubectl.NewStdinPump(options.Stdin)
+		options.bannerOnce = &sync.Once{}
+		options.containerEnv = os.Getenv(ContainerEnvVar)
+		defer options.logSummary(ctx)
+		defer options.startSessionRecord(ctx)()
+	}
+	if options.SubResource == kubectl.SubResourceAttach && len(options.Command) > 0 {
+		return 0, fmt.Errorf("a command cannot be specified when attaching to a container")
+	}
+	if options.CommandValidator != nil && options.restarts == 0 {
+		err := options.CommandValidator(options.Command)
+		if err != nil {
+			return 0, err
+		}
+	}
+	err := validateEventFormat(options.EventFormat)
+	if err != nil {
+		return 0, err
+	}
+	if options.ForceTTY {
+		err = validateForceTTY(options.Stdout)
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = kubectl.ValidateSubResource(options.SubResource)
 	if err != nil {
 		return 0, err
 	}
+	if options.Resume && options.resumeOffset == nil {
+		options.resumeOffset = new(int64)
+	}
+	if options.screenFallbackOnce == nil {
+		options.screenFallbackOnce = &sync.Once{}
+	}
+	ctx, err = sessionKubeContext(ctx, options, "")
+	if err != nil {
+		return 0, err
+	}
+	if len(options.PreExecHook) > 0 && options.restarts == 0 {
+		err = runPreExecHook(ctx, options.PreExecHook, options.Stderr)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if options.NamespaceOverride != "" {
+		selector = selector.WithNamespace(options.NamespaceOverride)
+	}
+	if options.AvoidScalingDownPods {
+		selector = selector.WithAvoidScalingDown()
+	}
+
+	selectStart := time.Now()
+	container, err := containerFromEnv(ctx, options.namespace(ctx), options.containerEnv)
+	if err != nil {
+		return 0, forbiddenError(err, options.namespace(ctx))
+	} else if container != nil {
+		if options.restarts == 0 {
+			ctx.Log().Warnf("Using container %s:%s set by %s instead of the configured selector", container.Pod.Name, container.Container.Name, ContainerEnvVar)
+		}
+	} else {
+		container, err = selector.SelectSingleContainer(ctx.Context(), ctx.KubeClient(), ctx.Log())
+		if err != nil {
+			return 0, forbiddenError(err, options.namespace(ctx))
+		}
+	}
+	selectDuration := time.Since(selectStart)
+	ctx.Log().Debugf("Selected container %s:%s in %dms", container.Pod.Name, container.Container.Name, selectDuration.Milliseconds())
+	options.trackPod(ctx, container.Pod)
+	if options.UseEphemeralContainer {
+		var revert func()
+		container, revert, err = startEphemeralContainer(ctx, container, options.EphemeralContainer)
+		if err != nil {
+			return 0, err
+		}
+		defer revert()
+	} else {
+		container, err = checkCrashLoop(ctx, container, options.WaitForRunning)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if options.CheckRBAC {
+		err = checkExecPermission(ctx, container, options.SubResource)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if options.WarnOnStaleBuild {
+		warnOnStaleBuild(ctx, options.Stderr, container)
+	}
 
-	ctx.Log().Infof("Opening shell to pod:container %s:%s", ansi.Color(container.Pod.Name, "white+b"), ansi.Color(container.Container.Name, "white+b"))
+	ctx.Log().Info(banner(options.Stdout, options.BannerColor, container))
+	options.emitEvent(ctx, newSessionStartedEvent(container.Pod.Name, container.Container.Name, selectDuration))
+	options.summary.connected(container, options.restarts)
+	options.recordSession(ctx, container)
 	done := make(chan error)
 	go func() {
-		done <- startTerminal(ctx, command, tty, !screen, screenSession, stdout, stderr, stdin, container)
+		done <- startTerminal(ctx, container, options.Command, options.TTY, !options.Screen, "", options)
 	}()
 
 	// wait until either client has finished or we got interrupted
 	select {
 	case <-ctx.Context().Done():
-		<-done
-		return 0, nil
+		options.summary.ended(<-done)
+		return 0, closedError(ctx)
 	case err = <-done:
+		options.summary.ended(err)
 		if err != nil {
-			if exitError, ok := err.(kubectlExec.CodeExitError); ok {
-				// Expected exit codes are (https://shapeshed.com/unix-exit-codes/):
-				// 1 - Catchall for general errors
-				// 2 - Misuse of shell builtins (according to Bash documentation)
-				// 126 - Command invoked cannot execute
-				// 127 - “command not found”
-				// 128 - Invalid argument to exit
-				// 130 - Script terminated by Control-C
-				if restart && IsUnexpectedExitCode(exitError.Code) {
-					ctx.Log().WriteString(logrus.InfoLevel, "\n")
-					ctx.Log().Infof("Restarting because: %s", err)
-					return StartTerminalFromCMD(ctx, selector, command, wait, restart, tty, screen, screenSession, stdout, stderr, stdin)
+			// restarting wouldn't help if we are not allowed to exec into the container
+			if kerrors.IsForbidden(err) {
+				return 0, forbiddenError(err, container.Pod.Namespace)
+			} else if options.shouldFollow() && podGone(ctx, container.Pod, err) {
+				replacement, followErr := waitForReplacement(ctx, container)
+				if followErr != nil {
+					return 0, errors.Wrapf(followErr, "follow pod %s", container.Pod.Name)
 				}
 
+				ctx.Log().Infof("Following pod %s to its replacement %s", container.Pod.Name, replacement.Pod.Name)
+				return StartTerminalFromCMDWithOptions(ctx, followSelector(replacement), options.followed())
+			} else if options.shouldRestart(err) {
+				logRestart(ctx, options.Stderr, err)
+				options.emitEvent(ctx, newEvent(EventSessionRestarted, container.Pod.Name, container.Container.Name, err))
+				// repeated restarts reuse the selected container as long as it still runs
+				return StartTerminalFromCMDWithOptions(ctx, selector.WithPreferNewest().WithCache(), options.restarted())
+			} else if exitError, ok := err.(kubectlExec.CodeExitError); ok {
 				return exitError.Code, nil
-			} else if restart {
-				ctx.Log().WriteString(logrus.InfoLevel, "\n")
-				ctx.Log().Infof("Restarting because: %s", err)
-				return StartTerminalFromCMD(ctx, selector, command, wait, restart, tty, screen, screenSession, stdout, stderr, stdin)
 			}
 
 			return 0, err
@@ -80,7 +229,103 @@ func StartTerminalFromCMD(
 	return 0, nil
 }
 
+// closedError logs why the context of the terminal is done and returns ErrClosedByTimeout if its deadline
+// was exceeded and ErrClosedByUser if it was cancelled by the user. If the context was cancelled with
+// another cause, e.g. by context.WithCancelCause, the cause is returned instead.
+func closedError(ctx devspacecontext.Context) error {
+	if errors.Is(ctx.Context().Err(), context.DeadlineExceeded) {
+		ctx.Log().Info("Terminal closed due to timeout")
+		return ErrClosedByTimeout
+	}
+
+	cause := context.Cause(ctx.Context())
+	if cause != nil && cause != context.Canceled && !errors.Is(cause, ErrClosedByUser) {
+		ctx.Log().Infof("Terminal closed: %v", cause)
+		return errors.Wrap(cause, "terminal closed")
+	}
+
+	ctx.Log().Info("Terminal closed by user")
+	return ErrClosedByUser
+}
+
+const (
+	// MultiplexerScreen is the TerminalResult.Multiplexer of sessions that ran in a screen session
+	MultiplexerScreen = "screen"
+
+	// MultiplexerTmux is the SessionInfo.Multiplexer of tmux sessions
+	MultiplexerTmux = "tmux"
+)
+
+// TerminalResult describes how a terminal started with StartTerminalFromCMDWithResult or
+// StartTerminalWithResult ended
+type TerminalResult struct {
+	// ExitCode is the exit code of the command like returned by StartTerminalFromCMDWithOptions. For
+	// StartTerminalWithResult it is the exit code of the kubectlExec.CodeExitError returned as error, if any.
+	ExitCode int
+
+	// UsedMultiplexer is true if the last session ran in a terminal multiplexer instead of falling back to
+	// a plain shell, so that its state, e.g. running processes, is restored when reconnecting
+	UsedMultiplexer bool
+
+	// Multiplexer is the terminal multiplexer the last session ran in, see MultiplexerScreen, or empty
+	Multiplexer string
+}
+
+// StartTerminalFromCMDWithResult is StartTerminalFromCMDWithOptions, but also returns whether the last
+// session ran in a terminal multiplexer. The result is also returned with an error, e.g. if the terminal
+// was closed by the user, where the screen session keeps running in the container.
+func StartTerminalFromCMDWithResult(
+	ctx devspacecontext.Context,
+	selector targetselector.TargetSelector,
+	options *TerminalOptions,
+) (*TerminalResult, error) {
+	exitCode, err := StartTerminalFromCMDWithOptions(ctx, selector, options)
+	return options.result(exitCode), err
+}
+
+// StartTerminalWithResult is StartTerminalWithOptions, but also returns whether the last session ran in a
+// terminal multiplexer. The result is also returned with an error, like by StartTerminalFromCMDWithResult.
+func StartTerminalWithResult(
+	ctx devspacecontext.Context,
+	devContainer *latest.DevContainer,
+	selector targetselector.TargetSelector,
+	parent *tomb.Tomb,
+	options *TerminalOptions,
+) (*TerminalResult, error) {
+	err := StartTerminalWithOptions(ctx, devContainer, selector, parent, options)
+	exitCode := 0
+	if exitError, ok := err.(kubectlExec.CodeExitError); ok {
+		exitCode = exitError.Code
+	}
+
+	return options.result(exitCode), err
+}
+
+// result returns the result of the terminal, which records the multiplexer of the last session in its summary
+func (o *TerminalOptions) result(exitCode int) *TerminalResult {
+	result := &TerminalResult{ExitCode: exitCode}
+	if o.summary != nil && o.summary.multiplexer != "" {
+		result.UsedMultiplexer = true
+		result.Multiplexer = o.summary.multiplexer
+	}
+
+	return result
+}
+
+// StartTerminalToFirstReady opens a terminal to the first running container matched by one of the
+// given selectors and waits until one is running. If containers of multiple selectors are running,
+// the selector given first is preferred.
+func StartTerminalToFirstReady(
+	ctx devspacecontext.Context,
+	selectors []targetselector.Options,
+	options *TerminalOptions,
+) (int, error) {
+	return StartTerminalFromCMDWithOptions(ctx, targetselector.NewPriorityTargetSelector(selectors...), options)
+}
+
 // StartTerminal opens a new terminal
+//
+// Deprecated: use StartTerminalWithOptions instead
 func StartTerminal(
 	ctx devspacecontext.Context,
 	devContainer *latest.DevContainer,
@@ -89,50 +334,311 @@ func StartTerminal(
 	stderr io.Writer,
 	stdin io.Reader,
 	parent *tomb.Tomb,
+) error {
+	return StartTerminalWithOptions(ctx, devContainer, selector, parent, NewTerminalOptions(
+		WithRestart(RestartUnlimited),
+		WithStreams(stdout, stderr, stdin),
+	))
+}
+
+// StartTerminalWithOptions opens a new terminal to the dev container with the given options
+func StartTerminalWithOptions(
+	ctx devspacecontext.Context,
+	devContainer *latest.DevContainer,
+	selector targetselector.TargetSelector,
+	parent *tomb.Tomb,
+	options *TerminalOptions,
 ) (err error) {
+	ctx = withExecLimit(ctx)
+	if options.restarts == 0 {
+		defer options.endStates()
+		options.summary = newSessionSummary()
+		options.sessionID = strings.ToLower(randutil.GenerateRandomString(12))
+		options.stdinPump = kubectl.NewStdinPump(options.Stdin)
+		options.bannerOnce = &sync.Once{}
+		options.ephemeralContainers = &ephemeralContainerList{}
+		defer options.stopEphemeralContainers(ctx)
+		defer options.logSummary(ctx)
+		defer options.startSessionRecord(ctx)()
+	}
+	err = validateEventFormat(options.EventFormat)
+	if err != nil {
+		return err
+	}
+	subResource := options.SubResource
+	if devContainer.Terminal.SubResource != "" {
+		subResource = kubectl.SubResource(devContainer.Terminal.SubResource)
+	}
+	err = kubectl.ValidateSubResource(subResource)
+	if err != nil {
+		return err
+	}
+	if options.screenFallbackOnce == nil {
+		options.screenFallbackOnce = &sync.Once{}
+	}
+	ctx, err = sessionKubeContext(ctx, options, devContainer.Terminal.KubeContext)
+	if err != nil {
+		return err
+	}
+
 	// restart on error
+	podName, containerName := "", ""
 	defer func() {
 		if err != nil {
 			if ctx.IsDone() {
 				return
 			}
 
-			ctx.Log().Infof("Restarting because: %s", err)
+			if !options.shouldRestart(err) {
+				// an expected exit code is no error if the restart hook declined the restart
+				if exitError, ok := err.(kubectlExec.CodeExitError); ok && !IsUnexpectedExitCode(exitError.Code) {
+					err = nil
+				}
+				return
+			}
+
+			logRestart(ctx, options.Stderr, err)
+			options.emitEvent(ctx, newEvent(EventSessionRestarted, podName, containerName, err))
 			select {
 			case <-ctx.Context().Done():
 				return
-			case <-time.After(time.Second * 3):
+			case <-time.After(jitter(restartDelay, options.RestartJitter)):
 			}
-			err = StartTerminal(ctx, devContainer, selector, stdout, stderr, stdin, parent)
+			// during a rolling update the old pod might still be around, so we reconnect to the newest one.
+			// Repeated restarts reuse the selected container as long as it still runs.
+			err = StartTerminalWithOptions(ctx, devContainer, selector.WithPreferNewest().WithCache(), parent, options.restarted())
 			return
 		}
 
 		ctx.Log().Debugf("Stopped terminal")
 	}()
 
-	command := getCommand(devContainer)
+	if options.NamespaceOverride != "" {
+		selector = selector.WithNamespace(options.NamespaceOverride)
+	}
+
+	selectStart := time.Now()
 	container, err := selector.WithContainer(devContainer.Container).SelectSingleContainer(ctx.Context(), ctx.KubeClient(), ctx.Log())
 	if err != nil {
 		return err
 	}
+	selectDuration := time.Since(selectStart)
+	ctx.Log().Debugf("Selected container %s:%s in %dms", container.Pod.Name, container.Container.Name, selectDuration.Milliseconds())
+	options.trackPod(ctx, container.Pod)
+	if devContainer.Terminal.UseEphemeralContainer {
+		// the container is named after the session, so that restarts reuse it
+		var revert func()
+		container, revert, err = startEphemeralContainer(ctx, container, EphemeralContainerOptions{
+			Name:  DefaultEphemeralContainerName + "-" + options.sessionID,
+			Image: devContainer.Terminal.EphemeralImage,
+		})
+		if err != nil {
+			return err
+		}
+		defer revert()
+		options.trackEphemeralContainer(container)
+	} else {
+		container, err = checkCrashLoop(ctx, container, options.WaitForRunning || devContainer.Terminal.WaitForRunning)
+		if err != nil {
+			return err
+		}
+	}
+	if options.CheckRBAC {
+		err = checkExecPermission(ctx, container, subResource)
+		if err != nil {
+			return err
+		}
+	}
+	if options.WarnOnStaleBuild {
+		warnOnStaleBuild(ctx, options.Stderr, container)
+	}
+	podName, containerName = container.Pod.Name, container.Container.Name
+
+	shell := devContainer.Terminal.Shell
+	if shell == "" {
+		shell = detectShell(ctx, container, devContainer.Terminal.WindowsShell)
+	}
+	terminalCommand, err := renderCommand(devContainer.Terminal.Command, container)
+	if err != nil {
+		return err
+	}
+
+	// the command for windows containers is built after all environment variables are known
+	var command []string
+	initScriptPath := ""
+	if isWindowsShell(shell) {
+		if devContainer.Terminal.InitScript != "" || devContainer.Terminal.RunAsUser != "" || devContainer.Terminal.RootFrom != "" || devContainer.Terminal.ShellPath != "" {
+			ctx.Log().Warnf("Terminal initScript, runAsUser, rootFrom and shellPath are not supported in windows container %s and are ignored", container.Container.Name)
+		}
+	} else {
+		userTool := ""
+		if devContainer.Terminal.RunAsUser != "" {
+			userTool = userSwitchTool(ctx, container, devContainer.Terminal.RunAsUser)
+		}
+
+		// with rootFrom the work dir is in the filesystem of the other container, which we cannot check
+		if options.ValidateWorkDir && devContainer.Terminal.WorkDir != "" && devContainer.Terminal.RootFrom == "" {
+			err = validateWorkDir(ctx, container, devContainer.Terminal.WorkDir, options.CreateWorkDir, devContainer.Terminal.RunAsUser, userTool)
+			if err != nil {
+				return err
+			}
+		}
+
+		if devContainer.Terminal.ShellPath != "" && !path.IsAbs(devContainer.Terminal.ShellPath) {
+			ctx.Log().Warnf("Terminal shellPath %s is not an absolute path and is looked up in the PATH of container %s", devContainer.Terminal.ShellPath, container.Container.Name)
+		}
+
+		if devContainer.Terminal.InitScript != "" && devContainer.Terminal.RootFrom != "" {
+			ctx.Log().Warnf("Terminal initScript is not supported together with rootFrom and is ignored")
+		} else if devContainer.Terminal.InitScript != "" {
+			initScriptPath = fmt.Sprintf(initScriptPathFormat, options.sessionID)
+			err = uploadInitScript(ctx, container, devContainer.Terminal.InitScript, initScriptPath)
+			if err != nil {
+				return err
+			}
+		}
+		command = getCommand(devContainer, terminalCommand, initScriptPath)
+		if devContainer.Terminal.RunAsUser != "" {
+			command = runAsUserCommand(command, devContainer.Terminal.RunAsUser, userTool)
+		}
+		if devContainer.Terminal.RootFrom != "" {
+			command, err = rootFrom(container, command, devContainer.Terminal.RootFrom)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// the screen session of the dev container is called dev unless a different
+	// name is given, which allows multiple terminals to the same container
+	sessionOptions := *options
+	if devContainer.Terminal.ScreenSessionName != "" {
+		sessionOptions.ScreenSession = devContainer.Terminal.ScreenSessionName
+	} else if sessionOptions.ScreenSession == "" {
+		sessionOptions.ScreenSession = DefaultScreenSessionName
+	}
+	sessionOptions.SubResource = subResource
+	sessionOptions.initScriptPath = initScriptPath
+	if devContainer.Terminal.ScreenInstallTimeout > 0 {
+		sessionOptions.ScreenInstallTimeout = time.Duration(devContainer.Terminal.ScreenInstallTimeout) * time.Second
+	}
+	if devContainer.Terminal.Cols > 0 && devContainer.Terminal.Rows > 0 {
+		sessionOptions.Cols, sessionOptions.Rows = devContainer.Terminal.Cols, devContainer.Terminal.Rows
+	}
+	if devContainer.Terminal.WatchdogInterval > 0 {
+		sessionOptions.WatchdogInterval = time.Duration(devContainer.Terminal.WatchdogInterval) * time.Second
+	}
+	if devContainer.Terminal.ManageScreenrc != nil && !*devContainer.Terminal.ManageScreenrc {
+		sessionOptions.SkipScreenrc = true
+	}
+	if devContainer.Terminal.DisableTTYFallback {
+		sessionOptions.DisableTTYFallback = true
+	}
+	if devContainer.Terminal.StdinRateLimit > 0 {
+		sessionOptions.StdinRateLimit = devContainer.Terminal.StdinRateLimit
+	}
+	if devContainer.Terminal.Banner != "" {
+		sessionOptions.Banner = devContainer.Terminal.Banner
+	}
+	if devContainer.Terminal.ScreenInstallScript != "" {
+		sessionOptions.ScreenInstallScript = devContainer.Terminal.ScreenInstallScript
+	}
+	if devContainer.Terminal.ScreenLogPath != "" {
+		sessionOptions.ScreenLogPath = devContainer.Terminal.ScreenLogPath
+	}
+	if devContainer.Terminal.NoPackageInstall {
+		sessionOptions.NoPackageInstall = true
+	}
+	if devContainer.Terminal.DisableSessionFile {
+		sessionOptions.DisableSessionFile = true
+	}
+	if devContainer.Terminal.ShowCommand {
+		sessionOptions.ShowCommand = true
+	}
+	sessionOptions.redactEnv = devContainer.Terminal.Env
+	if terminalCommand != "" {
+		sessionOptions.hintCommand = commandName(terminalCommand)
+	} else if !isWindowsShell(shell) {
+		// the default shell is started by the shell that wraps the command
+		sessionOptions.hintCommand = terminalShellPath(devContainer)
+	}
+	if devContainer.Terminal.InjectMetadata == nil || *devContainer.Terminal.InjectMetadata {
+		sessionOptions.EnvVars = mergeEnvVars(metadataEnvVars(container), sessionOptions.EnvVars)
+	}
+	if devContainer.Terminal.ShowAllProcesses && checkSharedProcessNamespace(ctx, container) {
+		sessionOptions.EnvVars = mergeEnvVars(sessionOptions.EnvVars, map[string]string{"PROMPT_COMMAND": sharedProcessNamespacePromptCommand})
+	}
 
-	ctx.Log().Infof("Opening shell to %s:%s (pod:container)", ansi.Color(container.Container.Name, "white+b"), ansi.Color(container.Pod.Name, "white+b"))
+	if options.InjectKubeconfig {
+		if isWindowsShell(shell) {
+			ctx.Log().Warnf("Injecting the kubeconfig is not supported in windows container %s and is skipped", container.Container.Name)
+		} else if err := injectKubeconfig(ctx, container, InjectedKubeconfigPathPrefix+options.sessionID); err != nil {
+			ctx.Log().Warnf("Error injecting kubeconfig into container %s: %v", container.Container.Name, err)
+		} else {
+			sessionOptions.injectedKubeconfigPath = InjectedKubeconfigPathPrefix + options.sessionID
+			sessionOptions.EnvVars = mergeEnvVars(sessionOptions.EnvVars, map[string]string{"KUBECONFIG": sessionOptions.injectedKubeconfigPath})
+		}
+	}
+
+	if devContainer.Terminal.ForwardSSHAgent {
+		if isWindowsShell(shell) {
+			ctx.Log().Warnf("Forwarding the ssh agent is not supported in windows container %s and is skipped", container.Container.Name)
+		} else if forward, err := sshAgentForward(ctx, container, options.sessionID); err != nil {
+			ctx.Log().Warnf("Cannot forward the ssh agent into container %s: %v", container.Container.Name, err)
+		} else {
+			sessionOptions.ForwardSockets = append(append([]SocketForward{}, sessionOptions.ForwardSockets...), *forward)
+			sessionOptions.EnvVars = mergeEnvVars(sessionOptions.EnvVars, map[string]string{"SSH_AUTH_SOCK": forward.RemotePath})
+		}
+	}
+
+	if !isWindowsShell(shell) && (devContainer.Terminal.IsolateHistory || devContainer.Terminal.NoHistory) {
+		historyEnv, ok := historyEnvVars(terminalCommand, options.sessionID, devContainer.Terminal.IsolateHistory, devContainer.Terminal.NoHistory)
+		if ok {
+			sessionOptions.EnvVars = mergeEnvVars(sessionOptions.EnvVars, historyEnv)
+		} else {
+			ctx.Log().Debugf("Terminal isolateHistory and noHistory are only supported for bash and zsh and are ignored for %s", commandName(terminalCommand))
+		}
+	}
+
+	disableScreen := devContainer.Terminal.DisableScreen
+	if isWindowsShell(shell) {
+		sessionOptions.EnvVars = mergeEnvVars(sessionOptions.EnvVars, devContainer.Terminal.Env)
+		command = getWindowsCommand(shell, devContainer.Terminal.WorkDir, terminalCommand, sessionOptions.EnvVars)
+		sessionOptions.EnvVars = nil
+		sessionOptions.DisableSessionFile = true
+		disableScreen = true
+	}
+
+	bannerColor := options.BannerColor
+	if devContainer.Terminal.BannerColor != "" {
+		bannerColor = devContainer.Terminal.BannerColor
+	}
+	ctx.Log().Info(banner(options.Stdout, bannerColor, container))
+	options.emitEvent(ctx, newSessionStartedEvent(container.Pod.Name, container.Container.Name, selectDuration))
+	options.summary.connected(container, options.restarts)
+	options.recordSession(ctx, container)
 	errChan := make(chan error)
 	parent.Go(func() error {
-		errChan <- startTerminal(ctx, command, !devContainer.Terminal.DisableTTY, devContainer.Terminal.DisableScreen, "dev", stdout, stderr, stdin, container)
+		errChan <- startTerminal(ctx, container, command, !devContainer.Terminal.DisableTTY, disableScreen, devContainer.Terminal.ScreenLogExport, &sessionOptions)
 		return nil
 	})
 
 	select {
 	case <-ctx.Context().Done():
-		<-errChan
+		options.summary.ended(<-errChan)
 		return nil
 	case err = <-errChan:
+		options.summary.ended(err)
 		if ctx.IsDone() {
 			return nil
 		}
 
 		if err != nil {
+			// a timed out session is restarted while a cancelled one is not
+			if errors.Is(err, kubectl.ErrExecTimeout) {
+				return fmt.Errorf("session to pod %s exceeded the maximum duration of %s", container.Pod.Name, options.MaxSessionDuration)
+			}
+
 			// check if context is done
 			if exitError, ok := err.(kubectlExec.CodeExitError); ok {
 				// Expected exit codes are (https://shapeshed.com/unix-exit-codes/):
@@ -142,14 +648,14 @@ func StartTerminal(
 				// 127 - “command not found”
 				// 128 - Invalid argument to exit
 				// 130 - Script terminated by Control-C
-				if IsUnexpectedExitCode(exitError.Code) {
+				if IsUnexpectedExitCode(exitError.Code) || options.ShouldRestart != nil {
 					return err
 				}
 
 				return nil
 			}
 
-			return fmt.Errorf("lost connection to pod %s: %v", container.Pod.Name, err)
+			return execStreamError(err, container)
 		}
 	}
 
@@ -158,82 +664,386 @@ func StartTerminal(
 
 func startTerminal(
 	ctx devspacecontext.Context,
+	container *selector.SelectedPodContainer,
 	command []string,
 	tty bool,
 	disableScreen bool,
-	screenSession string,
-	stdout io.Writer,
-	stderr io.Writer,
-	stdin io.Reader,
-	container *selector.SelectedPodContainer,
+	screenLogExport string,
+	options *TerminalOptions,
 ) error {
 	interruptpkg.Global.Stop()
 	defer interruptpkg.Global.Start()
 
+	hintCommand := options.hintCommand
+	if hintCommand == "" && len(command) > 0 {
+		hintCommand = command[0]
+	}
+
+	// the output of the session is scoped to the terminal instead of silencing the
+	// base logger, which would affect all services running concurrently
+	ctx = ctx.WithLogger(NewContextLogger(ctx.Log(), container.Container.Name))
+
+	// make the session identifiable in ps, e.g. on CI machines. Linux shows only
+	// proctitle.MaxLength bytes, so the container name is more telling than the pod name.
+	resetTitle, err := proctitle.Set(processTitlePrefix + container.Container.Name)
+	if err != nil {
+		ctx.Log().Debugf("Error setting process title: %v", err)
+	} else {
+		defer func() {
+			err := resetTitle()
+			if err != nil {
+				ctx.Log().Debugf("Error resetting process title: %v", err)
+			}
+		}()
+	}
+
+	stdout, stderr, stdin := options.Stdout, options.Stderr, options.Stdin
+	if options.Banner != "" && stdout != nil && isTerminal(stdout) {
+		stdout = &bannerWriter{Writer: stdout, banner: renderBanner(options.Banner, container) + "\n", once: options.bannerOnce}
+	}
+	if options.TeeFile != "" {
+		teeFile, err := os.OpenFile(ctx.ResolvePath(options.TeeFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return errors.Wrap(err, "open tee file")
+		}
+		defer teeFile.Close()
+
+		if stdout != nil {
+			stdout = io.MultiWriter(stdout, teeFile)
+		}
+		if stderr != nil {
+			stderr = io.MultiWriter(stderr, teeFile)
+		}
+	}
+
+	// attach connects to the main process of the container, so there is
+	// no command we could adjust or wrap in a screen session
+	subResource := options.SubResource
+	if subResource == "" {
+		subResource = kubectl.SubResourceExec
+	} else if subResource == kubectl.SubResourceAttach {
+		command = nil
+		disableScreen = true
+	}
+	var prompt *promptWriter
+	if subResource == kubectl.SubResourceExec {
+		envVars := options.EnvVars
+		if options.WaitForPrompt && stdout != nil {
+			promptID := newPromptID()
+			// the marker is printed after a PROMPT_COMMAND of the session, e.g. the note of showAllProcesses
+			markerCommand := promptCommand(promptID)
+			if envVars["PROMPT_COMMAND"] != "" {
+				markerCommand = envVars["PROMPT_COMMAND"] + "; " + markerCommand
+			}
+			envVars = mergeEnvVars(envVars, map[string]string{"PROMPT_COMMAND": markerCommand})
+			prompt = newPromptWriter(stdout, promptMarker(promptID))
+			stdout = prompt
+		}
+		command = withEnvVars(command, envVars)
+	}
+
+	// a remote tty would never see the end of piped input, e.g. devspace enter -- wc -l < file,
+	// so we only request one for interactive input to make sure EOF is forwarded to the command.
+	// If a fixed size is given, we force the tty instead, as the command requires one.
+	var sizeQueue remotecommand.TerminalSizeQueue
+	forceTTY := false
+	if tty && stdin != nil && !isTerminal(stdin) {
+		if options.TerminalSizeQueue != nil {
+			ctx.Log().Debugf("Stdin is not a terminal, using the given terminal size queue")
+			sizeQueue = options.TerminalSizeQueue
+			forceTTY = true
+		} else if options.Cols > 0 && options.Rows > 0 {
+			ctx.Log().Debugf("Stdin is not a terminal, using fixed terminal size %dx%d", options.Cols, options.Rows)
+			sizeQueue = newFixedSizeQueue(options.Cols, options.Rows)
+			forceTTY = true
+		} else if options.ForceTTY {
+			ctx.Log().Debugf("Stdin is not a terminal, forcing a tty")
+			forceTTY = true
+		} else {
+			ctx.Log().Debugf("Stdin is not a terminal, disabling tty")
+			tty = false
+		}
+	}
+
+	// a session without tty is resumed by skipping the output we already received
+	if options.resumeOffset != nil && stdout != nil && !tty && !forceTTY && subResource == kubectl.SubResourceExec {
+		command = options.resumeCommand(command)
+		stdout = &kubectl.CountingWriter{Writer: stdout, Count: options.resumeOffset}
+		disableScreen = true
+	}
+
+	kubectlPath, err := nativeKubectlPath(ctx, options)
+	if err != nil {
+		return err
+	}
+	if kubectlPath != "" && options.StdinRateLimit > 0 {
+		ctx.Log().Debugf("Stdin rate limit is not supported with native kubectl and is ignored")
+	}
+	if kubectlPath != "" && options.ForceTTY && stdin != nil && !isTerminal(stdin) {
+		ctx.Log().Debugf("Kubectl doesn't allocate a tty if stdin is not a terminal, the forced tty is ignored")
+	}
+
 	// try to install screen
 	useScreen := false
-	if term.IsTerminal(stdin) && !disableScreen {
-		ctx.Log().Debugf("Installing screen in container...")
-		bufferStdout, bufferStderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{
-			"sh",
-			"-c",
-			`if ! command -v screen; then
-  if command -v apk; then
-    apk add --no-cache screen
-  elif command -v apt-get; then
-    apt-get -qq update && apt-get install -y screen && rm -rf /var/lib/apt/lists/*
-  else
-    echo "Couldn't install screen using neither apt-get nor apk."
-    exit 1
-  fi
-fi
-if command -v screen; then
-  echo "Screen installed successfully."
-
-  if [ ! -f ~/.screenrc ]; then
-    echo "termcapinfo xterm* ti@:te@" > ~/.screenrc
-    echo "logfile /tmp/terminal-log.0" >> ~/.screenrc
-    echo "escape ^tt" >> ~/.screenrc
-  fi
-else
-  echo "Couldn't find screen, need to fallback."
-  exit 1
-fi`,
-		}, nil)
+	plainCommand := command
+	if isTerminal(stdin) && !disableScreen {
+		err := installScreen(ctx, container, options)
 		if err != nil {
-			ctx.Log().Debugf("Error installing screen: %s %s %v", string(bufferStdout), string(bufferStderr), err)
+			// users that prevent package installs expect screen to be missing
+			if !options.NoPackageInstall {
+				options.screenFallback(err)
+			}
 		} else {
 			useScreen = true
 		}
 	}
+	logPath := ""
 	if useScreen {
-		newCommand := []string{"screen", "-dRSqL", screenSession, "--"}
-		newCommand = append(newCommand, command...)
-		command = newCommand
+		command, logPath = screenCommand(container, options, command)
+	}
+
+	// tooling inside the container can discover the session through the session file
+	if subResource == kubectl.SubResourceExec && !options.DisableSessionFile {
+		sessionFilePath, err := writeSessionFile(ctx, container)
+		if err != nil {
+			ctx.Log().Debugf("Error writing session file: %v", err)
+		} else {
+			command = withSessionFileCleanup(command, sessionFilePath)
+			plainCommand = withSessionFileCleanup(plainCommand, sessionFilePath)
+		}
+	}
+	if options.injectedKubeconfigPath != "" {
+		command = withKubeconfigCleanup(command, options.injectedKubeconfigPath)
+		plainCommand = withKubeconfigCleanup(plainCommand, options.injectedKubeconfigPath)
+	}
+	if options.initScriptPath != "" {
+		command = withInitScriptCleanup(command, options.initScriptPath)
+		plainCommand = withInitScriptCleanup(plainCommand, options.initScriptPath)
 	}
 
 	ctx.Log().Debugf("Starting terminal...")
+	if len(options.ForwardSockets) > 0 {
+		// the socket forwardings are torn down together with the session
+		forwardCtx, cancelForward := context.WithCancel(ctx.Context())
+		defer cancelForward()
+		for _, forward := range options.ForwardSockets {
+			go forwardSocket(forwardCtx, unlimitedClient(ctx.KubeClient()), container, forward, ctx.Log())
+		}
+	}
+	if options.WatchdogInterval > 0 {
+		watchdogLog := options.WatchdogLog
+		if watchdogLog == nil {
+			watchdogLog = log.GetFileLogger("terminal")
+		}
 
-	before := log.GetBaseInstance().GetLevel()
-	log.GetBaseInstance().SetLevel(logrus.PanicLevel)
-	err := ctx.KubeClient().ExecStream(ctx.Context(), &kubectl.ExecStreamOptions{
-		Pod:         container.Pod,
-		Container:   container.Container.Name,
-		Command:     command,
-		TTY:         tty,
-		Stdin:       stdin,
-		Stdout:      stdout,
-		Stderr:      stderr,
-		SubResource: kubectl.SubResourceExec,
-	})
-	log.GetBaseInstance().SetLevel(before)
+		// the watchdog is stopped on every exit of the session, including restarts and panics
+		stopWatchdog := make(chan struct{})
+		defer close(stopWatchdog)
+		go watchSession(watchdogLog, container, options.WatchdogInterval, stopWatchdog)
+	}
+	stopConnected := options.emitConnected(ctx, container, prompt)
+
+	execOptions := &kubectl.ExecStreamOptions{
+		Pod:               container.Pod,
+		Container:         container.Container.Name,
+		Command:           command,
+		TTY:               tty,
+		ForceTTY:          forceTTY,
+		TerminalSizeQueue: sizeQueue,
+		Stdin:             stdin,
+		Stdout:            stdout,
+		Stderr:            stderr,
+		SubResource:       subResource,
+		Timeout:           options.MaxSessionDuration,
+		GracePeriod:       options.closeGracePeriod(),
+		StdinPump:         options.stdinPump,
+		HTTPProxy:         options.HTTPProxy,
+		NoProxy:           options.NoProxy,
+		StdinRateLimit:    options.StdinRateLimit,
+		BytesIn:           options.sessionBytesIn,
+		BytesOut:          options.sessionBytesOut,
+		RequestID:         options.requestID(ctx),
+	}
+	if options.ShowCommand {
+		ctx.Log().Infof("Executing command in container %s: %s", container.Container.Name, redactCommand(execOptions.Command, options.EnvVars, options.redactEnv))
+	}
+
+	stopRecording := options.recordSessionBytes(ctx)
+	started := time.Now()
+	rawMode := isTerminal(options.Stdin)
+	err = execStream(ctx, kubectlPath, execOptions, rawMode)
+	if (tty || forceTTY) && !options.DisableTTYFallback && isTTYNegotiationError(err, time.Since(started)) {
+		// screen needs a terminal, so the downgraded session runs the plain command
+		ctx.Log().Warnf("Couldn't negotiate a tty with pod %s, falling back to a session without tty: %v", container.Pod.Name, err)
+		execOptions.Command = plainCommand
+		execOptions.TTY = false
+		execOptions.ForceTTY = false
+		execOptions.TerminalSizeQueue = nil
+		useScreen = false
+		err = execStream(ctx, kubectlPath, execOptions, rawMode)
+	}
+	stopConnected()
+	stopRecording()
+	if useScreen {
+		options.summary.ranIn(MultiplexerScreen)
+	} else {
+		options.summary.ranIn("")
+	}
 	if err != nil {
 		ctx.Log().Debugf("error executing stream: %v", err)
 	}
+	if hint := commandHint(err, time.Since(started), options.CommandHintTimeout, hintCommand, container.Container.Name); hint != "" {
+		ctx.Log().Warn(hint)
+	}
+	options.emitEvent(ctx, newSessionEndedEvent(container.Pod.Name, container.Container.Name, err))
+	if exitError, ok := err.(kubectlExec.CodeExitError); ok && options.SnapshotOnExit {
+		snapshotContainer(ctx, container, exitError.Code)
+	}
+	if options.OnSessionEnd != nil {
+		exitCode := -1
+		if err == nil {
+			exitCode = 0
+		} else if exitError, ok := err.(kubectlExec.CodeExitError); ok {
+			exitCode = exitError.Code
+		}
+
+		options.OnSessionEnd(container.Pod.Name, container.Container.Name, exitCode, err)
+	}
+
+	if useScreen && screenLogExport != "" {
+		exportScreenLog(ctx, container, logPath, ctx.ResolvePath(screenLogExport), err)
+	}
 
 	return err
 }
 
+// runPreExecHook executes the local command and writes its output to out
+func runPreExecHook(ctx devspacecontext.Context, command []string, out io.Writer) error {
+	ctx.Log().Debugf("Running pre exec hook %s", strings.Join(command, " "))
+	cmd := exec.CommandContext(ctx.Context(), command[0], command[1:]...)
+	cmd.Dir = ctx.WorkingDir()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "run pre exec hook %s", strings.Join(command, " "))
+	}
+
+	return nil
+}
+
+// execStream executes the stream. If kubectlPath is set, the stream is executed
+// by the kubectl binary instead of the builtin client. If the stream puts the local
+// terminal into raw mode, the base logger is silenced while it runs, as log lines
+// would be garbled and interleave with the output of the session.
+func execStream(ctx devspacecontext.Context, kubectlPath string, options *kubectl.ExecStreamOptions, rawMode bool) error {
+	if rawMode && (options.TTY || options.ForceTTY) {
+		defer silenceBaseLogger()()
+	}
+
+	if kubectlPath != "" {
+		return nativeExecStream(ctx.Context(), kubectlPath, ctx.KubeClient(), options)
+	}
+
+	return ctx.KubeClient().ExecStream(ctx.Context(), options)
+}
+
+// forbiddenError returns a clear error message if err is caused by missing permissions in the namespace
+func forbiddenError(err error, namespace string) error {
+	if kerrors.IsForbidden(err) {
+		return fmt.Errorf("insufficient permissions in namespace %s, please make sure you are allowed to list and exec into pods: %v", namespace, err)
+	}
+
+	return err
+}
+
+// restartMessagePrefix is prepended to every message that announces a terminal restart
+const restartMessagePrefix = "[devspace] restarting terminal: "
+
+// execStreamError explains why the exec stream to the container failed. API errors that point
+// to a problem with the pod or the permissions are turned into actionable messages.
+func execStreamError(err error, container *selector.SelectedPodContainer) error {
+	switch {
+	case kerrors.IsForbidden(err):
+		return fmt.Errorf("you lack exec permission on pod %s in namespace %s, please make sure you are allowed to create pods/exec: %w", container.Pod.Name, container.Pod.Namespace, err)
+	case kerrors.IsNotFound(err):
+		return fmt.Errorf("pod %s in namespace %s does not exist anymore: %w", container.Pod.Name, container.Pod.Namespace, err)
+	case kerrors.IsConflict(err):
+		return fmt.Errorf("pod %s in namespace %s is being modified or terminating: %w", container.Pod.Name, container.Pod.Namespace, err)
+	}
+
+	return fmt.Errorf("lost connection to pod %s: %w", container.Pod.Name, err)
+}
+
+// logRestart prints a message that the terminal is restarting because of the given reason.
+// The message is colorized only if out is a terminal, so plain output stays unchanged when piped.
+func logRestart(ctx devspacecontext.Context, out io.Writer, reason error) {
+	ctx.Log().WriteString(logrus.InfoLevel, "\n")
+	ctx.Log().Info(formatRestartMessage(reason, isTerminal(out)))
+}
+
+func formatRestartMessage(reason error, colorize bool) string {
+	message := restartMessagePrefix + reason.Error()
+	if colorize {
+		return ansi.Color(message, "yellow")
+	}
+
+	return message
+}
+
+// DefaultBannerColor is the ansi style of the pod and container name in the banner
+const DefaultBannerColor = "white+b"
+
+// banner returns the message that is printed when a terminal to the container is opened
+func banner(out io.Writer, color string, container *selector.SelectedPodContainer) string {
+	if color == "" {
+		color = DefaultBannerColor
+	}
+
+	return forOutput(out, fmt.Sprintf("Opening shell to pod:container %s:%s", ansi.Color(container.Pod.Name, color), ansi.Color(container.Container.Name, color)))
+}
+
+// renderBanner replaces the \n escape sequences in message with new lines and ${POD_NAME}
+// and ${CONTAINER_NAME} with the names of the pod and container
+func renderBanner(message string, container *selector.SelectedPodContainer) string {
+	return strings.NewReplacer(
+		`\n`, "\n",
+		"${POD_NAME}", container.Pod.Name,
+		"${CONTAINER_NAME}", container.Container.Name,
+	).Replace(message)
+}
+
+// bannerWriter prints the banner before the first output of the session, which shows that the
+// session is connected. The once is shared across restarts, so the banner is only printed once.
+type bannerWriter struct {
+	io.Writer
+
+	banner string
+	once   *sync.Once
+}
+
+func (w *bannerWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		_, _ = io.WriteString(w.Writer, w.banner)
+	})
+
+	return w.Writer.Write(p)
+}
+
+// forOutput strips ANSI escape sequences from the message if out is not a terminal,
+// so that piped output, e.g. devspace enter -- ls | grep foo, is not polluted by colors
+func forOutput(out io.Writer, message string) string {
+	if !isTerminal(out) {
+		return stripANSI(message)
+	}
+
+	return message
+}
+
+func stripANSI(s string) string {
+	return stripansi.Strip(s)
+}
+
 func IsUnexpectedExitCode(code int) bool {
 	// Expected exit codes are (https://shapeshed.com/unix-exit-codes/):
 	// 1 - Catchall for general errors
@@ -245,15 +1055,128 @@ func IsUnexpectedExitCode(code int) bool {
 	return code != 0 && code != 1 && code != 2 && code != 126 && code != 127 && code != 128 && code != 130
 }
 
-func getCommand(devContainer *latest.DevContainer) []string {
-	command := devContainer.Terminal.Command
-	if command == "" {
+// initScriptPathFormat is the path inside the container the terminal init script of a session is written to
+const initScriptPathFormat = "/tmp/.devspace-init-%s.sh"
+
+// initScriptHeader is prepended to the init script. BASH_ENV and ENV are unset, so that shells started
+// from the terminal don't source the script again. As rc file of an interactive bash it replaces
+// ~/.bashrc, so that is loaded first.
+const initScriptHeader = `unset BASH_ENV ENV
+case $- in *i*) if [ -n "$BASH_VERSION" ] && [ -f ~/.bashrc ]; then . ~/.bashrc; fi ;; esac
+`
+
+// initScriptCleanupScript removes the init script given as first argument once the command has ended. It runs
+// as the exec user, which can remove the script from /tmp even if the shell runs as a different user, and
+// also cleans up if screen reattaches to a running session without sourcing the script.
+const initScriptCleanupScript = `initscript="$1"; shift; trap 'rm -f "$initscript"' EXIT; trap 'exit 129' HUP; trap 'exit 130' INT; trap 'exit 143' TERM; "$@"`
+
+// uploadInitScript writes the given init script to path inside the container
+func uploadInitScript(ctx devspacecontext.Context, container *selector.SelectedPodContainer, initScript string, path string) error {
+	ctx.Log().Debugf("Writing terminal init script to %s...", path)
+	initScript = initScriptHeader + initScript
+	stdout, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{"sh", "-c", "cat > " + path}, strings.NewReader(initScript))
+	if err != nil {
+		return fmt.Errorf("error writing init script: %s %s %v", string(stdout), string(stderr), err)
+	}
+
+	return nil
+}
+
+// withInitScriptCleanup wraps the command into a shell that removes the init script once the command has ended
+func withInitScriptCleanup(command []string, initScriptPath string) []string {
+	return append([]string{"sh", "-c", initScriptCleanupScript, "devspace-init", initScriptPath}, command...)
+}
+
+// withEnvVars prefixes the command with env to execute it with the given environment variables
+func withEnvVars(command []string, envVars map[string]string) []string {
+	if len(envVars) == 0 {
+		return command
+	}
+
+	keys := make([]string, 0, len(envVars))
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	newCommand := []string{"env"}
+	for _, key := range keys {
+		newCommand = append(newCommand, key+"="+envVars[key])
+	}
+
+	return append(newCommand, command...)
+}
+
+// metadataEnvVars returns the environment variables that describe the container the terminal is opened to
+func metadataEnvVars(container *selector.SelectedPodContainer) map[string]string {
+	return map[string]string{
+		"DEVSPACE_POD":       container.Pod.Name,
+		"DEVSPACE_CONTAINER": container.Container.Name,
+		"DEVSPACE_NAMESPACE": container.Pod.Namespace,
+	}
+}
+
+// mergeEnvVars returns a new map with the values of override applied over base
+func mergeEnvVars(base map[string]string, override map[string]string) map[string]string {
+	envVars := map[string]string{}
+	for key, value := range base {
+		envVars[key] = value
+	}
+	for key, value := range override {
+		envVars[key] = value
+	}
+
+	return envVars
+}
+
+// terminalShellPath returns the shell that executes the command of the dev container terminal
+func terminalShellPath(devContainer *latest.DevContainer) string {
+	if devContainer.Terminal.ShellPath != "" {
+		return devContainer.Terminal.ShellPath
+	}
+
+	return "sh"
+}
+
+func getCommand(devContainer *latest.DevContainer, command string, initScriptPath string) []string {
+	shellPath := terminalShellPath(devContainer)
+
+	// interactive shells load the init script themselves, otherwise the aliases and functions it
+	// defines would be lost when the shell replaces the one that sourced it. bash reads BASH_ENV
+	// instead of the rc file if stdin isn't a terminal.
+	switch {
+	case initScriptPath == "" && command == "":
 		command = "command -v bash >/dev/null 2>&1 && exec bash || exec sh"
+	case initScriptPath == "":
+	case command == "":
+		command = fmt.Sprintf("command -v bash >/dev/null 2>&1 && exec env BASH_ENV=%s bash --rcfile %s || exec env ENV=%s sh", initScriptPath, initScriptPath, initScriptPath)
+	case command == "bash":
+		command = fmt.Sprintf("exec env BASH_ENV=%s bash --rcfile %s", initScriptPath, initScriptPath)
+	case command == "sh":
+		command = fmt.Sprintf("exec env ENV=%s sh", initScriptPath)
+	default:
+		command = fmt.Sprintf(". %s; %s", initScriptPath, command)
 	}
 
 	if devContainer.Terminal.WorkDir != "" {
-		return []string{"sh", "-c", fmt.Sprintf("cd %s; %s", devContainer.Terminal.WorkDir, command)}
+		command = fmt.Sprintf("cd %s; %s", devContainer.Terminal.WorkDir, command)
+	}
+
+	// env only applies to a single program, so the command is executed by a new shell
+	// that replaces the current one, which also works for builtins and compound commands
+	if len(devContainer.Terminal.Env) > 0 {
+		keys := make([]string, 0, len(devContainer.Terminal.Env))
+		for key := range devContainer.Terminal.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		envCommand := "exec env"
+		for _, key := range keys {
+			envCommand += " " + key + "=" + shellquote.Join(devContainer.Terminal.Env[key])
+		}
+		command = envCommand + " " + shellquote.Join(shellPath) + " -c " + shellquote.Join(command)
 	}
 
-	return []string{"sh", "-c", command}
+	return []string{shellPath, "-c", command}
 }
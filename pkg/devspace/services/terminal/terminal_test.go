@@ -0,0 +1,1245 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	interruptpkg "github.com/loft-sh/devspace/pkg/util/interrupt"
+	kubeconfigtesting "github.com/loft-sh/devspace/pkg/util/kubeconfig/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/ptr"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/mgutz/ansi"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	remotecommandconsts "k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/remotecommand"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+type formatRestartMessageTestCase struct {
+	name     string
+	reason   error
+	colorize bool
+
+	expected string
+}
+
+func TestFormatRestartMessage(t *testing.T) {
+	testCases := []formatRestartMessageTestCase{
+		{
+			name:     "Plain",
+			reason:   fmt.Errorf("lost connection to pod test"),
+			expected: "[devspace] restarting terminal: lost connection to pod test",
+		},
+		{
+			name:     "Colorized",
+			reason:   fmt.Errorf("lost connection to pod test"),
+			colorize: true,
+			expected: ansi.Color("[devspace] restarting terminal: lost connection to pod test", "yellow"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		message := formatRestartMessage(testCase.reason, testCase.colorize)
+		assert.Equal(t, message, testCase.expected, "Unexpected message in "+testCase.name)
+	}
+}
+
+type forOutputTestCase struct {
+	name     string
+	message  string
+	terminal bool
+
+	expected string
+}
+
+func TestForOutput(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+
+	testCases := []forOutputTestCase{
+		{
+			name:     "Piped output",
+			message:  "Opening shell to " + ansi.Color("pod", "white+b") + ":" + ansi.Color("container", "white+b"),
+			expected: "Opening shell to pod:container",
+		},
+		{
+			name:     "Terminal output",
+			message:  "Opening shell to " + ansi.Color("pod", "white+b"),
+			terminal: true,
+			expected: "Opening shell to " + ansi.Color("pod", "white+b"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		isTerminal = func(interface{}) bool { return testCase.terminal }
+		assert.Equal(t, forOutput(io.Discard, testCase.message), testCase.expected, "Unexpected message in "+testCase.name)
+	}
+}
+
+func TestBanner(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}},
+		Container: &corev1.Container{Name: "container"},
+	}
+
+	isTerminal = func(interface{}) bool { return true }
+	assert.Equal(t, banner(io.Discard, "", container), "Opening shell to pod:container "+ansi.Color("pod", DefaultBannerColor)+":"+ansi.Color("container", DefaultBannerColor))
+	assert.Equal(t, banner(io.Discard, "blue+b", container), "Opening shell to pod:container "+ansi.Color("pod", "blue+b")+":"+ansi.Color("container", "blue+b"))
+
+	isTerminal = func(interface{}) bool { return false }
+	assert.Equal(t, banner(io.Discard, "blue+b", container), "Opening shell to pod:container pod:container")
+}
+
+// outputExecClient writes a prompt to stdout in every session
+type outputExecClient struct {
+	kubectltesting.Client
+}
+
+func (c *outputExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	_, err := options.Stdout.Write([]byte("$ "))
+	return err
+}
+
+// reconnectingOutputExecClient fails the first session before it is connected and loses the connection
+// of the second one after it wrote a prompt
+type reconnectingOutputExecClient struct {
+	kubectltesting.Client
+
+	sessions int
+}
+
+func (c *reconnectingOutputExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.sessions++
+	if c.sessions == 1 {
+		return fmt.Errorf("connection refused")
+	}
+
+	_, err := options.Stdout.Write([]byte("$ "))
+	if err != nil {
+		return err
+	} else if c.sessions == 2 {
+		return fmt.Errorf("connection reset by peer")
+	}
+
+	return nil
+}
+
+func TestStartTerminalBanner(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&outputExecClient{})
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "container"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{Shell: ShellSh, Banner: `Welcome to ${POD_NAME}\n${CONTAINER_NAME} is ready`}}
+
+	stdout := &bytes.Buffer{}
+	isTerminal = func(v interface{}) bool { return v == stdout }
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(stdout, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, stdout.String(), "Welcome to pod\ncontainer is ready\n$ ")
+
+	// piped output doesn't get the banner
+	isTerminal = func(interface{}) bool { return false }
+	stdout.Reset()
+	err = StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(stdout, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, stdout.String(), "$ ")
+}
+
+func TestStartTerminalBannerRestart(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&reconnectingOutputExecClient{})
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "container"},
+	}}
+
+	// the banner is printed once the first session is connected and not again after restarts
+	stdout := &bytes.Buffer{}
+	isTerminal = func(v interface{}) bool { return v == stdout }
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithRestart(RestartUnlimited),
+		WithDisableSessionFile(true),
+		WithStreams(stdout, io.Discard, strings.NewReader("")),
+		func(options *TerminalOptions) {
+			options.Banner = "Welcome"
+		},
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, stdout.String(), "Welcome\n$ $ ")
+}
+
+type getCommandTestCase struct {
+	name           string
+	terminal       *latest.Terminal
+	initScriptPath string
+
+	expected []string
+}
+
+func TestGetCommand(t *testing.T) {
+	testCases := []getCommandTestCase{
+		{
+			name:     "Default command",
+			terminal: &latest.Terminal{},
+			expected: []string{"sh", "-c", "command -v bash >/dev/null 2>&1 && exec bash || exec sh"},
+		},
+		{
+			name: "Command with work dir",
+			terminal: &latest.Terminal{
+				Command: "echo test",
+				WorkDir: "/app",
+			},
+			expected: []string{"sh", "-c", "cd /app; echo test"},
+		},
+		{
+			name:           "Init script with default command",
+			terminal:       &latest.Terminal{},
+			initScriptPath: "/tmp/init.sh",
+			expected:       []string{"sh", "-c", "command -v bash >/dev/null 2>&1 && exec env BASH_ENV=/tmp/init.sh bash --rcfile /tmp/init.sh || exec env ENV=/tmp/init.sh sh"},
+		},
+		{
+			name:           "Init script with bash",
+			terminal:       &latest.Terminal{Command: "bash"},
+			initScriptPath: "/tmp/init.sh",
+			expected:       []string{"sh", "-c", "exec env BASH_ENV=/tmp/init.sh bash --rcfile /tmp/init.sh"},
+		},
+		{
+			name:           "Init script with sh",
+			terminal:       &latest.Terminal{Command: "sh", WorkDir: "/app"},
+			initScriptPath: "/tmp/init.sh",
+			expected:       []string{"sh", "-c", "cd /app; exec env ENV=/tmp/init.sh sh"},
+		},
+		{
+			name: "Init script with overridden command",
+			terminal: &latest.Terminal{
+				Command: "echo test",
+				WorkDir: "/app",
+			},
+			initScriptPath: "/tmp/init.sh",
+			expected:       []string{"sh", "-c", "cd /app; . /tmp/init.sh; echo test"},
+		},
+		{
+			name: "Env with spaces and quotes",
+			terminal: &latest.Terminal{
+				Command: "echo test",
+				WorkDir: "/app",
+				Env:     map[string]string{"GREETING": "hello world", "NAME": "it's me"},
+			},
+			expected: []string{"sh", "-c", `exec env GREETING='hello world' NAME='it'\''s me' sh -c 'cd /app; echo test'`},
+		},
+		{
+			name: "Empty env",
+			terminal: &latest.Terminal{
+				Command: "echo test",
+				Env:     map[string]string{},
+			},
+			expected: []string{"sh", "-c", "echo test"},
+		},
+		{
+			name: "Shell path",
+			terminal: &latest.Terminal{
+				Command:   "echo test",
+				ShellPath: "/bin/bash",
+			},
+			expected: []string{"/bin/bash", "-c", "echo test"},
+		},
+		{
+			name: "Shell path with env",
+			terminal: &latest.Terminal{
+				Command:   "echo test",
+				Env:       map[string]string{"NAME": "test"},
+				ShellPath: "/busybox/sh",
+			},
+			expected: []string{"/busybox/sh", "-c", `exec env NAME=test /busybox/sh -c 'echo test'`},
+		},
+		{
+			name: "Shell path with spaces and env",
+			terminal: &latest.Terminal{
+				Command:   "echo test",
+				Env:       map[string]string{"NAME": "test"},
+				ShellPath: "/opt/my tools/sh",
+			},
+			expected: []string{"/opt/my tools/sh", "-c", `exec env NAME=test '/opt/my tools/sh' -c 'echo test'`},
+		},
+	}
+
+	for _, testCase := range testCases {
+		command := getCommand(&latest.DevContainer{Terminal: testCase.terminal}, testCase.terminal.Command, testCase.initScriptPath)
+		assert.DeepEqual(t, command, testCase.expected)
+	}
+}
+
+func TestStartTerminalInitScript(t *testing.T) {
+	client := &kubeconfigExecClient{written: map[string]string{}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Command:            "bash",
+		Shell:              "sh",
+		InitScript:         "greet() { echo \"hello $1\"; }",
+		DisableScreen:      true,
+		DisableSessionFile: true,
+	}}
+
+	// concurrent sessions of the same run don't share the init script
+	paths := map[string]string{}
+	for i := 0; i < 2; i++ {
+		err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err)
+		command := client.options.Command[len(client.options.Command)-1]
+		for path, content := range client.written {
+			if strings.HasPrefix(path, "cat > /tmp/.devspace-init-") {
+				paths[strings.TrimPrefix(path, "cat > ")] = content
+			}
+		}
+		assert.Assert(t, strings.Contains(command, "bash --rcfile /tmp/.devspace-init-"), "Expected bash to load the init script, got %s", command)
+		assert.DeepEqual(t, client.options.Command[:3], []string{"sh", "-c", initScriptCleanupScript})
+	}
+	assert.Equal(t, len(paths), 2)
+
+	// the functions of the script are available in the shell that runs the command and the wrapper removes the script
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not found")
+	}
+	for path, content := range paths {
+		localPath := filepath.Join(t.TempDir(), "init.sh")
+		assert.NilError(t, os.WriteFile(localPath, []byte(strings.ReplaceAll(content, path, localPath)), 0644))
+		command := withInitScriptCleanup(getCommand(devContainer, "bash", localPath), localPath)
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = strings.NewReader("greet world\n")
+		out, err := cmd.CombinedOutput()
+		assert.NilError(t, err, string(out))
+		assert.Equal(t, string(out), "hello world\n")
+		_, err = os.Stat(localPath)
+		assert.Assert(t, os.IsNotExist(err), "Expected the init script to be removed")
+	}
+}
+
+func TestStartTerminalShellPath(t *testing.T) {
+	client := &recordingExecClient{}
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Command:            "bash",
+		Shell:              "sh",
+		ShellPath:          "/bin/bash",
+		DisableScreen:      true,
+		DisableSessionFile: true,
+	}}
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, client.options.Command[len(client.options.Command)-3:], []string{"/bin/bash", "-c", "bash"})
+	assert.Assert(t, !strings.Contains(out.String(), "not an absolute path"), "Unexpected warning: %s", out.String())
+
+	// a relative path is used as is, but prints a warning
+	devContainer.Terminal.ShellPath = "bash"
+	err = StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, client.options.Command[len(client.options.Command)-3:], []string{"bash", "-c", "bash"})
+	assert.Assert(t, strings.Contains(out.String(), "Terminal shellPath bash is not an absolute path"), "Expected a warning, got %s", out.String())
+}
+
+func TestStartTerminalRequestID(t *testing.T) {
+	client := &recordingExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{DisableScreen: true, DisableSessionFile: true}}
+
+	// without a request id in the context the session id is used
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Assert(t, client.options.RequestID != "", "Expected a request id")
+
+	err = StartTerminalWithOptions(devspacecontext.WithRequestID(ctx, "test-request-id"), devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, client.options.RequestID, "test-request-id")
+}
+
+func TestStartTerminalFromCMDAttachWithCommand(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard)
+	_, err := StartTerminalFromCMDWithOptions(ctx, nil, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithSubResource(kubectl.SubResourceAttach),
+	))
+	assert.Error(t, err, "a command cannot be specified when attaching to a container")
+}
+
+// screenRecordingClient records the screen install probes and sessions started through it
+type screenRecordingClient struct {
+	kubectltesting.Client
+
+	scripts  []string
+	sessions map[string]bool
+	mutex    sync.Mutex
+}
+
+func (c *screenRecordingClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(command) > 2 {
+		c.scripts = append(c.scripts, command[2])
+	}
+	return []byte("Screen installed successfully."), nil, nil
+}
+
+func (c *screenRecordingClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(options.Command) > 2 && options.Command[0] == "screen" {
+		c.sessions[options.Command[2]] = true
+	}
+	return nil
+}
+
+// fixedSelector always selects the same container
+type fixedSelector struct {
+	container *selector.SelectedPodContainer
+	namespace string
+}
+
+func (f *fixedSelector) SelectSinglePod(ctx context.Context, client kubectl.Client, log log.Logger) (*corev1.Pod, error) {
+	return f.container.Pod, nil
+}
+
+func (f *fixedSelector) SelectSingleContainer(ctx context.Context, client kubectl.Client, log log.Logger) (*selector.SelectedPodContainer, error) {
+	return f.container, nil
+}
+
+func (f *fixedSelector) WithContainer(container string) targetselector.TargetSelector {
+	return f
+}
+
+func (f *fixedSelector) WithPreferNewest() targetselector.TargetSelector {
+	return f
+}
+
+func (f *fixedSelector) WithAvoidScalingDown() targetselector.TargetSelector {
+	return f
+}
+
+func (f *fixedSelector) WithCache() targetselector.TargetSelector {
+	return f
+}
+
+func (f *fixedSelector) WithBypassCache() targetselector.TargetSelector {
+	return f
+}
+
+func (f *fixedSelector) WithNamespace(namespace string) targetselector.TargetSelector {
+	f.namespace = namespace
+	return f
+}
+
+func TestStartTerminalNamedSessions(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(interface{}) bool { return true }
+	screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+
+	client := &screenRecordingClient{sessions: map[string]bool{}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "named-sessions"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{Shell: ShellSh, DisableSessionFile: true}}
+
+	waitGroup := sync.WaitGroup{}
+	for _, session := range []string{"first", "second"} {
+		waitGroup.Add(1)
+		go func(session string) {
+			defer waitGroup.Done()
+
+			err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+				WithScreenSession(session),
+				WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+			))
+			assert.NilError(t, err)
+		}(session)
+	}
+	waitGroup.Wait()
+
+	assert.DeepEqual(t, client.sessions, map[string]bool{"first": true, "second": true})
+	assert.DeepEqual(t, client.scripts, []string{DefaultScreenInstallScript, screenVersionScript, screenrcScript(false)})
+}
+
+type screenSessionNameTestCase struct {
+	name              string
+	screenSession     string
+	screenSessionName string
+
+	expectedSession string
+}
+
+func TestStartTerminalScreenSessionName(t *testing.T) {
+	testCases := []screenSessionNameTestCase{
+		{
+			name:            "Default",
+			expectedSession: DefaultScreenSessionName,
+		},
+		{
+			name:              "Configured",
+			screenSessionName: "frontend",
+			expectedSession:   "frontend",
+		},
+		{
+			name:            "Option",
+			screenSession:   "backend",
+			expectedSession: "backend",
+		},
+		{
+			name:              "Configured overrides option",
+			screenSession:     "backend",
+			screenSessionName: "frontend",
+			expectedSession:   "frontend",
+		},
+	}
+
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(interface{}) bool { return true }
+	for _, testCase := range testCases {
+		screenProbes = &screenProbeCache{confirmed: map[string]bool{}}
+		client := &screenRecordingClient{sessions: map[string]bool{}}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "screen-session-name"}},
+			Container: &corev1.Container{Name: "test"},
+		}}
+		devContainer := &latest.DevContainer{Terminal: &latest.Terminal{Shell: ShellSh, ScreenSessionName: testCase.screenSessionName, DisableSessionFile: true}}
+
+		err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+			WithScreenSession(testCase.screenSession),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.DeepEqual(t, client.sessions, map[string]bool{testCase.expectedSession: true})
+	}
+}
+
+// eofExecClient fails the first reconnects exec streams with io.EOF
+type eofExecClient struct {
+	kubectltesting.Client
+
+	remaining int
+}
+
+func (c *eofExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	if c.remaining > 0 {
+		c.remaining--
+		return io.EOF
+	}
+
+	return nil
+}
+
+// stdinPumpExecClient records the stdin pumps of the sessions and ends the first sessions with an error
+type stdinPumpExecClient struct {
+	eofExecClient
+
+	pumps []*kubectl.StdinPump
+}
+
+func (c *stdinPumpExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.pumps = append(c.pumps, options.StdinPump)
+	return c.eofExecClient.ExecStream(ctx, options)
+}
+
+func TestStartTerminalRestartSharesStdin(t *testing.T) {
+	client := &stdinPumpExecClient{eofExecClient: eofExecClient{remaining: 1}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+
+	// a read of the first session that is still blocked must not swallow the input of the restarted one
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithRestart(1),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, len(client.pumps), 2)
+	assert.Assert(t, client.pumps[0] != nil && client.pumps[0] == client.pumps[1], "Expected the sessions to share the stdin pump")
+}
+
+func BenchmarkStartTerminalReconnect(b *testing.B) {
+	const reconnects = 10
+
+	client := &eofExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+	options := NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithTTY(false),
+		WithRestart(RestartUnlimited),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.remaining = reconnects
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, options)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*reconnects), "ns/restart")
+}
+
+// newCatExecServer returns an API server that handles exec requests like cat in the container. The
+// received stdin is written to stdout once the client closed stdin, so the command only exits after EOF.
+func newCatExecServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := httpstream.Handshake(r, w, []string{remotecommandconsts.StreamProtocolV4Name})
+		if err != nil {
+			t.Errorf("Unexpected handshake error: %v", err)
+			return
+		}
+
+		streams := make(chan httpstream.Stream, 5)
+		conn := spdy.NewResponseUpgrader().UpgradeResponse(w, r, func(stream httpstream.Stream, replySent <-chan struct{}) error {
+			streams <- stream
+			return nil
+		})
+		if conn == nil {
+			return
+		}
+		defer conn.Close()
+
+		received := map[string]httpstream.Stream{}
+		for received[corev1.StreamTypeError] == nil || received[corev1.StreamTypeStdin] == nil || received[corev1.StreamTypeStdout] == nil {
+			select {
+			case stream := <-streams:
+				received[stream.Headers().Get(corev1.StreamType)] = stream
+			case <-time.After(time.Second * 5):
+				t.Errorf("Expected the client to create the stdin, stdout and error streams")
+				return
+			}
+		}
+
+		_, _ = io.Copy(received[corev1.StreamTypeStdout], received[corev1.StreamTypeStdin])
+		_ = received[corev1.StreamTypeStdout].Close()
+		_, _ = received[corev1.StreamTypeError].Write([]byte(`{"metadata":{},"status":"Success"}`))
+		_ = received[corev1.StreamTypeError].Close()
+	}))
+}
+
+func TestStartTerminalFromCMDStdinEOF(t *testing.T) {
+	server := newCatExecServer(t)
+	defer server.Close()
+
+	client, err := kubectl.NewClientFromContext("test", "default", false, &kubeconfigtesting.Loader{RawConfig: &api.Config{
+		Clusters:       map[string]*api.Cluster{"test": {Server: server.URL}},
+		AuthInfos:      map[string]*api.AuthInfo{"test": {}},
+		Contexts:       map[string]*api.Context{"test": {Cluster: "test", AuthInfo: "test"}},
+		CurrentContext: "test",
+	}})
+	assert.NilError(t, err)
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+
+	// a tty is requested, but a remote tty would never forward the end of the piped input
+	stdout := &strings.Builder{}
+	done := make(chan error)
+	go func() {
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"cat"}),
+			WithTTY(true),
+			WithDisableSessionFile(true),
+			WithStreams(stdout, io.Discard, strings.NewReader("line1\nline2\n")),
+		))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NilError(t, err)
+	case <-time.After(time.Second * 10):
+		t.Fatal("Expected command to exit after stdin reached EOF")
+	}
+	assert.Equal(t, stdout.String(), "line1\nline2\n")
+}
+
+// forbiddenExecClient denies every exec stream
+type forbiddenExecClient struct {
+	kubectltesting.Client
+
+	calls int
+}
+
+func (c *forbiddenExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.calls++
+	return kerrors.NewForbidden(schema.GroupResource{Resource: "pods/exec"}, options.Pod.Name, fmt.Errorf("access denied"))
+}
+
+func TestStartTerminalForbidden(t *testing.T) {
+	client := &forbiddenExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "other"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{DisableScreen: true}}
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithRestart(RestartUnlimited),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.ErrorContains(t, err, "you lack exec permission on pod test in namespace other")
+	assert.Equal(t, client.calls, 1, "Expected no restart after a forbidden error")
+}
+
+type execStreamErrorTestCase struct {
+	name string
+	err  error
+
+	expectedErr string
+}
+
+func TestExecStreamError(t *testing.T) {
+	testCases := []execStreamErrorTestCase{
+		{
+			name:        "Forbidden",
+			err:         kerrors.NewForbidden(schema.GroupResource{Resource: "pods/exec"}, "test", fmt.Errorf("access denied")),
+			expectedErr: "you lack exec permission on pod test in namespace default",
+		},
+		{
+			name:        "Not found",
+			err:         kerrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "test"),
+			expectedErr: "pod test in namespace default does not exist anymore",
+		},
+		{
+			name:        "Conflict",
+			err:         kerrors.NewConflict(schema.GroupResource{Resource: "pods"}, "test", fmt.Errorf("pod is terminating")),
+			expectedErr: "pod test in namespace default is being modified or terminating",
+		},
+		{
+			name:        "Connection lost",
+			err:         fmt.Errorf("connection reset by peer"),
+			expectedErr: "lost connection to pod test: connection reset by peer",
+		},
+	}
+
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test"},
+	}
+	for _, testCase := range testCases {
+		err := execStreamError(testCase.err, container)
+		assert.ErrorContains(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+		assert.Equal(t, errors.Is(err, testCase.err), true, "Expected the original error to be wrapped in "+testCase.name)
+	}
+}
+
+func TestStartTerminalFromCMDNamespaceOverride(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&forbiddenExecClient{})
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "other"}},
+		Container: &corev1.Container{Name: "test"},
+	}}
+
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithRestart(RestartUnlimited),
+		WithNamespaceOverride("other"),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.Equal(t, podSelector.namespace, "other", "Expected namespace override to be passed to the selector")
+	assert.ErrorContains(t, err, "insufficient permissions in namespace other")
+}
+
+// exitCodeExecClient exits every exec stream with the given exit code
+type exitCodeExecClient struct {
+	kubectltesting.Client
+
+	exitCode int
+}
+
+func (c *exitCodeExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	return kubectlExec.CodeExitError{Err: fmt.Errorf("command terminated with exit code %d", c.exitCode), Code: c.exitCode}
+}
+
+func TestStartTerminalFromCMDOnSessionEnd(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&exitCodeExecClient{exitCode: 3})
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test-container"},
+	}}
+
+	notified := []string{}
+	exitCode, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"sh"}),
+		WithOnSessionEnd(func(podName, containerName string, exitCode int, err error) {
+			notified = append(notified, fmt.Sprintf("%s:%s %d", podName, containerName, exitCode))
+		}),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, exitCode, 3)
+	assert.DeepEqual(t, notified, []string{"test-pod:test-container 3"})
+}
+
+func TestStartTerminalFromCMDRestartsInterruptHandler(t *testing.T) {
+	client := &recordingExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test-container"},
+	}}
+	interruptpkg.Global.Start()
+
+	// the tee file cannot be opened, which fails the session after the interrupt handler was stopped
+	startFailing := func() {
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithTeeFile(filepath.Join(t.TempDir(), "missing", "tee.log")),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.ErrorContains(t, err, "open tee file")
+	}
+	startFailing()
+	assert.Assert(t, client.options == nil, "Expected no exec")
+	assert.Assert(t, interruptpkg.Global.Started(), "Expected the interrupt handler to be started again")
+
+	// a nested session doesn't start the interrupt handler while the outer one is running
+	interruptpkg.Global.Stop()
+	startFailing()
+	assert.Assert(t, !interruptpkg.Global.Started(), "Expected the interrupt handler to stay stopped")
+	interruptpkg.Global.Start()
+	assert.Assert(t, interruptpkg.Global.Started(), "Expected the interrupt handler to be started again")
+}
+
+func TestStartTerminalFromCMDCommandValidator(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	client := &recordingExecClient{Client: kubectltesting.Client{Client: kubeClient}}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "test-container"},
+	}}
+	allowlist := func(command []string) error {
+		if len(command) == 0 || command[0] != "bash" {
+			return fmt.Errorf("command %v is not allowed in this environment", command)
+		}
+
+		return nil
+	}
+
+	_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"rm", "-rf", "/"}),
+		WithCommandValidator(allowlist),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.Error(t, err, "command [rm -rf /] is not allowed in this environment")
+	assert.Assert(t, client.options == nil, "Expected no exec stream for a disallowed command")
+	assert.Equal(t, len(kubeClient.Actions()), 0, "Expected no api call for a disallowed command")
+
+	_, err = StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+		WithCommand([]string{"bash"}),
+		WithCommandValidator(allowlist),
+		WithDisableSessionFile(true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Assert(t, client.options != nil, "Expected an exec stream for an allowed command")
+}
+
+// recordingExecClient records the options of the last exec stream
+type recordingExecClient struct {
+	kubectltesting.Client
+
+	options *kubectl.ExecStreamOptions
+}
+
+func (c *recordingExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.options = options
+	return nil
+}
+
+type injectMetadataTestCase struct {
+	name           string
+	injectMetadata *bool
+	envVars        map[string]string
+
+	expected []string
+}
+
+func TestStartTerminalInjectMetadata(t *testing.T) {
+	testCases := []injectMetadataTestCase{
+		{
+			name:     "Injected by default",
+			expected: []string{"env", "DEVSPACE_CONTAINER=test", "DEVSPACE_NAMESPACE=default", "DEVSPACE_POD=test-pod", "sh", "-c", "exec bash"},
+		},
+		{
+			name:     "Composes with user env",
+			envVars:  map[string]string{"DEVSPACE_POD": "custom", "FOO": "bar"},
+			expected: []string{"env", "DEVSPACE_CONTAINER=test", "DEVSPACE_NAMESPACE=default", "DEVSPACE_POD=custom", "FOO=bar", "sh", "-c", "exec bash"},
+		},
+		{
+			name:           "Disabled",
+			injectMetadata: ptr.Bool(false),
+			expected:       []string{"sh", "-c", "exec bash"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		client := &recordingExecClient{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"}},
+			Container: &corev1.Container{Name: "test"},
+		}}
+		devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+			Command:            "exec bash",
+			DisableScreen:      true,
+			InjectMetadata:     testCase.injectMetadata,
+			DisableSessionFile: true,
+		}}
+
+		err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+			WithEnvVars(testCase.envVars),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.DeepEqual(t, client.options.Command, testCase.expected)
+	}
+}
+
+type preExecHookTestCase struct {
+	name string
+	hook []string
+
+	expectedErr  string
+	expectedExec bool
+}
+
+func TestStartTerminalFromCMDPreExecHook(t *testing.T) {
+	testCases := []preExecHookTestCase{
+		{
+			name:         "Successful hook",
+			hook:         []string{"true"},
+			expectedExec: true,
+		},
+		{
+			name:        "Failing hook",
+			hook:        []string{"false"},
+			expectedErr: "run pre exec hook false",
+		},
+	}
+
+	for _, testCase := range testCases {
+		client := &recordingExecClient{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+			Container: &corev1.Container{Name: "test"},
+		}}
+
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithPreExecHook(testCase.hook...),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		if testCase.expectedErr != "" {
+			assert.ErrorContains(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+		} else {
+			assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		}
+		assert.Equal(t, client.options != nil, testCase.expectedExec, "Unexpected exec in "+testCase.name)
+	}
+}
+
+type terminalSizeTestCase struct {
+	name string
+	cols uint16
+	rows uint16
+
+	expectedTTY  bool
+	expectedSize *remotecommand.TerminalSize
+}
+
+func TestStartTerminalFromCMDSize(t *testing.T) {
+	testCases := []terminalSizeTestCase{
+		{
+			name: "No size",
+		},
+		{
+			name: "Only cols",
+			cols: 80,
+		},
+		{
+			name:         "Fixed size",
+			cols:         80,
+			rows:         24,
+			expectedTTY:  true,
+			expectedSize: &remotecommand.TerminalSize{Width: 80, Height: 24},
+		},
+	}
+
+	for _, testCase := range testCases {
+		client := &recordingExecClient{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+			Container: &corev1.Container{Name: "test"},
+		}}
+
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"top"}),
+			WithSize(testCase.cols, testCase.rows),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, client.options.TTY, testCase.expectedTTY, "Unexpected tty in "+testCase.name)
+		assert.Equal(t, client.options.ForceTTY, testCase.expectedTTY, "Unexpected force tty in "+testCase.name)
+		if testCase.expectedSize == nil {
+			assert.Assert(t, client.options.TerminalSizeQueue == nil, "Unexpected size queue in "+testCase.name)
+			continue
+		}
+
+		assert.DeepEqual(t, client.options.TerminalSizeQueue.Next(), testCase.expectedSize)
+		assert.Assert(t, client.options.TerminalSizeQueue.Next() == nil, "Expected size to be sent once in "+testCase.name)
+	}
+}
+
+type subResourceTestCase struct {
+	name              string
+	configSubResource string
+	subResource       kubectl.SubResource
+
+	expectedErr         string
+	expectedSubResource kubectl.SubResource
+	expectedCommand     []string
+}
+
+func TestStartTerminalSubResource(t *testing.T) {
+	testCases := []subResourceTestCase{
+		{
+			name:                "Exec by default",
+			expectedSubResource: kubectl.SubResourceExec,
+			expectedCommand:     []string{"sh", "-c", "exec bash"},
+		},
+		{
+			name:                "Attach from config",
+			configSubResource:   "attach",
+			expectedSubResource: kubectl.SubResourceAttach,
+		},
+		{
+			name:                "Attach from options",
+			subResource:         kubectl.SubResourceAttach,
+			expectedSubResource: kubectl.SubResourceAttach,
+		},
+		{
+			name:              "Unknown sub resource",
+			configSubResource: "logs",
+			expectedErr:       `unknown sub resource "logs", please use exec or attach`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		client := &recordingExecClient{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"}},
+			Container: &corev1.Container{Name: "test"},
+		}}
+		devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+			Command:            "exec bash",
+			SubResource:        testCase.configSubResource,
+			DisableScreen:      true,
+			InjectMetadata:     ptr.Bool(false),
+			DisableSessionFile: true,
+		}}
+
+		options := []OptionFunc{WithStreams(io.Discard, io.Discard, strings.NewReader(""))}
+		if testCase.subResource != "" {
+			options = append(options, WithSubResource(testCase.subResource))
+		}
+		err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(options...))
+		if testCase.expectedErr != "" {
+			assert.Error(t, err, testCase.expectedErr, "Unexpected error in "+testCase.name)
+			assert.Assert(t, client.options == nil, "Unexpected exec stream in "+testCase.name)
+			continue
+		}
+
+		assert.NilError(t, err, "Unexpected error in "+testCase.name)
+		assert.Equal(t, client.options.SubResource, testCase.expectedSubResource, "Unexpected sub resource in "+testCase.name)
+		assert.DeepEqual(t, client.options.Command, testCase.expectedCommand)
+	}
+}
+
+func TestStartTerminalFromCMDUnknownSubResource(t *testing.T) {
+	client := &recordingExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+
+	_, err := StartTerminalFromCMDWithOptions(ctx, &fixedSelector{}, NewTerminalOptions(
+		WithSubResource("logs"),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.Error(t, err, `unknown sub resource "logs", please use exec or attach`)
+	assert.Assert(t, client.options == nil, "Unexpected exec stream")
+}
+
+type closedTestCase struct {
+	name    string
+	timeout bool
+	cause   error
+
+	expectedErr error
+	expectedLog string
+}
+
+func TestStartTerminalFromCMDClosed(t *testing.T) {
+	errStopped := errors.New("dev session stopped")
+	testCases := []closedTestCase{
+		{
+			name:        "Closed by user",
+			expectedErr: context.Canceled,
+			expectedLog: "Terminal closed by user",
+		},
+		{
+			name:        "Closed due to timeout",
+			timeout:     true,
+			expectedErr: context.DeadlineExceeded,
+			expectedLog: "Terminal closed due to timeout",
+		},
+		{
+			name:        "Closed by user with cause",
+			cause:       ErrClosedByUser,
+			expectedErr: ErrClosedByUser,
+			expectedLog: "Terminal closed by user",
+		},
+		{
+			name:        "Cancelled by the caller",
+			cause:       errStopped,
+			expectedErr: errStopped,
+			expectedLog: "Terminal closed: dev session stopped",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		client := &blockingExecClient{started: make(chan struct{})}
+		cancelCtx, cancel := context.WithCancel(context.Background())
+		if testCase.timeout {
+			cancelCtx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+		} else if testCase.cause != nil {
+			var cancelCause context.CancelCauseFunc
+			cancelCtx, cancelCause = context.WithCancelCause(context.Background())
+			cancel = func() { cancelCause(testCase.cause) }
+		}
+
+		out := &bytes.Buffer{}
+		ctx := devspacecontext.NewContext(cancelCtx, nil, log.NewStreamLogger(out, out, logrus.InfoLevel)).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+			Container: &corev1.Container{Name: "container"},
+		}}
+		// the goroutine also exits if the terminal ends before it was started, e.g. due to the timeout
+		go func(cancel func(), timeout bool) {
+			select {
+			case <-client.started:
+				if !timeout {
+					cancel()
+				}
+			case <-cancelCtx.Done():
+			}
+		}(cancel, testCase.timeout)
+
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithDisableSessionFile(true),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		cancel()
+		assert.Assert(t, errors.Is(err, testCase.expectedErr), "Unexpected error in %s: %v", testCase.name, err)
+		assert.Assert(t, strings.Contains(out.String(), testCase.expectedLog), "Expected log in %s, got %s", testCase.name, out.String())
+	}
+}
+
+func TestStartTerminalStdinRateLimit(t *testing.T) {
+	client := &recordingExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Command:            "bash",
+		Shell:              "sh",
+		DisableScreen:      true,
+		DisableSessionFile: true,
+		StdinRateLimit:     1024,
+	}}
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithStdinRateLimit(512),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, client.options.StdinRateLimit, int64(1024))
+}
@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	dockerterm "github.com/moby/term"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ttyNegotiationTimeout is the time after which a failed exec stream is not
+// considered a failed tty negotiation anymore, as the session was running
+const ttyNegotiationTimeout = time.Second * 5
+
+// ttyNegotiationErrors are parts of the errors returned if a proxy in front
+// of the api server breaks the upgrade of a tty exec request
+var ttyNegotiationErrors = []string{
+	"upgrade request required",
+	"error while negotiating tty",
+	"tty upgrade",
+}
+
+// upgradeErrorPrefix prefixes every failed upgrade of an exec request. It is only considered
+// a failed tty negotiation if the upgrade didn't fail for one of upgradeErrorReasons.
+const upgradeErrorPrefix = "unable to upgrade connection"
+
+// upgradeErrorReasons are parts of the errors of upgrades that failed for other reasons than the tty
+var upgradeErrorReasons = []string{
+	"not found",
+	"does not exist",
+	"forbidden",
+	"unauthorized",
+}
+
+// isTTYNegotiationError checks if err is caused by a failed tty negotiation. Only errors
+// that occurred right after the exec stream was started are taken into account.
+func isTTYNegotiationError(err error, after time.Duration) bool {
+	if err == nil || after > ttyNegotiationTimeout {
+		return false
+	}
+
+	// the api server answers with an error status if the pod or container doesn't exist or the exec isn't allowed
+	if kerrors.IsNotFound(err) || kerrors.IsForbidden(err) || kerrors.IsUnauthorized(err) {
+		return false
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, ttyError := range ttyNegotiationErrors {
+		if strings.Contains(message, ttyError) {
+			return true
+		}
+	}
+	if !strings.Contains(message, upgradeErrorPrefix) {
+		return false
+	}
+	for _, reason := range upgradeErrorReasons {
+		if strings.Contains(message, reason) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateForceTTY checks that the output of a forced tty can be written to stdout. If stdout is a
+// terminal, its state has to be readable, otherwise it cannot be switched to raw mode.
+func validateForceTTY(stdout io.Writer) error {
+	if stdout == nil {
+		return errors.New("a forced tty requires stdout")
+	}
+
+	fd, isTerminal := dockerterm.GetFdInfo(stdout)
+	if !isTerminal {
+		return nil
+	}
+
+	_, err := dockerterm.SaveState(fd)
+	if err != nil {
+		return errors.Wrap(err, "stdout doesn't support raw mode, which is required for a forced tty")
+	}
+
+	return nil
+}
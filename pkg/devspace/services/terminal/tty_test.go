@@ -0,0 +1,155 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type isTTYNegotiationErrorTestCase struct {
+	name  string
+	err   error
+	after time.Duration
+
+	expected bool
+}
+
+func TestIsTTYNegotiationError(t *testing.T) {
+	testCases := []isTTYNegotiationErrorTestCase{
+		{
+			name:     "No error",
+			expected: false,
+		},
+		{
+			name:     "Upgrade failed",
+			err:      fmt.Errorf("error dialing backend: unable to upgrade connection: <html>502 Bad Gateway</html>"),
+			expected: true,
+		},
+		{
+			name:     "Upgrade request required",
+			err:      fmt.Errorf("Upgrade request required"),
+			expected: true,
+		},
+		{
+			name:     "Container not found",
+			err:      fmt.Errorf("unable to upgrade connection: container not found (\"app\")"),
+			expected: false,
+		},
+		{
+			name:     "Pod does not exist",
+			err:      fmt.Errorf("unable to upgrade connection: pod does not exist"),
+			expected: false,
+		},
+		{
+			name:     "Forbidden",
+			err:      fmt.Errorf("error dialing backend: unable to upgrade connection: Forbidden"),
+			expected: false,
+		},
+		{
+			name:     "Not found status",
+			err:      kerrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod"),
+			expected: false,
+		},
+		{
+			name:     "Upgrade failed after session was running",
+			err:      fmt.Errorf("unable to upgrade connection"),
+			after:    time.Minute,
+			expected: false,
+		},
+		{
+			name:     "Connection lost",
+			err:      fmt.Errorf("connection reset by peer"),
+			expected: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, isTTYNegotiationError(testCase.err, testCase.after), testCase.expected, "Unexpected result in "+testCase.name)
+	}
+}
+
+// ttyFailingExecClient fails every exec stream that requests a tty
+type ttyFailingExecClient struct {
+	kubectltesting.Client
+
+	ttys [][]bool
+}
+
+func (c *ttyFailingExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.ttys = append(c.ttys, []bool{options.TTY, options.ForceTTY})
+	if options.TTY || options.ForceTTY {
+		return fmt.Errorf("unable to upgrade connection: upgrade request required")
+	}
+
+	return nil
+}
+
+func TestStartTerminalFromCMDTTYFallback(t *testing.T) {
+	defer func(original func(interface{}) bool) { isTerminal = original }(isTerminal)
+	isTerminal = func(interface{}) bool { return true }
+
+	for _, disableFallback := range []bool{false, true} {
+		client := &ttyFailingExecClient{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+			Container: &corev1.Container{Name: "test"},
+		}}
+
+		options := NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		)
+		options.DisableTTYFallback = disableFallback
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, options)
+		if disableFallback {
+			assert.ErrorContains(t, err, "unable to upgrade connection")
+			assert.DeepEqual(t, client.ttys, [][]bool{{true, false}})
+		} else {
+			assert.NilError(t, err)
+			assert.DeepEqual(t, client.ttys, [][]bool{{true, false}, {false, false}})
+		}
+	}
+}
+
+func TestStartTerminalFromCMDForceTTY(t *testing.T) {
+	for _, forceTTY := range []bool{false, true} {
+		client := &recordingExecClient{}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}},
+			Container: &corev1.Container{Name: "test"},
+		}}
+
+		// piped stdin disables the tty unless it is forced
+		_, err := StartTerminalFromCMDWithOptions(ctx, podSelector, NewTerminalOptions(
+			WithCommand([]string{"sh"}),
+			WithForceTTY(forceTTY),
+			WithDisableSessionFile(true),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("ls\n")),
+		))
+		assert.NilError(t, err)
+		assert.Equal(t, client.options.TTY, forceTTY)
+		assert.Equal(t, client.options.ForceTTY, forceTTY)
+	}
+
+	_, err := StartTerminalFromCMDWithOptions(devspacecontext.NewContext(context.Background(), nil, log.Discard), &fixedSelector{}, NewTerminalOptions(
+		WithForceTTY(true),
+		WithStreams(nil, io.Discard, strings.NewReader("")),
+	))
+	assert.ErrorContains(t, err, "a forced tty requires stdout")
+}
@@ -0,0 +1,61 @@
+package terminal
+
+import (
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+)
+
+// userSwitchProbeScript prints the first tool found in the container that can run a command as a different user
+const userSwitchProbeScript = `for tool in runuser setpriv su; do
+  if command -v $tool >/dev/null 2>&1; then
+    echo $tool
+    exit 0
+  fi
+done
+exit 1`
+
+// setprivScript runs the command with setpriv as the user given as first argument. Setpriv doesn't
+// change the group by itself, so the primary group of the user is resolved. Users without a passwd
+// entry, which can only be given as uid, run with the same gid and without supplementary groups.
+const setprivScript = `user=$1; shift
+if gid=$(id -g "$user" 2>/dev/null); then
+  exec setpriv --reuid="$user" --regid="$gid" --init-groups -- "$@"
+fi
+exec setpriv --reuid="$user" --regid="$user" --clear-groups -- "$@"`
+
+// userSwitchTool returns the tool runAsUserCommand uses to execute commands as the given user in the
+// container. If the container has no tool to switch the user, an empty string is returned and a
+// warning is printed, so that commands are executed unchanged.
+func userSwitchTool(ctx devspacecontext.Context, container *selector.SelectedPodContainer, user string) string {
+	tool := ""
+	stdout, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{"sh", "-c", userSwitchProbeScript}, nil)
+	if err != nil {
+		ctx.Log().Debugf("Error probing for user switch tools: %s %v", string(stderr), err)
+	} else {
+		tool = strings.TrimSpace(string(stdout))
+	}
+
+	if tool == "" {
+		ctx.Log().Warnf("Cannot run terminal as user %s, because neither runuser, setpriv nor su is available in the container", user)
+	}
+
+	return tool
+}
+
+// runAsUserCommand wraps the command with the given tool to execute it as user. Without a tool the
+// command is returned unchanged.
+func runAsUserCommand(command []string, user string, tool string) []string {
+	switch tool {
+	case "runuser":
+		return append([]string{"runuser", "-u", user, "--"}, command...)
+	case "setpriv":
+		return append([]string{"sh", "-c", setprivScript, "setpriv", user}, command...)
+	case "su":
+		return []string{"su", "-", user, "-c", shellquote.Join(command...)}
+	default:
+		return command
+	}
+}
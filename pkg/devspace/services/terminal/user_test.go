@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type runAsUserCommandTestCase struct {
+	name    string
+	command []string
+	user    string
+	tool    string
+
+	expected []string
+}
+
+func TestRunAsUserCommand(t *testing.T) {
+	testCases := []runAsUserCommandTestCase{
+		{
+			name:     "su",
+			command:  []string{"sh", "-c", "cd /app; exec bash"},
+			user:     "app",
+			tool:     "su",
+			expected: []string{"su", "-", "app", "-c", "sh -c 'cd /app; exec bash'"},
+		},
+		{
+			name:     "runuser",
+			command:  []string{"sh", "-c", "exec bash"},
+			user:     "app",
+			tool:     "runuser",
+			expected: []string{"runuser", "-u", "app", "--", "sh", "-c", "exec bash"},
+		},
+		{
+			name:     "setpriv",
+			command:  []string{"sh"},
+			user:     "1000",
+			tool:     "setpriv",
+			expected: []string{"sh", "-c", setprivScript, "setpriv", "1000", "sh"},
+		},
+		{
+			name:     "Fallback",
+			command:  []string{"sh", "-c", "exec bash"},
+			user:     "app",
+			expected: []string{"sh", "-c", "exec bash"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		command := runAsUserCommand(testCase.command, testCase.user, testCase.tool)
+		assert.DeepEqual(t, command, testCase.expected)
+	}
+}
+
+type setprivScriptTestCase struct {
+	name string
+	user string
+
+	expected string
+}
+
+func TestSetprivScript(t *testing.T) {
+	for _, tool := range []string{"sh", "id"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not available", tool)
+		}
+	}
+
+	// the fake setpriv prints its arguments instead of switching the user
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "setpriv"), []byte("#!/bin/sh\necho \"$@\"\n"), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	testCases := []setprivScriptTestCase{
+		{
+			name:     "User with passwd entry",
+			user:     "root",
+			expected: "--reuid=root --regid=0 --init-groups -- echo test",
+		},
+		{
+			name:     "Uid without passwd entry",
+			user:     "54321",
+			expected: "--reuid=54321 --regid=54321 --clear-groups -- echo test",
+		},
+	}
+
+	for _, testCase := range testCases {
+		command := runAsUserCommand([]string{"echo", "test"}, testCase.user, "setpriv")
+		out, err := exec.Command(command[0], command[1:]...).Output()
+		assert.NilError(t, err, "Unexpected error in test case %s", testCase.name)
+		assert.Equal(t, strings.TrimSpace(string(out)), testCase.expected, "Unexpected setpriv arguments in test case %s", testCase.name)
+	}
+}
@@ -0,0 +1,25 @@
+package terminal
+
+import (
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+)
+
+// watchSession logs how long the session to the container is connected every interval until
+// stop is closed. The messages go to the given side log and never to the terminal itself.
+func watchSession(logger log.Logger, container *selector.SelectedPodContainer, interval time.Duration, stop <-chan struct{}) {
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			logger.Infof("Terminal to pod %s (container %s) open for %s", container.Pod.Name, container.Container.Name, time.Since(start).Round(time.Second))
+		}
+	}
+}
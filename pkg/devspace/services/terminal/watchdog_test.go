@@ -0,0 +1,39 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatchSession(t *testing.T) {
+	out := &bytes.Buffer{}
+	container := &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod"}},
+		Container: &corev1.Container{Name: "test-container"},
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		watchSession(log.NewStreamLogger(out, out, logrus.InfoLevel), container, time.Millisecond*10, stop)
+		close(done)
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Expected watchdog to stop")
+	}
+
+	assert.Assert(t, bytes.Contains(out.Bytes(), []byte("Terminal to pod test-pod (container test-container) open for")), "Unexpected output: %s", out.String())
+}
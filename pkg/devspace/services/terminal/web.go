@@ -0,0 +1,146 @@
+package terminal
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// ResizeMessage is the message a web frontend sends if the size of its terminal changed
+type ResizeMessage struct {
+	Cols uint16 `json:"cols"`
+	Rows uint16 `json:"rows"`
+}
+
+// WebTerminal adapts the streams of a terminal to a web frontend, e.g. by bridging them to a websocket.
+// The frontend writes its input to and reads the output from Stdio and reports size changes with
+// Resize or HandleResizeMessage.
+type WebTerminal struct {
+	stdinReader  *io.PipeReader
+	stdinWriter  *io.PipeWriter
+	stdoutReader *io.PipeReader
+	stdoutWriter *io.PipeWriter
+	stderrReader *io.PipeReader
+	stderrWriter *io.PipeWriter
+
+	// sizes holds the latest size that wasn't reported yet
+	sizes chan remotecommand.TerminalSize
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// NewWebTerminal creates a web terminal, which reports the given size once the terminal is started
+func NewWebTerminal(cols, rows uint16) *WebTerminal {
+	w := &WebTerminal{
+		sizes:  make(chan remotecommand.TerminalSize, 1),
+		closed: make(chan struct{}),
+	}
+	w.stdinReader, w.stdinWriter = io.Pipe()
+	w.stdoutReader, w.stdoutWriter = io.Pipe()
+	w.stderrReader, w.stderrWriter = io.Pipe()
+	if cols > 0 && rows > 0 {
+		w.Resize(cols, rows)
+	}
+
+	return w
+}
+
+// Stdio returns the input and output of the terminal, writes are sent to stdin of the command and
+// reads return its stdout. As the session has a tty, stdout also contains the output to stderr.
+func (w *WebTerminal) Stdio() io.ReadWriter {
+	return struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: w.stdoutReader,
+		Writer: w.stdinWriter,
+	}
+}
+
+// Stderr returns the output to stderr, which only contains messages of DevSpace, e.g. the stale build warning
+func (w *WebTerminal) Stderr() io.Reader {
+	return w.stderrReader
+}
+
+// Resize reports a new size of the terminal, sizes that weren't reported yet are replaced
+func (w *WebTerminal) Resize(cols, rows uint16) {
+	size := remotecommand.TerminalSize{Width: cols, Height: rows}
+	for {
+		select {
+		case w.sizes <- size:
+			return
+		default:
+		}
+
+		select {
+		case <-w.sizes:
+		default:
+		}
+	}
+}
+
+// HandleResizeMessage parses a json ResizeMessage and reports the size
+func (w *WebTerminal) HandleResizeMessage(message []byte) error {
+	resize := ResizeMessage{}
+	err := json.Unmarshal(message, &resize)
+	if err != nil {
+		return errors.Wrap(err, "parse resize message")
+	} else if resize.Cols == 0 || resize.Rows == 0 {
+		return errors.Errorf("invalid terminal size %dx%d", resize.Cols, resize.Rows)
+	}
+
+	w.Resize(resize.Cols, resize.Rows)
+	return nil
+}
+
+// Next implements remotecommand.TerminalSizeQueue and blocks until the size changes. It returns nil
+// once the web terminal is closed, which stops the size updates.
+func (w *WebTerminal) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-w.sizes:
+		return &size
+	case <-w.closed:
+		return nil
+	}
+}
+
+// Options returns the options that connect the terminal to the web terminal
+func (w *WebTerminal) Options() []OptionFunc {
+	return []OptionFunc{
+		WithTTY(true),
+		WithTerminalSizeQueue(w),
+		WithStreams(w.stdoutWriter, w.stderrWriter, w.stdinReader),
+	}
+}
+
+// Close ends the input of the terminal and the size updates. Reads from Stdio and Stderr return io.EOF
+// once the output was read completely.
+func (w *WebTerminal) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.closed)
+		_ = w.stdinWriter.Close()
+		_ = w.stdoutWriter.Close()
+		_ = w.stderrWriter.Close()
+	})
+
+	return nil
+}
+
+// StartWebTerminal starts a terminal like StartTerminalFromCMDWithOptions that is connected to the web terminal
+// and closes the web terminal once it has ended
+func StartWebTerminal(
+	ctx devspacecontext.Context,
+	selector targetselector.TargetSelector,
+	web *WebTerminal,
+	options ...OptionFunc,
+) (int, error) {
+	defer web.Close()
+
+	return StartTerminalFromCMDWithOptions(ctx, selector, NewTerminalOptions(append(options, web.Options()...)...))
+}
@@ -0,0 +1,78 @@
+package terminal
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	kubectltesting "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// echoExecClient copies stdin to stdout until stdin is closed
+type echoExecClient struct {
+	kubectltesting.Client
+
+	options *kubectl.ExecStreamOptions
+}
+
+func (c *echoExecClient) ExecStream(ctx context.Context, options *kubectl.ExecStreamOptions) error {
+	c.options = options
+	_, err := io.Copy(options.Stdout, options.Stdin)
+	return err
+}
+
+func TestWebTerminalResize(t *testing.T) {
+	web := NewWebTerminal(80, 24)
+	assert.DeepEqual(t, web.Next(), &remotecommand.TerminalSize{Width: 80, Height: 24})
+
+	// sizes that weren't reported yet are replaced
+	web.Resize(100, 30)
+	assert.NilError(t, web.HandleResizeMessage([]byte(`{"cols":120,"rows":40}`)))
+	assert.DeepEqual(t, web.Next(), &remotecommand.TerminalSize{Width: 120, Height: 40})
+
+	assert.ErrorContains(t, web.HandleResizeMessage([]byte(`{"cols":120}`)), "invalid terminal size 120x0")
+	assert.ErrorContains(t, web.HandleResizeMessage([]byte(`resize`)), "parse resize message")
+
+	assert.NilError(t, web.Close())
+	assert.Assert(t, web.Next() == nil)
+}
+
+func TestStartWebTerminal(t *testing.T) {
+	client := &echoExecClient{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+
+	web := NewWebTerminal(80, 24)
+	done := make(chan error)
+	go func() {
+		_, err := StartWebTerminal(ctx, podSelector, web, WithCommand([]string{"sh"}), WithDisableSessionFile(true))
+		done <- err
+	}()
+
+	stdio := web.Stdio()
+	_, err := stdio.Write([]byte("hello"))
+	assert.NilError(t, err)
+	out := make([]byte, 5)
+	_, err = io.ReadFull(stdio, out)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "hello")
+
+	// closing the input ends the session, which ends the output
+	assert.NilError(t, web.stdinWriter.Close())
+	_, err = stdio.Read(out)
+	assert.Equal(t, err, io.EOF)
+	assert.NilError(t, <-done)
+	assert.Assert(t, client.options.ForceTTY)
+	assert.Equal(t, client.options.TerminalSizeQueue, remotecommand.TerminalSizeQueue(web))
+}
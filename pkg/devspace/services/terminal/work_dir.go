@@ -0,0 +1,33 @@
+package terminal
+
+import (
+	"fmt"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/pkg/errors"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// validateWorkDir checks that the work dir exists in the container, as the cd of the command would
+// otherwise fail and leave the user in a different directory. If create is true, a missing work dir
+// is created instead, as the given user with the tool of userSwitchTool, so that the user can write to it.
+// The work dir is expanded by the shell the same way as by the cd of the command.
+func validateWorkDir(ctx devspacecontext.Context, container *selector.SelectedPodContainer, workDir string, create bool, user string, userTool string) error {
+	_, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{"sh", "-c", "test -d " + workDir}, nil)
+	if err == nil {
+		return nil
+	} else if exitError, ok := err.(kubectlExec.CodeExitError); !ok || exitError.Code != 1 {
+		return errors.Errorf("error checking work dir %s in container %s: %s %v", workDir, container.Container.Name, string(stderr), err)
+	} else if !create {
+		return fmt.Errorf("work dir %s does not exist in container %s, please create it or change terminal.workDir", workDir, container.Container.Name)
+	}
+
+	ctx.Log().Infof("Creating work dir %s in container %s", workDir, container.Container.Name)
+	_, stderr, err = ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, runAsUserCommand([]string{"sh", "-c", "mkdir -p " + workDir}, user, userTool), nil)
+	if err != nil {
+		return errors.Errorf("error creating work dir %s in container %s: %s %v", workDir, container.Container.Name, string(stderr), err)
+	}
+
+	return nil
+}
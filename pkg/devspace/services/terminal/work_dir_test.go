@@ -0,0 +1,133 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubectlExec "k8s.io/client-go/util/exec"
+)
+
+// workDirExecClient simulates the directories of a container for test -d and mkdir -p
+type workDirExecClient struct {
+	recordingExecClient
+
+	dirs     map[string]bool
+	buffered []string
+
+	// userTool is returned by the user switch probe
+	userTool string
+	mkdir    []string
+}
+
+func (c *workDirExecClient) ExecBuffered(ctx context.Context, pod *corev1.Pod, container string, command []string, input io.Reader) ([]byte, []byte, error) {
+	script := command[len(command)-1]
+	if script == userSwitchProbeScript {
+		return []byte(c.userTool + "\n"), nil, nil
+	}
+
+	c.buffered = append(c.buffered, script)
+	if dir := strings.TrimPrefix(script, "test -d "); dir != script && !c.dirs[dir] {
+		return nil, nil, kubectlExec.CodeExitError{Err: fmt.Errorf("exit 1"), Code: 1}
+	} else if dir := strings.TrimPrefix(script, "mkdir -p "); dir != script {
+		c.dirs[dir] = true
+		c.mkdir = command
+	}
+
+	return nil, nil, nil
+}
+
+type validateWorkDirTestCase struct {
+	name          string
+	dirs          map[string]bool
+	createWorkDir bool
+
+	expectedBuffered []string
+	expectedErr      string
+}
+
+func TestStartTerminalValidateWorkDir(t *testing.T) {
+	testCases := []validateWorkDirTestCase{
+		{
+			name:             "Existing work dir",
+			dirs:             map[string]bool{"/app": true},
+			expectedBuffered: []string{"test -d /app"},
+		},
+		{
+			name:             "Missing work dir",
+			dirs:             map[string]bool{},
+			expectedBuffered: []string{"test -d /app"},
+			expectedErr:      "work dir /app does not exist in container app, please create it or change terminal.workDir",
+		},
+		{
+			name:             "Missing work dir is created",
+			dirs:             map[string]bool{},
+			createWorkDir:    true,
+			expectedBuffered: []string{"test -d /app", "mkdir -p /app"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		client := &workDirExecClient{dirs: testCase.dirs}
+		ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+		podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+			Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+			Container: &corev1.Container{Name: "app"},
+		}}
+		devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+			Command:            "bash",
+			Shell:              "sh",
+			WorkDir:            "/app",
+			DisableScreen:      true,
+			DisableSessionFile: true,
+		}}
+
+		err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+			WithValidateWorkDir(true, testCase.createWorkDir),
+			WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+		))
+		assert.DeepEqual(t, client.buffered, testCase.expectedBuffered)
+		if testCase.expectedErr != "" {
+			assert.Error(t, err, testCase.expectedErr, "Unexpected error in test case %s", testCase.name)
+			assert.Assert(t, client.options == nil, "Expected no exec in test case %s", testCase.name)
+			continue
+		}
+		assert.NilError(t, err, "Unexpected error in test case %s", testCase.name)
+		assert.Assert(t, client.options != nil, "Expected exec in test case %s", testCase.name)
+	}
+}
+
+func TestStartTerminalCreateWorkDirAsUser(t *testing.T) {
+	client := &workDirExecClient{dirs: map[string]bool{}, userTool: "runuser"}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(client)
+	podSelector := &fixedSelector{container: &selector.SelectedPodContainer{
+		Pod:       &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}},
+		Container: &corev1.Container{Name: "app"},
+	}}
+	devContainer := &latest.DevContainer{Terminal: &latest.Terminal{
+		Command:            "bash",
+		Shell:              "sh",
+		WorkDir:            "/app",
+		RunAsUser:          "dev",
+		DisableScreen:      true,
+		DisableSessionFile: true,
+	}}
+
+	err := StartTerminalWithOptions(ctx, devContainer, podSelector, &tomb.Tomb{}, NewTerminalOptions(
+		WithValidateWorkDir(true, true),
+		WithStreams(io.Discard, io.Discard, strings.NewReader("")),
+	))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, client.mkdir, []string{"runuser", "-u", "dev", "--", "sh", "-c", "mkdir -p /app"})
+	assert.Assert(t, strings.Contains(strings.Join(client.options.Command, " "), "runuser -u dev -- sh -c"), "Expected the shell to run as dev, got %v", client.options.Command)
+}
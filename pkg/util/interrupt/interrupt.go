@@ -45,6 +45,7 @@ type Handler struct {
 
 	channelMutex sync.Mutex
 	channel      chan os.Signal
+	stops        int
 }
 
 type notify struct {
@@ -125,12 +126,18 @@ func (h *Handler) RunAlways(fn func() error, notify func()) error {
 	return fn()
 }
 
-// Start ensures the handler is started and ready for incoming signals
+// Start ensures the handler is started and ready for incoming signals. Calls to Stop are counted,
+// so that nested sessions, e.g. a terminal that is opened while another one is running, don't start
+// the handler while the outer one is still stopped. Every Stop has to be followed by exactly one Start,
+// which is easiest to guarantee with defer.
 func (h *Handler) Start() {
 	h.channelMutex.Lock()
 	defer h.channelMutex.Unlock()
 
-	if h.channel != nil {
+	if h.stops > 0 {
+		h.stops--
+	}
+	if h.channel != nil || h.stops > 0 {
 		return
 	}
 
@@ -145,14 +152,24 @@ func (h *Handler) Start() {
 	}(h.channel)
 }
 
-// Stop ensures we do not watch for incoming signals anymore
+// Stop ensures we do not watch for incoming signals anymore until Start was called for this and
+// every other Stop
 func (h *Handler) Stop() {
 	h.channelMutex.Lock()
 	defer h.channelMutex.Unlock()
 
+	h.stops++
 	if h.channel != nil {
 		signal.Stop(h.channel)
 		close(h.channel)
 		h.channel = nil
 	}
 }
+
+// Started returns true if the handler is currently watching for incoming signals
+func (h *Handler) Started() bool {
+	h.channelMutex.Lock()
+	defer h.channelMutex.Unlock()
+
+	return h.channel != nil
+}
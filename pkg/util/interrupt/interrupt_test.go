@@ -0,0 +1,34 @@
+package interrupt
+
+import (
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestNestedStopStart(t *testing.T) {
+	handler := New(func(os.Signal) {})
+	handler.Start()
+	assert.Assert(t, handler.Started())
+
+	// outer session
+	handler.Stop()
+	assert.Assert(t, !handler.Started())
+
+	// nested session ends before the outer one
+	handler.Stop()
+	handler.Start()
+	assert.Assert(t, !handler.Started(), "Expected the handler to stay stopped until the outer session has ended")
+
+	handler.Start()
+	assert.Assert(t, handler.Started())
+
+	// additional starts don't make a later stop ineffective
+	handler.Start()
+	handler.Stop()
+	assert.Assert(t, !handler.Started())
+	handler.Start()
+	assert.Assert(t, handler.Started())
+	handler.Stop()
+}
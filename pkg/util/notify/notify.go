@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// DesktopNotifyFunc shows a desktop notification after a terminal session has ended.
+// It matches the signature of terminal.NotifyFunc and can be used as TerminalOptions.OnSessionEnd.
+func DesktopNotifyFunc(podName, containerName string, exitCode int, err error) {
+	_ = Desktop("DevSpace", SessionEndMessage(podName, containerName, exitCode, err))
+}
+
+// SessionEndMessage returns the notification message for an ended terminal session
+func SessionEndMessage(podName, containerName string, exitCode int, err error) string {
+	if exitCode < 0 {
+		return fmt.Sprintf("Terminal to %s:%s ended: %v", podName, containerName, err)
+	}
+
+	return fmt.Sprintf("Terminal to %s:%s exited with code %d", podName, containerName, exitCode)
+}
+
+// Desktop shows a desktop notification with the given title and message
+func Desktop(title, message string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", message, title)).Run()
+	case "linux":
+		return exec.Command("notify-send", title, message).Run()
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+}
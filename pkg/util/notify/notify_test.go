@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type sessionEndMessageTestCase struct {
+	name     string
+	exitCode int
+	err      error
+
+	expected string
+}
+
+func TestSessionEndMessage(t *testing.T) {
+	testCases := []sessionEndMessageTestCase{
+		{
+			name:     "Success",
+			expected: "Terminal to pod:container exited with code 0",
+		},
+		{
+			name:     "Exit code",
+			exitCode: 2,
+			err:      fmt.Errorf("command terminated with exit code 2"),
+			expected: "Terminal to pod:container exited with code 2",
+		},
+		{
+			name:     "Lost connection",
+			exitCode: -1,
+			err:      fmt.Errorf("lost connection"),
+			expected: "Terminal to pod:container ended: lost connection",
+		},
+	}
+
+	for _, testCase := range testCases {
+		message := SessionEndMessage("pod", "container", testCase.exitCode, testCase.err)
+		assert.Equal(t, message, testCase.expected, "Unexpected message in "+testCase.name)
+	}
+}
@@ -0,0 +1,60 @@
+package proctitle
+
+import "sync"
+
+// MaxLength is the number of bytes of a title that are shown on linux, longer titles are truncated
+const MaxLength = 15
+
+var (
+	original string
+	titles   []*string
+	m        sync.Mutex
+)
+
+// Set changes the title of the current process as it is shown by ps and top, which helps to identify
+// DevSpace processes, and returns the function that removes the title again. The title might be
+// truncated to MaxLength by the operating system. If the title is set by multiple callers, the
+// most recent title that wasn't removed yet is shown and the original title is restored once the
+// last caller removed its title.
+func Set(title string) (func() error, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if len(titles) == 0 {
+		current, err := get()
+		if err != nil {
+			return nil, err
+		}
+
+		original = current
+	}
+
+	entry := &title
+	titles = append(titles, entry)
+	err := set(title)
+	if err != nil {
+		titles = titles[:len(titles)-1]
+		return nil, err
+	}
+
+	return func() error {
+		return remove(entry)
+	}, nil
+}
+
+func remove(entry *string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for i, title := range titles {
+		if title == entry {
+			titles = append(titles[:i], titles[i+1:]...)
+			break
+		}
+	}
+	if len(titles) == 0 {
+		return set(original)
+	}
+
+	return set(*titles[len(titles)-1])
+}
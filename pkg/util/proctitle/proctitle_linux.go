@@ -0,0 +1,29 @@
+//go:build linux
+// +build linux
+
+package proctitle
+
+import (
+	"os"
+	"strings"
+)
+
+// commPath is the name of the current process, which is limited to MaxLength bytes
+const commPath = "/proc/self/comm"
+
+func get() (string, error) {
+	out, err := os.ReadFile(commPath)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func set(title string) error {
+	if len(title) > MaxLength {
+		title = title[:MaxLength]
+	}
+
+	return os.WriteFile(commPath, []byte(title), 0644)
+}
@@ -0,0 +1,39 @@
+//go:build linux
+// +build linux
+
+package proctitle
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSetAndReset(t *testing.T) {
+	before, err := get()
+	assert.NilError(t, err)
+
+	resetFirst, err := Set("ds:first-container")
+	assert.NilError(t, err)
+	title, err := get()
+	assert.NilError(t, err)
+	assert.Equal(t, title, "ds:first-contai")
+
+	// a concurrent caller shows its own title until it removes it
+	resetSecond, err := Set("ds:second")
+	assert.NilError(t, err)
+	title, err = get()
+	assert.NilError(t, err)
+	assert.Equal(t, title, "ds:second")
+
+	// removing a title of an earlier caller keeps the title of the active one
+	assert.NilError(t, resetFirst())
+	title, err = get()
+	assert.NilError(t, err)
+	assert.Equal(t, title, "ds:second")
+
+	assert.NilError(t, resetSecond())
+	title, err = get()
+	assert.NilError(t, err)
+	assert.Equal(t, title, before)
+}
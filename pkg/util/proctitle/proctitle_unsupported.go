@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package proctitle
+
+// get and set do nothing on darwin, the bsds and windows, as changing the title
+// requires to overwrite the arguments of the process there
+func get() (string, error) {
+	return "", nil
+}
+
+func set(title string) error {
+	return nil
+}
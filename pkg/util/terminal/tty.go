@@ -22,9 +22,11 @@ func SetupTTY(stdin io.Reader, stdout io.Writer) (bool, term.TTY) {
 	// can safely set t.Raw to true
 	t.Raw = true
 
+	// only replace stdout if it is a terminal itself, otherwise writers that wrap
+	// stdout (e.g. to additionally write the output to a file) would be skipped
 	newStdin, newStdout, _ := dockerterm.StdStreams()
 	t.In = newStdin
-	if stdout != nil {
+	if _, isTerminal := dockerterm.GetFdInfo(stdout); stdout != nil && isTerminal {
 		t.Out = newStdout
 	}
 